@@ -0,0 +1,126 @@
+// Package analyzer provides go/analysis checker of shared objects for two common mistakes:
+//   - parameter of constructor (or field of its config struct) is not passed to NewSharedObjectBase,
+//     so objects with different parameters get same ID and are replaced by each other upon registration;
+//   - object is subscribed to updates using SubscribeObj or Subscribe, but its update handler is never set.
+//
+// The checker can be run using cmd/shdepvet, e.g. "go vet -vettool=$(which shdepvet) ./...".
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "shdep",
+	Doc:      "check constructors and subscriptions of shared objects based on shdep.SharedObjectBase",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+const (
+	shdepPkg = "github.com/nnikolash/go-shdep"
+	stdPkg   = "github.com/nnikolash/go-shdep/std"
+)
+
+// Constructors of base objects, which calculate ID of the object from their parameters.
+// Parameters of constructors with suffix "Opts" are passed using options (see hashOptions).
+var baseConstructors = map[string]map[string]bool{
+	shdepPkg: {
+		"NewSharedObjectBase":              true,
+		"NewSharedObjectBaseWithEvent":     true,
+		"NewSharedObjectBaseOpts":          true,
+		"NewSharedObjectBaseWithEventOpts": true,
+	},
+	stdPkg: {
+		"NewObjectBase":              true,
+		"NewObjectBaseWithEvent":     true,
+		"NewObjectBaseOpts":          true,
+		"NewObjectBaseWithEventOpts": true,
+	},
+}
+
+// Options of shdep package, arguments of which are parameters of the object, from which its ID is calculated.
+var hashOptions = map[string]bool{"WithHashParams": true, "WithExplicitHash": true}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	checkConstructors(pass, insp)
+	checkUpdateHandlers(pass, insp)
+
+	return nil, nil
+}
+
+// calledFunc returns function or method called by the call expression, or nil.
+func calledFunc(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	if index, ok := fun.(*ast.IndexExpr); ok {
+		fun = index.X
+	} else if index, ok := fun.(*ast.IndexListExpr); ok {
+		fun = index.X
+	}
+
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return nil
+	}
+
+	fn, _ := pass.TypesInfo.Uses[id].(*types.Func)
+	return fn
+}
+
+func isBaseConstructor(fn *types.Func) bool {
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+
+	return baseConstructors[fn.Pkg().Path()][fn.Name()]
+}
+
+// isShdepFunc reports whether fn is a function with the name defined in shdep package.
+func isShdepFunc(fn *types.Func, names map[string]bool) bool {
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == shdepPkg && names[fn.Name()]
+}
+
+// constructorOptions returns calls of options passed into constructor of base object with suffix "Opts".
+// Returns false if options can't be determined, e.g. if they are built elsewhere and passed as a slice.
+func constructorOptions(pass *analysis.Pass, call *ast.CallExpr) ([]*ast.CallExpr, bool) {
+	if call.Ellipsis.IsValid() || len(call.Args) == 0 {
+		return nil, false
+	}
+
+	opts := make([]*ast.CallExpr, 0, len(call.Args)-1)
+	for _, arg := range call.Args[1:] {
+		opt, ok := ast.Unparen(arg).(*ast.CallExpr)
+		if !ok {
+			return nil, false
+		}
+		opts = append(opts, opt)
+	}
+
+	return opts, true
+}
+
+// isShdepMethod reports whether fn is a method with the name defined in shdep or updtree package.
+func isShdepMethod(fn *types.Func, name string) bool {
+	if fn == nil || fn.Pkg() == nil || fn.Name() != name {
+		return false
+	}
+
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() == nil {
+		return false
+	}
+
+	path := fn.Pkg().Path()
+	return path == shdepPkg || path == shdepPkg+"/updtree"
+}
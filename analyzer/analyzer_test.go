@@ -0,0 +1,12 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"github.com/nnikolash/go-shdep/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "a")
+}
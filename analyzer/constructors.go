@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// checkConstructors reports parameters of functions, which are not passed into constructors of base objects.
+// Parameters of interface, function and channel types (and slices of them) are not checked, because they are usually
+// dependencies like logger or options rather than parameters of the object.
+func checkConstructors(pass *analysis.Pass, insp *inspector.Inspector) {
+	insp.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		call := n.(*ast.CallExpr)
+		fn := calledFunc(pass, call)
+		if !isBaseConstructor(fn) {
+			return true
+		}
+
+		args, ok := hashArgs(pass, fn, call)
+		if !ok {
+			return true
+		}
+
+		// Only constructors are checked, i.e. functions returning the object.
+		funcType := enclosingFuncType(stack)
+		if funcType == nil || funcType.Params == nil || funcType.Results == nil {
+			return true
+		}
+
+		fullyUsed, usedFields := collectArgsUsage(pass, args)
+
+		for _, field := range funcType.Params.List {
+			for _, name := range field.Names {
+				param, ok := pass.TypesInfo.Defs[name].(*types.Var)
+				if !ok || name.Name == "_" || fullyUsed[param] || !isParameterType(param.Type()) {
+					continue
+				}
+
+				st, isStruct := structOf(param.Type())
+				if !isStruct || len(usedFields[param]) == 0 {
+					pass.Reportf(call.Pos(), "parameter %v is not passed to %v, so objects with different %v get same ID",
+						param.Name(), fn.Name(), param.Name())
+					continue
+				}
+
+				for i := 0; i < st.NumFields(); i++ {
+					f := st.Field(i)
+					if !usedFields[param][f.Name()] && isParameterType(f.Type()) {
+						pass.Reportf(call.Pos(), "field %v of %v is not passed to %v, so objects with different %v.%v get same ID",
+							f.Name(), param.Name(), fn.Name(), param.Name(), f.Name())
+					}
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+// hashArgs returns arguments of the constructor, from which ID of the object is calculated:
+// all arguments of regular constructors, and name and arguments of hash options of constructors with suffix "Opts".
+// Returns false if they can't be determined.
+func hashArgs(pass *analysis.Pass, fn *types.Func, call *ast.CallExpr) ([]ast.Expr, bool) {
+	if !strings.HasSuffix(fn.Name(), "Opts") {
+		return call.Args, true
+	}
+
+	opts, ok := constructorOptions(pass, call)
+	if !ok {
+		return nil, false
+	}
+
+	args := []ast.Expr{call.Args[0]}
+	for _, opt := range opts {
+		if isShdepFunc(calledFunc(pass, opt), hashOptions) {
+			args = append(args, opt.Args...)
+		}
+	}
+
+	return args, true
+}
+
+// enclosingFuncType returns type of the innermost function declaration or literal in the stack.
+func enclosingFuncType(stack []ast.Node) *ast.FuncType {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch f := stack[i].(type) {
+		case *ast.FuncDecl:
+			return f.Type
+		case *ast.FuncLit:
+			return f.Type
+		}
+	}
+
+	return nil
+}
+
+// collectArgsUsage returns variables used in the arguments as a whole, and fields of variables
+// accessed in the arguments. Calling method of a variable counts as using it as a whole, e.g. cfg.String().
+func collectArgsUsage(pass *analysis.Pass, args []ast.Expr) (fullyUsed map[*types.Var]bool, usedFields map[*types.Var]map[string]bool) {
+	fullyUsed = make(map[*types.Var]bool)
+	usedFields = make(map[*types.Var]map[string]bool)
+
+	for _, arg := range args {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			switch e := n.(type) {
+			case *ast.SelectorExpr:
+				x, ok := ast.Unparen(e.X).(*ast.Ident)
+				if !ok {
+					return true
+				}
+				v, ok := pass.TypesInfo.Uses[x].(*types.Var)
+				if !ok {
+					return true
+				}
+
+				if sel, ok := pass.TypesInfo.Selections[e]; ok && sel.Kind() == types.FieldVal {
+					if usedFields[v] == nil {
+						usedFields[v] = make(map[string]bool)
+					}
+					usedFields[v][e.Sel.Name] = true
+				} else {
+					fullyUsed[v] = true
+				}
+				return false
+			case *ast.Ident:
+				if v, ok := pass.TypesInfo.Uses[e].(*types.Var); ok {
+					fullyUsed[v] = true
+				}
+			}
+
+			return true
+		})
+	}
+
+	return fullyUsed, usedFields
+}
+
+func isParameterType(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Interface, *types.Signature, *types.Chan:
+		return false
+	case *types.Slice:
+		// E.g. variadic options.
+		return isParameterType(u.Elem())
+	default:
+		return true
+	}
+}
+
+// structOf returns struct type of the value or of the value pointed to.
+func structOf(t types.Type) (*types.Struct, bool) {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	st, ok := t.Underlying().(*types.Struct)
+	return st, ok
+}
@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// checkUpdateHandlers reports subscriptions of objects of types defined in the package,
+// for which SetUpdateHandler is never called in the package and which don't have method OnDependenciesUpdated.
+func checkUpdateHandlers(pass *analysis.Pass, insp *inspector.Inspector) {
+	type subscription struct {
+		call *ast.CallExpr
+		typ  *types.Named
+	}
+
+	var subscriptions []subscription
+	handlerSet := make(map[*types.Named]bool)
+
+	insp.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		call := n.(*ast.CallExpr)
+		fn := calledFunc(pass, call)
+
+		switch {
+		case isShdepMethod(fn, "SetUpdateHandler"):
+			if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+				if named := localNamed(pass, pass.TypesInfo.TypeOf(sel.X)); named != nil {
+					handlerSet[named] = true
+				}
+			}
+		case isBaseConstructor(fn) && strings.HasSuffix(fn.Name(), "Opts"):
+			if !hasUpdateHandlerOption(pass, call) {
+				return true
+			}
+			if named := constructedType(pass, stack); named != nil {
+				handlerSet[named] = true
+			}
+		case isShdepMethod(fn, "SubscribeObj") || isShdepMethod(fn, "Subscribe"):
+			if len(call.Args) != 1 {
+				return true
+			}
+			if named := localNamed(pass, pass.TypesInfo.TypeOf(call.Args[0])); named != nil {
+				subscriptions = append(subscriptions, subscription{call: call, typ: named})
+			}
+		}
+
+		return true
+	})
+
+	for _, s := range subscriptions {
+		if !handlerSet[s.typ] && hasBaseUpdateHandler(s.typ) && !hasMethod(s.typ, "OnDependenciesUpdated") {
+			pass.Reportf(s.call.Pos(), "%v is subscribed to updates, but its update handler is never set using SetUpdateHandler",
+				s.typ.Obj().Name())
+		}
+	}
+}
+
+// hasUpdateHandlerOption reports whether update handler is set by options of constructor with suffix "Opts".
+func hasUpdateHandlerOption(pass *analysis.Pass, call *ast.CallExpr) bool {
+	opts, _ := constructorOptions(pass, call)
+	for _, opt := range opts {
+		if isShdepFunc(calledFunc(pass, opt), map[string]bool{"WithUpdateHandler": true}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// constructedType returns type of the object, base of which is created by the call on top of the stack,
+// e.g. T in "&T{Base: NewSharedObjectBaseOpts(...)}" or in "t.Base = NewSharedObjectBaseOpts(...)".
+func constructedType(pass *analysis.Pass, stack []ast.Node) *types.Named {
+	if len(stack) < 2 {
+		return nil
+	}
+
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.KeyValueExpr:
+		if len(stack) >= 3 {
+			if lit, ok := stack[len(stack)-3].(*ast.CompositeLit); ok {
+				return localNamed(pass, pass.TypesInfo.TypeOf(lit))
+			}
+		}
+	case *ast.CompositeLit:
+		return localNamed(pass, pass.TypesInfo.TypeOf(parent))
+	case *ast.AssignStmt:
+		if len(parent.Lhs) == 1 {
+			if sel, ok := ast.Unparen(parent.Lhs[0]).(*ast.SelectorExpr); ok {
+				return localNamed(pass, pass.TypesInfo.TypeOf(sel.X))
+			}
+		}
+	}
+
+	return nil
+}
+
+// localNamed returns named type of the value or of the value pointed to, if it is defined in the analyzed package.
+func localNamed(pass *analysis.Pass, t types.Type) *types.Named {
+	if t == nil {
+		return nil
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() != pass.Pkg {
+		return nil
+	}
+
+	return named.Origin()
+}
+
+// hasBaseUpdateHandler reports whether SetUpdateHandler of the type is promoted from SharedObjectBase.
+// Other types can set update handler differently, e.g. by passing it into updtree.NewNode.
+func hasBaseUpdateHandler(named *types.Named) bool {
+	sel := types.NewMethodSet(types.NewPointer(named)).Lookup(nil, "SetUpdateHandler")
+	if sel == nil {
+		return false
+	}
+
+	fn, ok := sel.Obj().(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == shdepPkg
+}
+
+// hasMethod reports whether pointer to the type has exported method with the name.
+func hasMethod(named *types.Named, name string) bool {
+	return types.NewMethodSet(types.NewPointer(named)).Lookup(nil, name) != nil
+}
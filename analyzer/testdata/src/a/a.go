@@ -0,0 +1,133 @@
+package a
+
+import (
+	"context"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/std"
+)
+
+type InitParams struct{}
+
+type Base = shdep.SharedObjectBase[context.Context, *InitParams]
+
+type Counter struct {
+	Base
+}
+
+func NewCounter(start int) *Counter {
+	return &Counter{Base: shdep.NewSharedObjectBase[context.Context, *InitParams]("Counter", start)}
+}
+
+func NewCounterWithoutParam(start int, step int) *Counter {
+	return &Counter{Base: shdep.NewSharedObjectBase[context.Context, *InitParams]("Counter", start)} // want `parameter step is not passed to NewSharedObjectBase`
+}
+
+type MAConfig struct {
+	Period int
+	Source string
+}
+
+type MA struct {
+	Base
+	counter *Counter
+}
+
+func NewMA(cfg MAConfig, l interface{ Infof(string, ...interface{}) }) *MA {
+	ma := &MA{
+		Base:    shdep.NewSharedObjectBase[context.Context, *InitParams]("MA", cfg.Period), // want `field Source of cfg is not passed to NewSharedObjectBase`
+		counter: NewCounter(cfg.Period),
+	}
+	ma.SetUpdateHandler(ma.onUpdated)
+	ma.counter.SubscribeObj(ma)
+
+	return ma
+}
+
+func NewMAFromConfig(cfg *MAConfig) *MA {
+	return &MA{Base: shdep.NewSharedObjectBase[context.Context, *InitParams]("MA", cfg)}
+}
+
+func (ma *MA) onUpdated(ctx context.Context, evtTime time.Time) {}
+
+type Printer struct {
+	Base
+	counter *Counter
+}
+
+func NewPrinter(start int) *Printer {
+	p := &Printer{
+		Base:    shdep.NewSharedObjectBase[context.Context, *InitParams]("Printer", start),
+		counter: NewCounter(start),
+	}
+	p.counter.SubscribeObj(p) // want `Printer is subscribed to updates, but its update handler is never set using SetUpdateHandler`
+
+	return p
+}
+
+type AutoPrinter struct {
+	Base
+	counter *Counter
+}
+
+func NewAutoPrinter(start int) *AutoPrinter {
+	p := &AutoPrinter{
+		Base:    shdep.NewSharedObjectBase[context.Context, *InitParams]("AutoPrinter", start),
+		counter: NewCounter(start),
+	}
+	p.counter.SubscribeObj(p)
+
+	return p
+}
+
+func (p *AutoPrinter) OnDependenciesUpdated(ctx context.Context, evtTime time.Time) {}
+
+type Gauge struct {
+	Base
+	counter *Counter
+}
+
+func NewGauge(period int, source string) *Gauge {
+	g := &Gauge{counter: NewCounter(period)}
+	g.Base = shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("Gauge", // want `parameter source is not passed to NewSharedObjectBaseOpts`
+		shdep.WithHashParams(period), shdep.WithUpdateHandler(g.onUpdated))
+	g.counter.SubscribeObj(g)
+
+	return g
+}
+
+func NewGaugeWithHashParams(period int, source string) *Gauge {
+	return &Gauge{Base: shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("Gauge", shdep.WithHashParams(period, source))}
+}
+
+func NewGaugeWithExplicitHash(period int, source string) *Gauge {
+	return &Gauge{Base: shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("Gauge", shdep.WithExplicitHash(source+string(rune(period))))}
+}
+
+// Options built elsewhere are not checked.
+func NewGaugeWithOpts(period int, opts ...shdep.SharedObjectBaseOption) *Gauge {
+	return &Gauge{Base: shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("Gauge", opts...)}
+}
+
+func (g *Gauge) onUpdated(ctx context.Context, evtTime time.Time) {}
+
+type Level struct {
+	std.ObjectBase[*InitParams]
+}
+
+func NewLevel(value int, unit string) *Level {
+	return &Level{ObjectBase: std.NewObjectBaseOpts[*InitParams]("Level", shdep.WithHashParams(unit))} // want `parameter value is not passed to NewObjectBaseOpts`
+}
+
+type Alarm struct {
+	Base
+}
+
+func NewAlarm(level *Level) *Alarm {
+	a := &Alarm{Base: shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("Alarm",
+		shdep.WithHashParams(level), shdep.WithUpdateHandler(func(ctx context.Context, evtTime time.Time) {}))}
+	level.SubscribeObj(a)
+
+	return a
+}
@@ -0,0 +1,34 @@
+// Package shdep is a stub of the real package for tests of the analyzer.
+package shdep
+
+import "time"
+
+type SharedObject[Ctx, InitParams any] interface {
+	ID() string
+}
+
+type SharedObjectBase[Ctx, InitParams any] struct{}
+
+func NewSharedObjectBase[Ctx, InitParams any](name string, params ...interface{}) SharedObjectBase[Ctx, InitParams] {
+	return SharedObjectBase[Ctx, InitParams]{}
+}
+
+func (o *SharedObjectBase[Ctx, InitParams]) ID() string { return "" }
+
+func (o *SharedObjectBase[Ctx, InitParams]) SubscribeObj(subscriber SharedObject[Ctx, InitParams]) {}
+
+func (o *SharedObjectBase[Ctx, InitParams]) SetUpdateHandler(f func(ctx Ctx, evtTime time.Time)) {}
+
+type SharedObjectBaseOption func()
+
+func WithHashParams(params ...interface{}) SharedObjectBaseOption { return nil }
+
+func WithExplicitHash(hash string) SharedObjectBaseOption { return nil }
+
+func WithUpdateHandler[Ctx any](handler func(ctx Ctx, evtTime time.Time)) SharedObjectBaseOption {
+	return nil
+}
+
+func NewSharedObjectBaseOpts[Ctx, InitParams any](name string, opts ...SharedObjectBaseOption) SharedObjectBase[Ctx, InitParams] {
+	return SharedObjectBase[Ctx, InitParams]{}
+}
@@ -0,0 +1,14 @@
+// Package std is a stub of the real package for tests of the analyzer.
+package std
+
+import (
+	"context"
+
+	"github.com/nnikolash/go-shdep"
+)
+
+type ObjectBase[InitParams any] = shdep.SharedObjectBase[context.Context, InitParams]
+
+func NewObjectBaseOpts[InitParams any](name string, opts ...shdep.SharedObjectBaseOption) ObjectBase[InitParams] {
+	return shdep.NewSharedObjectBaseOpts[context.Context, InitParams](name, opts...)
+}
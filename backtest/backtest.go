@@ -0,0 +1,130 @@
+// Package backtest replays historical data through shared objects.
+// Records are delivered to feeds (e.g. price providers) in order of their time from a single goroutine,
+// so that propagation of updates and results of strategies are deterministic and same for each run.
+package backtest
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Record is a single historical data point, e.g. price of an asset.
+type Record[T any] struct {
+	Time time.Time
+	// Key identifies source of data, e.g. name of an asset. Record is delivered to feeds subscribed to the key.
+	Key   string
+	Value T
+}
+
+// Feed is implemented by objects, which are sources of data for others, e.g. price providers.
+// Feed must update state of the object and notify its subscribers, passing time of the record as event time.
+type Feed[Ctx, T any] interface {
+	Feed(ctx Ctx, rec Record[T])
+}
+
+// FeedFunc adapts function to Feed.
+type FeedFunc[Ctx, T any] func(ctx Ctx, rec Record[T])
+
+func (f FeedFunc[Ctx, T]) Feed(ctx Ctx, rec Record[T]) {
+	f(ctx, rec)
+}
+
+// Clock is a virtual clock of backtest. It shows time of the record being replayed.
+type Clock struct {
+	now time.Time
+}
+
+func (c *Clock) Now() time.Time {
+	return c.now
+}
+
+// Results of the backtest.
+type Results struct {
+	// Number of replayed records. Records without subscribed feeds are not counted.
+	Records int
+	// Time of the first and the last replayed records.
+	Start, End time.Time
+	// Results of collectors by their names.
+	Collected map[string]interface{}
+}
+
+// NewEngine creates engine, which replays records with values of type T.
+// Function newCtx creates context for propagation of each record.
+func NewEngine[Ctx, T any](newCtx func() Ctx) *Engine[Ctx, T] {
+	return &Engine[Ctx, T]{
+		newCtx:     newCtx,
+		clock:      &Clock{},
+		feeds:      make(map[string][]Feed[Ctx, T]),
+		collectors: make(map[string]func() interface{}),
+	}
+}
+
+// Engine replays historical records. It is not thread-safe: it must be set up before the run,
+// e.g. feeds are subscribed from Init or Start of objects, and the run must be the only source of updates.
+type Engine[Ctx, T any] struct {
+	newCtx         func() Ctx
+	clock          *Clock
+	feeds          map[string][]Feed[Ctx, T]
+	collectors     map[string]func() interface{}
+	collectorNames []string
+}
+
+// Clock returns virtual clock of the engine, which objects must use instead of time.Now().
+func (e *Engine[Ctx, T]) Clock() *Clock {
+	return e.clock
+}
+
+// Subscribe adds feed, to which records with the key are delivered. Feeds of the same key
+// receive records in order of subscription.
+func (e *Engine[Ctx, T]) Subscribe(key string, f Feed[Ctx, T]) {
+	e.feeds[key] = append(e.feeds[key], f)
+}
+
+// Collect adds collector of results, e.g. of a strategy. Collectors are called after all records are replayed.
+// Collector with the same name is replaced.
+func (e *Engine[Ctx, T]) Collect(name string, collector func() interface{}) {
+	if _, exists := e.collectors[name]; !exists {
+		e.collectorNames = append(e.collectorNames, name)
+	}
+	e.collectors[name] = collector
+}
+
+// Run replays records in order of their time. Records with equal time are replayed in the order they are passed.
+// Returns error if none of records have subscribed feeds, which usually means that feeds are not set up.
+func (e *Engine[Ctx, T]) Run(records []Record[T]) (Results, error) {
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b Record[T]) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	res := Results{Collected: make(map[string]interface{}, len(e.collectors))}
+
+	for _, rec := range records {
+		feeds := e.feeds[rec.Key]
+		if len(feeds) == 0 {
+			continue
+		}
+
+		if res.Records == 0 {
+			res.Start = rec.Time
+		}
+		res.End = rec.Time
+		res.Records++
+
+		e.clock.now = rec.Time
+		for _, f := range feeds {
+			f.Feed(e.newCtx(), rec)
+		}
+	}
+
+	if len(records) != 0 && res.Records == 0 {
+		return res, fmt.Errorf("none of %v records have subscribed feeds", len(records))
+	}
+
+	for _, name := range e.collectorNames {
+		res.Collected[name] = e.collectors[name]()
+	}
+
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package backtest_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/backtest"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct {
+	Engine *backtest.Engine[context.Context, float64]
+}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+
+type price struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	asset string
+	value float64
+}
+
+func newPrice(asset string) *price {
+	return &price{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("price", asset),
+		asset:            asset,
+	}
+}
+
+func (p *price) Start(params *InitParams) error {
+	params.Engine.Subscribe(p.asset, p)
+	return nil
+}
+
+func (p *price) Feed(ctx context.Context, rec backtest.Record[float64]) {
+	p.value = rec.Value
+	p.NotifyUpdated(ctx, rec.Time)
+}
+
+// spread records difference between prices of two assets together with event time.
+type spread struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	a, b    *price
+	history []string
+}
+
+func newSpread(a, b string) *spread {
+	s := &spread{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("spread", a, b),
+		a:                newPrice(a),
+		b:                newPrice(b),
+	}
+
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		s.history = append(s.history, evtTime.Format(time.TimeOnly)+" "+strconv.FormatFloat(s.a.value-s.b.value, 'f', -1, 64))
+	})
+
+	return s
+}
+
+func (s *spread) RegisterDependencies(store SharedStore) {
+	store.Register(&s.a)
+	store.Register(&s.b)
+	s.a.SubscribeObj(s)
+	s.b.SubscribeObj(s)
+}
+
+func TestEngine(t *testing.T) {
+	t.Parallel()
+
+	at := func(sec int) time.Time { return time.Date(2024, 1, 1, 0, 0, sec, 0, time.UTC) }
+	records := []backtest.Record[float64]{
+		{Time: at(3), Key: "A", Value: 5},
+		{Time: at(1), Key: "A", Value: 1},
+		{Time: at(1), Key: "B", Value: 2},
+		{Time: at(2), Key: "unknown", Value: 100},
+		{Time: at(2), Key: "B", Value: 1},
+	}
+
+	run := func() backtest.Results {
+		engine := backtest.NewEngine[context.Context, float64](context.Background)
+
+		store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+		s := newSpread("A", "B")
+		store.Register(&s)
+		require.NoError(t, store.Init(&InitParams{Engine: engine}))
+		require.NoError(t, store.Start())
+		defer func() {
+			store.Stop()
+			store.Close()
+		}()
+
+		engine.Collect("spread", func() interface{} { return s.history })
+		engine.Collect("time", func() interface{} { return engine.Clock().Now() })
+
+		res, err := engine.Run(records)
+		require.NoError(t, err)
+
+		return res
+	}
+
+	res := run()
+	require.Equal(t, 4, res.Records)
+	require.Equal(t, at(1), res.Start)
+	require.Equal(t, at(3), res.End)
+	require.Equal(t, at(3), res.Collected["time"])
+	require.Equal(t, []string{"00:00:01 1", "00:00:01 -1", "00:00:02 0", "00:00:03 4"}, res.Collected["spread"])
+
+	require.Equal(t, res, run(), "replay must be deterministic")
+}
+
+func TestEngine_NoFeeds(t *testing.T) {
+	t.Parallel()
+
+	engine := backtest.NewEngine[context.Context, float64](context.Background)
+	_, err := engine.Run([]backtest.Record[float64]{{Key: "A"}})
+	require.ErrorContains(t, err, "none of 1 records have subscribed feeds")
+}
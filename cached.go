@@ -0,0 +1,78 @@
+package shdep
+
+import (
+	"context"
+	"time"
+)
+
+// CachedValue is a helper base struct for shared objects, which provide value derived from their dependencies,
+// e.g. expensive indicator. The value is computed lazily on first call of Get after any of subscriptions has updated,
+// so it is not recomputed, while inputs have not changed, and is not computed at all, if nobody reads it.
+//
+// Update handler of the object invalidates the value and notifies subscribers. It is set by store created by NewSharedStore
+// from method OnDependenciesUpdated, so SetUpdateHandler must not be called for such objects.
+// Use Invalidate to invalidate the value in custom update handler instead.
+type CachedValue[Ctx, InitParams, T any] struct {
+	SharedObjectBase[Ctx, InitParams]
+	compute      func() T
+	value        T
+	valid        bool
+	computations uint64
+}
+
+// NewCachedValue creates new CachedValue. Function, which computes the value, is set by SetCompute, because it
+// usually needs the object embedding CachedValue. Parameters are same as of NewSharedObjectBase.
+func NewCachedValue[Ctx, InitParams, T any](name string, params ...interface{}) CachedValue[Ctx, InitParams, T] {
+	return CachedValue[Ctx, InitParams, T]{
+		SharedObjectBase: NewSharedObjectBase[Ctx, InitParams](name, params...),
+	}
+}
+
+// SetCompute sets function, which computes the value from dependencies. Invalidates the value.
+func (c *CachedValue[Ctx, InitParams, T]) SetCompute(compute func() T) {
+	c.compute = compute
+	c.Invalidate()
+}
+
+// Get returns the value, computing it if it has been invalidated since the last computation.
+func (c *CachedValue[Ctx, InitParams, T]) Get() T {
+	if !c.valid {
+		c.value = c.compute()
+		c.valid = true
+		c.computations++
+	}
+
+	return c.value
+}
+
+// Invalidate makes next call of Get compute the value again.
+func (c *CachedValue[Ctx, InitParams, T]) Invalidate() {
+	var zero T
+	c.value = zero
+	c.valid = false
+}
+
+// Valid reports whether the value is computed and has not been invalidated since then.
+func (c *CachedValue[Ctx, InitParams, T]) Valid() bool {
+	return c.valid
+}
+
+// OnDependenciesUpdated invalidates the value and notifies subscribers, that it has changed.
+func (c *CachedValue[Ctx, InitParams, T]) OnDependenciesUpdated(ctx Ctx, evtTime time.Time) {
+	c.Invalidate()
+	c.NotifyUpdated(ctx, evtTime)
+}
+
+// DebugInfo returns same info as SharedObjectBase, plus whether the value is valid and number of its computations.
+func (c *CachedValue[Ctx, InitParams, T]) DebugInfo() map[string]interface{} {
+	info := c.SharedObjectBase.DebugInfo()
+	info[DebugInfoCache] = map[string]interface{}{
+		"valid":        c.valid,
+		"computations": c.computations,
+	}
+
+	return info
+}
+
+var _ SharedObject[context.Context, string] = &CachedValue[context.Context, string, int]{}
+var _ DependenciesUpdatedHandler[context.Context] = &CachedValue[context.Context, string, int]{}
@@ -0,0 +1,68 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+type cachedSquare struct {
+	shdep.CachedValue[context.Context, *InitParams, int]
+	counter *counter
+}
+
+func newCachedSquare() *cachedSquare {
+	s := &cachedSquare{CachedValue: shdep.NewCachedValue[context.Context, *InitParams, int]("cachedSquare", 1), counter: newCounter()}
+	s.SetCompute(func() int {
+		return s.counter.value * s.counter.value
+	})
+
+	return s
+}
+
+func (s *cachedSquare) RegisterDependencies(store SharedStore) {
+	store.Register(&s.counter)
+	s.counter.SubscribeObj(s)
+}
+
+func TestCachedValue(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	s := newCachedSquare()
+	store.Register(&s)
+
+	var notified int
+	probe := shdep.NewFuncObject("probe", []interface{}{1}, shdep.FuncObjectSpec[context.Context, *InitParams]{
+		RegisterDeps: func(store SharedStore) {
+			store.Register(&s)
+		},
+		OnUpdate: func(ctx context.Context, evtTime time.Time) { notified++ },
+	})
+	store.Register(&probe)
+	s.SubscribeObj(probe)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	s.counter.value = 3
+	require.Equal(t, 9, s.Get())
+	s.counter.value = 4
+	// Value is not recomputed until dependency notifies about update.
+	require.Equal(t, 9, s.Get())
+	require.True(t, s.Valid())
+
+	s.counter.NotifyUpdated(context.Background(), time.Now())
+	require.False(t, s.Valid())
+	require.Equal(t, 1, notified)
+	require.Equal(t, 16, s.Get())
+	require.Equal(t, 16, s.Get())
+	require.Equal(t, map[string]interface{}{"valid": true, "computations": uint64(2)}, s.DebugInfo()[shdep.DebugInfoCache])
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// graph is a dependencies graph of objects of a snapshot.
+// Objects are identified by ID prefixed by name of their store, if it is set (e.g. "shard-0/feed").
+type graph struct {
+	objects map[string]objstore.ObjectDescription
+	order   []string
+	edges   utils.Graph[string]
+	missing map[string][]string // dependencies, which are not present in the snapshot
+}
+
+func objectKey(store, id string) string {
+	if store == "" {
+		return id
+	}
+	return store + "/" + id
+}
+
+func newGraph(snapshot objstore.Snapshot) *graph {
+	g := &graph{
+		objects: make(map[string]objstore.ObjectDescription, len(snapshot.Objects)),
+		order:   make([]string, 0, len(snapshot.Objects)),
+		edges:   make(utils.Graph[string], len(snapshot.Objects)),
+		missing: make(map[string][]string),
+	}
+
+	for _, obj := range snapshot.Objects {
+		key := objectKey(obj.Store, obj.ID)
+		g.objects[key] = obj
+		g.order = append(g.order, key)
+	}
+
+	for _, key := range g.order {
+		obj := g.objects[key]
+		edges := make([]string, 0, len(obj.Dependencies))
+
+		for _, depID := range obj.Dependencies {
+			// Dependencies of shards can be owned by the parent store of ShardedStore.
+			depKey := objectKey(obj.Store, depID)
+			if _, ok := g.objects[depKey]; !ok {
+				depKey = objectKey("parent", depID)
+			}
+
+			if _, ok := g.objects[depKey]; !ok {
+				g.missing[key] = append(g.missing[key], depID)
+				continue
+			}
+
+			edges = append(edges, depKey)
+		}
+
+		g.edges[key] = edges
+	}
+
+	return g
+}
+
+// DOT renders dependencies graph in Graphviz DOT format.
+func (g *graph) DOT() string {
+	return utils.GraphToDOT(g.edges, nil)
+}
+
+// Cycles returns cycles of dependencies. Each cycle starts and ends with the same object.
+func (g *graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	status := make(map[string]int, len(g.order))
+	path := make([]string, 0)
+	var cycles [][]string
+
+	var visit func(key string)
+	visit = func(key string) {
+		status[key] = visiting
+		path = append(path, key)
+
+		for _, dep := range g.edges[key] {
+			switch status[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := slices.Index(path, dep)
+				cycle := append(slices.Clone(path[start:]), dep)
+				cycles = append(cycles, cycle)
+			}
+		}
+
+		path = path[:len(path)-1]
+		status[key] = visited
+	}
+
+	for _, key := range g.order {
+		if status[key] == unvisited {
+			visit(key)
+		}
+	}
+
+	return cycles
+}
+
+// Orphans returns objects, which are not reachable from any top-level object.
+// Such objects are kept alive by the store, but nothing uses them.
+func (g *graph) Orphans() []string {
+	reachable := make(map[string]bool, len(g.order))
+
+	var visit func(key string)
+	visit = func(key string) {
+		if reachable[key] {
+			return
+		}
+
+		reachable[key] = true
+		for _, dep := range g.edges[key] {
+			visit(dep)
+		}
+	}
+
+	for _, key := range g.order {
+		if g.objects[key].TopLevel {
+			visit(key)
+		}
+	}
+
+	var orphans []string
+	for _, key := range g.order {
+		if !reachable[key] {
+			orphans = append(orphans, key)
+		}
+	}
+
+	return orphans
+}
+
+// Missing returns descriptions of dependencies, which are not present in the snapshot.
+func (g *graph) Missing() []string {
+	var missing []string
+	for _, key := range g.order {
+		for _, depID := range g.missing[key] {
+			missing = append(missing, fmt.Sprintf("%v -> %v", key, depID))
+		}
+	}
+
+	return missing
+}
+
+// diff returns human-readable list of differences between two snapshots:
+// added, removed objects and changes of type, state and dependencies of the objects present in both.
+func diff(oldSnapshot, newSnapshot objstore.Snapshot) []string {
+	oldObjects := newGraph(oldSnapshot)
+	newObjects := newGraph(newSnapshot)
+
+	var changes []string
+
+	for _, key := range oldObjects.order {
+		if _, ok := newObjects.objects[key]; !ok {
+			changes = append(changes, fmt.Sprintf("- %v (%v)", key, oldObjects.objects[key].Type))
+		}
+	}
+
+	for _, key := range newObjects.order {
+		newObj := newObjects.objects[key]
+
+		oldObj, ok := oldObjects.objects[key]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("+ %v (%v)", key, newObj.Type))
+			continue
+		}
+
+		if oldObj.Type != newObj.Type {
+			changes = append(changes, fmt.Sprintf("~ %v: type %v -> %v", key, oldObj.Type, newObj.Type))
+		}
+
+		if oldObj.State != newObj.State {
+			changes = append(changes, fmt.Sprintf("~ %v: state %v -> %v", key, oldObj.State, newObj.State))
+		}
+
+		if oldObj.TopLevel != newObj.TopLevel {
+			changes = append(changes, fmt.Sprintf("~ %v: top-level %v -> %v", key, oldObj.TopLevel, newObj.TopLevel))
+		}
+
+		added, removed := diffSets(oldObj.Dependencies, newObj.Dependencies)
+		if len(added) != 0 {
+			changes = append(changes, fmt.Sprintf("~ %v: added dependencies %v", key, strings.Join(added, ", ")))
+		}
+		if len(removed) != 0 {
+			changes = append(changes, fmt.Sprintf("~ %v: removed dependencies %v", key, strings.Join(removed, ", ")))
+		}
+	}
+
+	return changes
+}
+
+func diffSets(oldItems, newItems []string) (added, removed []string) {
+	for _, item := range newItems {
+		if !slices.Contains(oldItems, item) {
+			added = append(added, item)
+		}
+	}
+
+	for _, item := range oldItems {
+		if !slices.Contains(newItems, item) {
+			removed = append(removed, item)
+		}
+	}
+
+	return added, removed
+}
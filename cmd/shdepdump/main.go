@@ -0,0 +1,132 @@
+// Command shdepdump analyzes snapshots of shared objects stores written by WriteSnapshot.
+//
+// Usage:
+//
+//	shdepdump dot <snapshot>          render dependencies graph in Graphviz DOT format
+//	shdepdump check <snapshot>        report cycles, orphan objects and missing dependencies
+//	shdepdump diff <old> <new>        report differences between two snapshots
+//
+// Use "-" instead of file name to read snapshot from standard input.
+// Command check exits with code 1 if any problems are found, command diff - if snapshots differ.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/pkg/errors"
+)
+
+const usage = `Usage:
+  shdepdump dot <snapshot>      render dependencies graph in Graphviz DOT format
+  shdepdump check <snapshot>    report cycles, orphan objects and missing dependencies
+  shdepdump diff <old> <new>    report differences between two snapshots
+`
+
+func main() {
+	code, err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	os.Exit(code)
+}
+
+// run executes command and returns exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if len(args) == 0 {
+		return 2, errors.New(usage)
+	}
+
+	cmd, args := args[0], args[1:]
+
+	switch {
+	case cmd == "dot" && len(args) == 1:
+		snapshot, err := readSnapshot(args[0], stdin)
+		if err != nil {
+			return 2, err
+		}
+
+		fmt.Fprint(stdout, newGraph(snapshot).DOT())
+		return 0, nil
+
+	case cmd == "check" && len(args) == 1:
+		snapshot, err := readSnapshot(args[0], stdin)
+		if err != nil {
+			return 2, err
+		}
+
+		return check(newGraph(snapshot), stdout), nil
+
+	case cmd == "diff" && len(args) == 2:
+		oldSnapshot, err := readSnapshot(args[0], stdin)
+		if err != nil {
+			return 2, err
+		}
+
+		newSnapshot, err := readSnapshot(args[1], stdin)
+		if err != nil {
+			return 2, err
+		}
+
+		changes := diff(oldSnapshot, newSnapshot)
+		for _, change := range changes {
+			fmt.Fprintln(stdout, change)
+		}
+
+		if len(changes) != 0 {
+			return 1, nil
+		}
+		return 0, nil
+
+	default:
+		return 2, errors.New(usage)
+	}
+}
+
+func check(g *graph, stdout io.Writer) int {
+	problems := 0
+
+	for _, cycle := range g.Cycles() {
+		problems++
+		fmt.Fprintf(stdout, "cycle: %v\n", cycle)
+	}
+
+	for _, orphan := range g.Orphans() {
+		problems++
+		fmt.Fprintf(stdout, "orphan: %v\n", orphan)
+	}
+
+	for _, missing := range g.Missing() {
+		problems++
+		fmt.Fprintf(stdout, "missing dependency: %v\n", missing)
+	}
+
+	if problems != 0 {
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "%v objects, no problems found\n", len(g.order))
+	return 0
+}
+
+func readSnapshot(fileName string, stdin io.Reader) (objstore.Snapshot, error) {
+	if fileName == "-" {
+		return objstore.ReadSnapshot(stdin)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return objstore.Snapshot{}, errors.Wrapf(err, "failed to open snapshot")
+	}
+	defer f.Close()
+
+	snapshot, err := objstore.ReadSnapshot(f)
+	if err != nil {
+		return objstore.Snapshot{}, errors.Wrapf(err, "snapshot %v", fileName)
+	}
+
+	return snapshot, nil
+}
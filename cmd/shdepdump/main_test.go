@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot() objstore.Snapshot {
+	return objstore.Snapshot{
+		FormatVersion: objstore.SnapshotFormatVersion,
+		StoreDescription: objstore.StoreDescription{
+			Objects: []objstore.ObjectDescription{
+				{Store: "parent", ID: "provider", Type: "*Provider", State: objstore.ObjectStarted},
+				{Store: "shard-0", ID: "feed", Type: "*Feed", State: objstore.ObjectStarted, Dependencies: []string{"provider"}},
+				{Store: "shard-0", ID: "strategy", Type: "*Strategy", State: objstore.ObjectStarted, TopLevel: true, Dependencies: []string{"feed"}},
+			},
+		},
+	}
+}
+
+func writeSnapshot(t *testing.T, snapshot objstore.Snapshot) string {
+	var buf bytes.Buffer
+	require.NoError(t, objstore.WriteSnapshot(&buf, snapshotDescriber(snapshot)))
+
+	fileName := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(fileName, buf.Bytes(), 0o644))
+
+	return fileName
+}
+
+type snapshotDescriber objstore.Snapshot
+
+func (s snapshotDescriber) Describe() objstore.StoreDescription {
+	return s.StoreDescription
+}
+
+func runCmd(t *testing.T, args ...string) (int, string) {
+	var out bytes.Buffer
+	code, err := run(args, strings.NewReader(""), &out)
+	require.NoError(t, err)
+
+	return code, out.String()
+}
+
+func TestDOT(t *testing.T) {
+	t.Parallel()
+
+	code, out := runCmd(t, "dot", writeSnapshot(t, testSnapshot()))
+	require.Equal(t, 0, code)
+	require.Contains(t, out, `[label="shard-0/feed"]`)
+	require.Equal(t, 2, strings.Count(out, "->"))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	code, out := runCmd(t, "check", writeSnapshot(t, testSnapshot()))
+	require.Equal(t, 0, code)
+	require.Contains(t, out, "3 objects, no problems found")
+
+	snapshot := testSnapshot()
+	snapshot.Objects[0].Dependencies = []string{"unknown"}
+	snapshot.Objects = append(snapshot.Objects,
+		objstore.ObjectDescription{Store: "shard-0", ID: "a", Dependencies: []string{"b"}},
+		objstore.ObjectDescription{Store: "shard-0", ID: "b", Dependencies: []string{"a"}},
+	)
+
+	code, out = runCmd(t, "check", writeSnapshot(t, snapshot))
+	require.Equal(t, 1, code)
+	require.Contains(t, out, "cycle: [shard-0/a shard-0/b shard-0/a]")
+	require.Contains(t, out, "orphan: shard-0/a")
+	require.Contains(t, out, "orphan: shard-0/b")
+	require.Contains(t, out, "missing dependency: parent/provider -> unknown")
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	oldFile := writeSnapshot(t, testSnapshot())
+
+	code, out := runCmd(t, "diff", oldFile, oldFile)
+	require.Equal(t, 0, code)
+	require.Empty(t, out)
+
+	snapshot := testSnapshot()
+	snapshot.Objects[1].Type = "*FeedV2"
+	snapshot.Objects[2].Dependencies = []string{"provider"}
+	snapshot.Objects = snapshot.Objects[1:]
+	snapshot.Objects = append(snapshot.Objects, objstore.ObjectDescription{Store: "shard-0", ID: "new", Type: "*New"})
+
+	code, out = runCmd(t, "diff", oldFile, writeSnapshot(t, snapshot))
+	require.Equal(t, 1, code)
+	require.Equal(t, strings.Join([]string{
+		"- parent/provider (*Provider)",
+		"~ shard-0/feed: type *Feed -> *FeedV2",
+		"~ shard-0/strategy: added dependencies provider",
+		"~ shard-0/strategy: removed dependencies feed",
+		"+ shard-0/new (*New)",
+	}, "\n")+"\n", out)
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	code, err := run([]string{"unknown"}, strings.NewReader(""), &bytes.Buffer{})
+	require.Equal(t, 2, code)
+	require.Error(t, err)
+}
@@ -0,0 +1,14 @@
+// Command shdepvet checks usage of shared objects, see package analyzer.
+//
+//	go install github.com/nnikolash/go-shdep/cmd/shdepvet
+//	go vet -vettool=$(which shdepvet) ./...
+package main
+
+import (
+	"github.com/nnikolash/go-shdep/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
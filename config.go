@@ -0,0 +1,152 @@
+package shdep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// GraphConfig describes top-level objects of the store. Dependencies of the objects are registered by objects
+// themselves, so only objects, which are used directly (e.g. strategies), need to be listed.
+type GraphConfig struct {
+	Objects []ObjectConfig `json:"objects"`
+}
+
+// ObjectConfig describes single object: name of the factory and parameters passed to it.
+type ObjectConfig struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Factory creates shared object from its parameters.
+type Factory[Ctx, InitParams any] func(params json.RawMessage) (SharedObject[Ctx, InitParams], error)
+
+func NewFactoryRegistry[Ctx, InitParams any]() *FactoryRegistry[Ctx, InitParams] {
+	return &FactoryRegistry[Ctx, InitParams]{
+		factories: make(map[string]Factory[Ctx, InitParams]),
+	}
+}
+
+// FactoryRegistry creates objects described in configuration by type names of the objects.
+type FactoryRegistry[Ctx, InitParams any] struct {
+	lock      sync.RWMutex
+	factories map[string]Factory[Ctx, InitParams]
+}
+
+// Register adds factory of objects of given type. Registering same type twice is a failure.
+func (r *FactoryRegistry[Ctx, InitParams]) Register(typeName string, f Factory[Ctx, InitParams]) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, exists := r.factories[typeName]; exists {
+		utils.Fail(fmt.Errorf("factory of type %v is already registered", typeName))
+		return
+	}
+
+	r.factories[typeName] = f
+}
+
+// Build creates objects described in configuration.
+func (r *FactoryRegistry[Ctx, InitParams]) Build(cfg GraphConfig) ([]SharedObject[Ctx, InitParams], error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	objects := make([]SharedObject[Ctx, InitParams], 0, len(cfg.Objects))
+
+	for i, objCfg := range cfg.Objects {
+		f, ok := r.factories[objCfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("object %v: unknown type %v", i, objCfg.Type)
+		}
+
+		obj, err := f(objCfg.Params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "object %v: failed to create %v", i, objCfg.Type)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// Load parses configuration in JSON or YAML format, creates described objects and registers them in the store.
+// Returns objects used by the store, which are replicas of created objects if same objects were already registered.
+func (r *FactoryRegistry[Ctx, InitParams]) Load(store SharedStore[Ctx, InitParams], data []byte) ([]SharedObject[Ctx, InitParams], error) {
+	cfg, err := ParseGraphConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := r.Build(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, obj := range objects {
+		objType := reflect.TypeOf(obj)
+
+		replica, ok := objstore.RegisterObject(store, obj, func(registered SharedObject[Ctx, InitParams]) bool {
+			return reflect.TypeOf(registered) == objType
+		})
+		if !ok {
+			return nil, fmt.Errorf("object %v: failed to register %v", i, cfg.Objects[i].Type)
+		}
+
+		objects[i] = replica
+	}
+
+	return objects, nil
+}
+
+// ParseGraphConfig parses configuration in JSON or YAML format.
+func ParseGraphConfig(data []byte) (GraphConfig, error) {
+	// YAML is a superset of JSON, so YAML parser is used for both formats.
+	// Parsed document is converted into JSON to pass parameters to factories as json.RawMessage.
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return GraphConfig{}, errors.Wrapf(err, "failed to parse config")
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return GraphConfig{}, errors.Wrapf(err, "failed to convert config into JSON")
+	}
+
+	var cfg GraphConfig
+	if err := json.Unmarshal(jsonData, &cfg); err != nil {
+		return GraphConfig{}, errors.Wrapf(err, "invalid config")
+	}
+
+	return cfg, nil
+}
+
+var defaultFactoryRegistries sync.Map // reflect.Type of registry -> registry
+
+// DefaultFactoryRegistry returns package-level registry for given context and init params types.
+func DefaultFactoryRegistry[Ctx, InitParams any]() *FactoryRegistry[Ctx, InitParams] {
+	key := reflect.TypeOf((*FactoryRegistry[Ctx, InitParams])(nil))
+
+	if r, ok := defaultFactoryRegistries.Load(key); ok {
+		return r.(*FactoryRegistry[Ctx, InitParams])
+	}
+
+	r, _ := defaultFactoryRegistries.LoadOrStore(key, NewFactoryRegistry[Ctx, InitParams]())
+	return r.(*FactoryRegistry[Ctx, InitParams])
+}
+
+// RegisterFactory adds factory into default registry. Usually called from init() of package, which defines the objects.
+func RegisterFactory[Ctx, InitParams any](typeName string, f Factory[Ctx, InitParams]) {
+	DefaultFactoryRegistry[Ctx, InitParams]().Register(typeName, f)
+}
+
+// LoadConfig loads objects described in configuration into the store using default registry.
+func LoadConfig[Ctx, InitParams any](store SharedStore[Ctx, InitParams], data []byte) ([]SharedObject[Ctx, InitParams], error) {
+	return DefaultFactoryRegistry[Ctx, InitParams]().Load(store, data)
+}
@@ -0,0 +1,144 @@
+package shdep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronSchedule is a parsed cron specification with fields minute, hour, day of month, month and day of week.
+// Each field is a set of allowed values represented as bits.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Whether day of month or day of week is "*". If both are restricted, day matches if any of them matches, as in cron.
+	domAny, dowAny bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// parseCron parses cron specification consisting of five fields: minute, hour, day of month, month and day of week.
+// Each field is "*", number, range "a-b" or list of them separated by comma, optionally followed by step "/n".
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron spec %q must have %v fields, but has %v", spec, len(cronFields), len(fields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		bits[i], err = parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron spec %q", spec)
+		}
+	}
+
+	return &cronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %v", stepStr, f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rng != "*" {
+			lowStr, highStr, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if low, err = strconv.Atoi(lowStr); err != nil {
+				return 0, fmt.Errorf("invalid value %q of %v", lowStr, f.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highStr); err != nil {
+					return 0, fmt.Errorf("invalid value %q of %v", highStr, f.name)
+				}
+			} else if hasStep {
+				high = f.max
+			}
+		}
+
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%v must be within %v-%v, but got %q", f.name, f.min, f.max, part)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// next returns the first time matching the schedule, which is after t. Returns zero time, if there is none within 5 years.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatches := s.dom&(1<<t.Day()) != 0
+	dowMatches := s.dow&(1<<int(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatches && dowMatches
+	}
+
+	return domMatches || dowMatches
+}
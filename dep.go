@@ -0,0 +1,39 @@
+package shdep
+
+import (
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// Dep is a field of shared object holding its dependency. Store created by NewSharedStore registers
+// dependencies held by Dep fields automatically before calling RegisterDependencies of the object
+// and, if Subscribe is set, subscribes the object to their updates. So typical objects don't need
+// to implement RegisterDependencies at all:
+//
+//	type Strategy struct {
+//		shdep.SharedObjectBase[context.Context, *InitParams]
+//		price shdep.Dep[*Price]
+//	}
+//
+//	s.price = shdep.Dep[*Price]{Obj: NewPrice(symbol), Subscribe: true}
+type Dep[T any] struct {
+	// Dependency. After registration it is replaced by the shared replica.
+	Obj T
+	// Whether object owning the field must be subscribed to updates of the dependency.
+	Subscribe bool
+}
+
+// Get returns dependency.
+func (d *Dep[T]) Get() T {
+	return d.Obj
+}
+
+// AutoRegister registers dependency. Called by the store, see objstore.AutoRegistered.
+func (d *Dep[T]) AutoRegister(r objstore.AutoRegistrar) {
+	if d.Subscribe {
+		r.RegisterAndSubscribeOwner(&d.Obj)
+	} else {
+		r.Register(&d.Obj)
+	}
+}
+
+var _ objstore.AutoRegistered = &Dep[*SharedObjectBase[string, string]]{}
@@ -0,0 +1,49 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+type autoDoubler struct {
+	SharedObjectBase
+	counter shdep.Dep[*counter]
+	doubler shdep.Dep[*doubler]
+	value   int
+}
+
+func TestDep(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	c := newCounter()
+	store.Register(&c)
+
+	d := &autoDoubler{
+		SharedObjectBase: NewSharedObjectBase("autoDoubler", 1),
+		counter:          shdep.Dep[*counter]{Obj: newCounter(), Subscribe: true},
+		doubler:          shdep.Dep[*doubler]{Obj: newDoubler()},
+	}
+	d.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		d.value = d.counter.Get().value * 2
+	})
+	store.Register(&d)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.Same(t, c, d.counter.Get())
+	require.Same(t, c, d.doubler.Get().counter)
+	require.NoError(t, store.Start())
+
+	c.value = 5
+	c.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 10, d.value)
+	require.Equal(t, 10, d.doubler.Get().value)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
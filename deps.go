@@ -0,0 +1,83 @@
+package shdep
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// DepsBuilder registers dependencies of the object, subscribes to them and creates event pullers in one chain
+// of calls. Intended to be used in RegisterDependencies:
+//
+//	shdep.Deps(store).Use(&o.price).UseAndSubscribe(&o.ma1, o).UseWithPuller(&o.ma2, o, &o.ma2Events)
+type DepsBuilder[Ctx, InitParams any] struct {
+	store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]
+}
+
+// Deps creates DepsBuilder for the store.
+func Deps[Ctx, InitParams any](store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]) *DepsBuilder[Ctx, InitParams] {
+	return &DepsBuilder[Ctx, InitParams]{store: store}
+}
+
+// Use registers dependency. Same as store.Register(depPtr).
+func (b *DepsBuilder[Ctx, InitParams]) Use(depPtr interface{}) *DepsBuilder[Ctx, InitParams] {
+	b.store.Register(depPtr)
+	return b
+}
+
+// UseAndSubscribe registers dependency and subscribes subscriber to its updates.
+func (b *DepsBuilder[Ctx, InitParams]) UseAndSubscribe(depPtr interface{}, subscriber SharedObject[Ctx, InitParams]) *DepsBuilder[Ctx, InitParams] {
+	b.store.Register(depPtr)
+	if dep, ok := b.registered(depPtr); ok {
+		dep.Subscribe(subscriber.GetUpdateNode())
+	}
+
+	return b
+}
+
+// UseWithPuller registers dependency, subscribes subscriber to its updates and stores new event puller
+// of the dependency into pullerPtr. Dependency must have method NewEventPuller, e.g. provided by SharedObjectBaseWithEvent,
+// and pullerPtr must be pointer to variable of type returned by that method.
+func (b *DepsBuilder[Ctx, InitParams]) UseWithPuller(depPtr interface{}, subscriber SharedObject[Ctx, InitParams], pullerPtr interface{}) *DepsBuilder[Ctx, InitParams] {
+	b.store.Register(depPtr)
+	dep, ok := b.registered(depPtr)
+	if !ok {
+		return b
+	}
+
+	newPuller := reflect.ValueOf(dep).MethodByName("NewEventPuller")
+	if !newPuller.IsValid() || newPuller.Type().NumIn() != 0 || newPuller.Type().NumOut() != 1 {
+		utils.Fail(fmt.Errorf("dependency of type %T does not have method NewEventPuller", dep))
+		return b
+	}
+
+	dst := reflect.ValueOf(pullerPtr)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() || !newPuller.Type().Out(0).AssignableTo(dst.Elem().Type()) {
+		utils.Fail(fmt.Errorf("puller of dependency of type %T must be stored into pointer to %v, but got %T", dep, newPuller.Type().Out(0), pullerPtr))
+		return b
+	}
+
+	dep.Subscribe(subscriber.GetUpdateNode())
+	dst.Elem().Set(newPuller.Call(nil)[0])
+
+	return b
+}
+
+// registered returns dependency, to which depPtr points after registration.
+func (b *DepsBuilder[Ctx, InitParams]) registered(depPtr interface{}) (SharedObject[Ctx, InitParams], bool) {
+	v := reflect.ValueOf(depPtr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		// Already reported by store.
+		return nil, false
+	}
+
+	dep, ok := v.Elem().Interface().(SharedObject[Ctx, InitParams])
+	if !ok || (v.Elem().Kind() == reflect.Pointer && v.Elem().IsNil()) {
+		// Already reported by store.
+		return nil, false
+	}
+
+	return dep, true
+}
@@ -0,0 +1,63 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+type ticker struct {
+	shdep.SharedObjectBaseWithEvent[context.Context, *InitParams, int]
+}
+
+func newTicker() *ticker {
+	return &ticker{SharedObjectBaseWithEvent: shdep.NewSharedObjectBaseWithEvent[context.Context, *InitParams, int]("ticker", 1)}
+}
+
+type tickerSum struct {
+	SharedObjectBase
+	counter *counter
+	doubler *doubler
+	ticker  *ticker
+	ticks   shdep.EventPuller[int]
+	sum     int
+}
+
+func (s *tickerSum) RegisterDependencies(store SharedStore) {
+	shdep.Deps(store).Use(&s.doubler).UseAndSubscribe(&s.counter, s).UseWithPuller(&s.ticker, s, &s.ticks)
+}
+
+func TestDeps(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	c := newCounter()
+	store.Register(&c)
+
+	s := &tickerSum{SharedObjectBase: NewSharedObjectBase("tickerSum", 1), counter: newCounter(), doubler: newDoubler(), ticker: newTicker()}
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		s.sum = s.counter.value
+		for _, tick := range s.ticks.Pull() {
+			s.sum += *tick.Event
+		}
+	})
+	store.Register(&s)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.Same(t, c, s.counter)
+	require.Same(t, c, s.doubler.counter)
+	require.NoError(t, store.Start())
+
+	c.value = 5
+	c.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 5, s.sum)
+	s.ticker.PublishEvent(context.Background(), time.Now(), 10)
+	require.Equal(t, 15, s.sum)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
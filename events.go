@@ -0,0 +1,58 @@
+package shdep
+
+import (
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// Event storage types of package updtree, so that users of SharedObjectBaseWithEvent don't need to import it.
+type (
+	AccumulatedEvent[Event any]  = updtree.AccumulatedEvent[Event]
+	EventsPullStorage[Event any] = updtree.EventsPullStorage[Event]
+	EventsStats                  = updtree.EventsStats
+	AbandonedPuller              = updtree.AbandonedPuller
+)
+
+// NewEventsPullStorage creates storage of events, from which each puller receives all events published after its creation.
+func NewEventsPullStorage[Event any]() *EventsPullStorage[Event] {
+	return updtree.NewEventsPullStorage[Event]()
+}
+
+// EventPuller pulls events published by shared object. Implemented by updtree.EventPuller.
+// Events must be pulled from it at least periodically, or it must be closed. Otherwise it will cause memory leak.
+type EventPuller[Event any] interface {
+	// Pulls all events from the storage published since last pull.
+	Pull() []AccumulatedEvent[Event]
+
+	// Pulls at most n oldest events published since last pull. Remaining events are returned by next pulls.
+	PullN(n int) []AccumulatedEvent[Event]
+
+	// Discards all events except the last one. If no events were published since last pull, returns nil.
+	Last() (lastPublishedEventIfExists *Event)
+
+	// Same as Last, but returns def if no events were published since last pull.
+	LastOr(def Event) Event
+
+	// Same as Last, but returns false instead of nil if no events were published since last pull.
+	LastOK() (Event, bool)
+
+	// Returns number of events published since last pull.
+	Unread() int
+
+	// Discards unread events and detaches puller from the storage. Closed puller does not return any events.
+	Close()
+}
+
+var _ EventPuller[int] = &updtree.EventPuller[int]{}
+
+// EventPublisher is implemented by shared objects embedding SharedObjectBaseWithEvent.
+type EventPublisher[Event any] interface {
+	NewEventPuller() EventPuller[Event]
+	subscribeObjUntyped(subscriber interface{})
+}
+
+// SubscribeTo subscribes subscriber to updates of publisher and returns new puller of its events.
+// Subscriber must implement SharedObject with same context and init params as publisher.
+func SubscribeTo[Event any](publisher EventPublisher[Event], subscriber interface{}) EventPuller[Event] {
+	publisher.subscribeObjUntyped(subscriber)
+	return publisher.NewEventPuller()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/nnikolash/go-shdep"
 	"github.com/nnikolash/go-shdep/examples/trading/shobj"
 	"github.com/nnikolash/go-shdep/objstore"
 	"github.com/nnikolash/go-shdep/updtree"
@@ -20,10 +21,11 @@ func NewMAIndicator(cfg PriceProviderConfig) *MAIndicator {
 		// This is crusial for the correct hash calculation. Otherwise, same hash may be
 		// shared by different objects and your algorythm will work incorrectly.
 		SharedObjectBase: shobj.NewSharedObjectBase(name, cfg),
-		priceProvider:    NewPriceProvider(cfg.Asset),
-		asset:            cfg.Asset,
-		period:           cfg.Period,
-		eventsPublisher:  updtree.NewEventsPullStorage[MAEvent](),
+		// NOTE that window is shared object too, so it is shared with other objects keeping same number of last prices.
+		prices:          shdep.NewWindow[context.Context, *shobj.InitParams]("Prices", NewPriceProvider(cfg.Asset), (*PriceProvider).Price, shdep.WindowOpts{Size: cfg.Period}),
+		asset:           cfg.Asset,
+		period:          cfg.Period,
+		eventsPublisher: updtree.NewEventsPullStorage[MAEvent](),
 	}
 
 	// NOTE that we must set this to be able to receive updates from dependencies.
@@ -37,10 +39,9 @@ type MAIndicator struct {
 
 	asset           string
 	period          int
-	priceProvider   *PriceProvider
+	prices          *shdep.Window[context.Context, *shobj.InitParams, float64, *PriceProvider]
 	eventsPublisher *updtree.EventsPullStorage[MAEvent]
 
-	lastPrices   []float64
 	currentValue float64
 }
 
@@ -58,21 +59,16 @@ func (s *MAIndicator) NewEventPuller() *updtree.EventPuller[MAEvent] {
 
 func (o *MAIndicator) RegisterDependencies(store objstore.SharedStore[shobj.SharedObject, *shobj.InitParams]) {
 	// NOTE that we pass pointer to pointer here.
-	store.Register(&o.priceProvider)
+	store.Register(&o.prices)
 
 	// Registration only provides us shared replica of that object.
 	// But if we need to listen for updates, we must also subscribe on it.
-	o.priceProvider.SubscribeObj(o)
+	o.prices.SubscribeObj(o)
 }
 
 // This function will be called when any of dependencies will call NotifyUpdated.
 func (o *MAIndicator) onDependenciesUpdated(ctx context.Context, evtTime time.Time) {
-	o.lastPrices = append(o.lastPrices, o.priceProvider.Price())
-
-	if len(o.lastPrices) > o.period {
-		o.lastPrices = o.lastPrices[1:]
-	}
-
+	// Window keeps last prices by itself.
 	o.currentValue = o.calculateValue()
 
 	// In this example event published using pull model.
@@ -90,14 +86,11 @@ func (o *MAIndicator) onDependenciesUpdated(ctx context.Context, evtTime time.Ti
 
 func (o *MAIndicator) calculateValue() float64 {
 	sum := 0.0
-	count := 0
-
-	for i := 0; i < len(o.lastPrices) && i < o.period; i++ {
-		sum += o.lastPrices[i]
-		count++
+	for _, price := range o.prices.All() {
+		sum += price
 	}
 
-	return sum / float64(count)
+	return sum / float64(o.prices.Len())
 }
 
 func (o *MAIndicator) Value() float64 {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/nnikolash/go-shdep/backtest"
 	"github.com/nnikolash/go-shdep/examples/trading/shobj"
 )
 
@@ -37,6 +38,12 @@ func (p *PriceProvider) Init(params *shobj.InitParams) error {
 }
 
 func (p *PriceProvider) Start(params *shobj.InitParams) error {
+	if p.p.Backtest != nil {
+		// Prices are delivered by backtest engine from the same goroutine as all other updates, so no locking is needed.
+		p.p.Backtest.Subscribe(p.assetName, p)
+		return nil
+	}
+
 	getPrice := p.p.GetPriceTicker(p.assetName)
 
 	go func() {
@@ -61,6 +68,12 @@ func (p *PriceProvider) Start(params *shobj.InitParams) error {
 	return nil
 }
 
+// Feed receives historical price from backtest engine.
+func (p *PriceProvider) Feed(ctx context.Context, rec backtest.Record[float64]) {
+	p.curentPrice = rec.Value
+	p.NotifyUpdated(ctx, rec.Time)
+}
+
 func (p *PriceProvider) Price() float64 {
 	return p.curentPrice
 }
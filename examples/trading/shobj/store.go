@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/backtest"
 	"github.com/nnikolash/go-shdep/objstore"
 )
 
@@ -24,4 +25,7 @@ type InitParams struct {
 	ExternalUpdateLock *sync.Mutex
 
 	GetPriceTicker func(asset string) chan float64
+
+	// If set, prices are replayed from history by backtest engine instead of GetPriceTicker.
+	Backtest *backtest.Engine[context.Context, float64]
 }
@@ -2,13 +2,35 @@ package example_trading
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
+	"github.com/nnikolash/go-shdep"
+
 	"github.com/nnikolash/go-shdep/examples/trading/indicators"
 	"github.com/nnikolash/go-shdep/examples/trading/shobj"
 	"github.com/nnikolash/go-shdep/objstore"
 )
 
+// StrategyConfig is a configuration of strategy used to create it from config file.
+type StrategyConfig struct {
+	Asset        string `json:"asset"`
+	MAPeriodFast int    `json:"maPeriodFast"`
+	MAPeriodSlow int    `json:"maPeriodSlow"`
+}
+
+func init() {
+	// This allows to create strategies from config files using shdep.LoadConfig.
+	shdep.RegisterFactory("Strategy", func(params json.RawMessage) (shobj.SharedObject, error) {
+		var cfg StrategyConfig
+		if err := json.Unmarshal(params, &cfg); err != nil {
+			return nil, err
+		}
+
+		return NewStrategy(cfg.Asset, cfg.MAPeriodFast, cfg.MAPeriodSlow), nil
+	})
+}
+
 func NewStrategy(asset string, maPeriodFast, maPeriodSlow int) *Strategy {
 	ind := &Strategy{
 		SharedObjectBase: shobj.NewSharedObjectBase("Strategy", asset, maPeriodFast, maPeriodSlow),
@@ -1,10 +1,14 @@
 package example_trading_test
 
 import (
+	"context"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/backtest"
 	example_trading "github.com/nnikolash/go-shdep/examples/trading"
 	"github.com/nnikolash/go-shdep/examples/trading/shobj"
 	"github.com/stretchr/testify/require"
@@ -34,20 +38,10 @@ var btcPrices = []float64{
 	9.5, 8.5, 7.5, 6.5, 5.5, 4.5, 3.5, 2.5, 1.5, // and then went down
 }
 
-func TestExampleTrading(t *testing.T) {
-	t.Parallel()
-
-	store := shobj.NewSharedStore(nil)
-
-	strat1 := example_trading.NewStrategy("BTC", 2, 5)
-	strat2 := example_trading.NewStrategy("BTC", 5, 10)
-
-	store.Register(&strat1)
-	store.Register(&strat2)
-
+func newInitParams() (*shobj.InitParams, chan struct{}) {
 	done := make(chan struct{})
 
-	err := store.Init(&shobj.InitParams{
+	return &shobj.InitParams{
 		ExternalUpdateLock: &sync.Mutex{},
 		GetPriceTicker: func(asset string) chan float64 {
 			ch := make(chan float64)
@@ -63,7 +57,22 @@ func TestExampleTrading(t *testing.T) {
 
 			return ch
 		},
-	})
+	}, done
+}
+
+func TestExampleTrading(t *testing.T) {
+	t.Parallel()
+
+	store := shobj.NewSharedStore(nil)
+
+	strat1 := example_trading.NewStrategy("BTC", 2, 5)
+	strat2 := example_trading.NewStrategy("BTC", 5, 10)
+
+	store.Register(&strat1)
+	store.Register(&strat2)
+
+	initParams, done := newInitParams()
+	err := store.Init(initParams)
 	require.NoError(t, err)
 
 	err = store.Start()
@@ -88,3 +97,74 @@ func TestExampleTrading(t *testing.T) {
 	var maAddrInStrat2 = reflect.ValueOf(strat2.Cross().MAFast()).Pointer()
 	require.Equal(t, maAddrInStrat1, maAddrInStrat2)
 }
+
+// Same as TestExampleTrading, but strategies are created from configuration.
+func TestExampleTradingFromConfig(t *testing.T) {
+	t.Parallel()
+
+	store := shobj.NewSharedStore(nil)
+
+	objects, err := shdep.LoadConfig(store, []byte(`
+objects:
+  - type: Strategy
+    params: {asset: BTC, maPeriodFast: 2, maPeriodSlow: 5}
+  - type: Strategy
+    params: {asset: BTC, maPeriodFast: 5, maPeriodSlow: 10}
+`))
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+
+	_, err = shdep.LoadConfig(store, []byte(`{"objects": [{"type": "Unknown"}]}`))
+	require.ErrorContains(t, err, "unknown type Unknown")
+
+	initParams, done := newInitParams()
+	require.NoError(t, store.Init(initParams))
+	require.NoError(t, store.Start())
+
+	<-done
+
+	store.Stop()
+	store.Close()
+
+	strat1 := objects[0].(*example_trading.Strategy)
+	strat2 := objects[1].(*example_trading.Strategy)
+	require.Equal(t, []float64{-7.5}, strat1.TradeOperationsLog())
+	require.Equal(t, []float64{-5.5}, strat2.TradeOperationsLog())
+	require.Same(t, strat1.Cross().MASlow(), strat2.Cross().MAFast())
+}
+
+// Same as TestExampleTrading, but prices are replayed from history by backtest engine.
+func TestExampleTradingBacktest(t *testing.T) {
+	t.Parallel()
+
+	engine := backtest.NewEngine[context.Context, float64](context.Background)
+
+	store := shobj.NewSharedStore(nil)
+
+	strat1 := example_trading.NewStrategy("BTC", 2, 5)
+	strat2 := example_trading.NewStrategy("BTC", 5, 10)
+	store.Register(&strat1)
+	store.Register(&strat2)
+
+	require.NoError(t, store.Init(&shobj.InitParams{Backtest: engine}))
+	require.NoError(t, store.Start())
+
+	engine.Collect("strategy1", func() interface{} { return strat1.TradeOperationsLog() })
+	engine.Collect("strategy2", func() interface{} { return strat2.TradeOperationsLog() })
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]backtest.Record[float64], 0, len(btcPrices))
+	for i, price := range btcPrices {
+		records = append(records, backtest.Record[float64]{Time: start.Add(time.Duration(i) * time.Minute), Key: "BTC", Value: price})
+	}
+
+	res, err := engine.Run(records)
+	require.NoError(t, err)
+
+	store.Stop()
+	store.Close()
+
+	require.Equal(t, len(btcPrices), res.Records)
+	require.Equal(t, []float64{-7.5}, res.Collected["strategy1"])
+	require.Equal(t, []float64{-5.5}, res.Collected["strategy2"])
+}
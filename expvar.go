@@ -0,0 +1,70 @@
+package shdep
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+type expvarConfig struct {
+	lock sync.Locker
+}
+
+type ExpvarOption func(c *expvarConfig)
+
+// ExpvarWithLock sets lock, which is held while reading the store.
+// See objstore.ReadLocked for which lock it must be.
+func ExpvarWithLock(l sync.Locker) ExpvarOption {
+	return func(c *expvarConfig) {
+		c.lock = l
+	}
+}
+
+// PublishExpvar publishes state of the store as expvar variable with the given name: number of objects
+// by lifecycle state, initialization order and update counters of objects based on SharedObjectBase.
+// The state is read each time the variable is requested, e.g. from /debug/vars endpoint.
+// Like expvar.Publish, it panics if variable with the name is already published.
+func PublishExpvar(store objstore.Describer, name string, opts ...ExpvarOption) {
+	var c expvarConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return expvarState(objstore.DescribeLocked(store, c.lock))
+	}))
+}
+
+type expvarStoreState struct {
+	Objects        int                          `json:"objects"`
+	ObjectsByState map[string]int               `json:"objectsByState"`
+	InitOrder      []string                     `json:"initOrder"`
+	Updates        map[string]updtree.NodeStats `json:"updates"`
+}
+
+func expvarState(desc objstore.StoreDescription) expvarStoreState {
+	state := expvarStoreState{
+		Objects:        len(desc.Objects),
+		ObjectsByState: make(map[string]int),
+		InitOrder:      make([]string, 0, len(desc.Objects)),
+		Updates:        make(map[string]updtree.NodeStats),
+	}
+
+	for _, obj := range desc.Objects {
+		id := obj.ID
+		if obj.Store != "" {
+			id = obj.Store + "/" + id
+		}
+
+		state.ObjectsByState[obj.State.String()]++
+		state.InitOrder = append(state.InitOrder, id)
+
+		if updates, ok := obj.Info[DebugInfoUpdates].(updtree.NodeStats); ok {
+			state.Updates[id] = updates
+		}
+	}
+
+	return state
+}
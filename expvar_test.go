@@ -0,0 +1,65 @@
+package shdep_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+	d := newDoubler()
+	store.Register(&d)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	d.counter.value = 2
+	d.counter.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 4, d.value)
+
+	var lock sync.Mutex
+	shdep.PublishExpvar(store, "shdep_test_store", shdep.ExpvarWithLock(&lock))
+	require.Panics(t, func() { shdep.PublishExpvar(store, "shdep_test_store") })
+
+	type updates struct {
+		Notifications  uint64 `json:"notifications"`
+		UpdatesHandled uint64 `json:"updatesHandled"`
+	}
+	type state struct {
+		Objects        int                `json:"objects"`
+		ObjectsByState map[string]int     `json:"objectsByState"`
+		InitOrder      []string           `json:"initOrder"`
+		Updates        map[string]updates `json:"updates"`
+	}
+
+	var s state
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("shdep_test_store").String()), &s))
+
+	counterID := shdep.ObjectID(d.counter)
+	doublerID := shdep.ObjectID(d)
+	require.Equal(t, state{
+		Objects:        2,
+		ObjectsByState: map[string]int{"started": 2},
+		InitOrder:      []string{counterID, doublerID},
+		Updates: map[string]updates{
+			counterID: {Notifications: 1, UpdatesHandled: 0},
+			doublerID: {Notifications: 0, UpdatesHandled: 1},
+		},
+	}, s)
+
+	// State is read on each request.
+	store.Stop()
+	s = state{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("shdep_test_store").String()), &s))
+	require.Equal(t, map[string]int{"stopped": 2}, s.ObjectsByState)
+
+	store.Close()
+}
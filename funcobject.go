@@ -0,0 +1,81 @@
+package shdep
+
+import (
+	"context"
+	"time"
+
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// FuncObjectSpec defines lifecycle methods of object created by NewFuncObject.
+// All fields are optional. Missing methods do nothing.
+type FuncObjectSpec[Ctx, InitParams any] struct {
+	RegisterDeps func(store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams])
+	Init         func(params InitParams) error
+	Start        func(params InitParams) error
+	OnUpdate     func(ctx Ctx, evtTime time.Time)
+	Stop         func()
+	Close        func()
+}
+
+// FuncObject is a shared object, which lifecycle methods are defined by functions.
+type FuncObject[Ctx, InitParams any] struct {
+	SharedObjectBase[Ctx, InitParams]
+	spec FuncObjectSpec[Ctx, InitParams]
+}
+
+// NewFuncObject creates shared object, which lifecycle methods are defined by functions of spec.
+// It is intended for small glue objects like loggers, forwarders or test probes.
+// Functions are not part of the hash, so like for any other object ALL parameters, which affect behaviour
+// of the functions, must be passed. Otherwise the object might get replaced by another object with same name
+// and parameters upon registration, and its functions will never be called.
+func NewFuncObject[Ctx, InitParams any](name string, params []interface{}, spec FuncObjectSpec[Ctx, InitParams]) *FuncObject[Ctx, InitParams] {
+	opts := []SharedObjectBaseOption{WithHashParams(params...)}
+	if spec.OnUpdate != nil {
+		opts = append(opts, WithUpdateHandler(spec.OnUpdate))
+	}
+
+	return &FuncObject[Ctx, InitParams]{
+		SharedObjectBase: NewSharedObjectBaseOpts[Ctx, InitParams](name, opts...),
+		spec:             spec,
+	}
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (o *FuncObject[Ctx, InitParams]) RegisterDependencies(store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]) {
+	if o.spec.RegisterDeps != nil {
+		o.spec.RegisterDeps(store)
+	}
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (o *FuncObject[Ctx, InitParams]) Init(params InitParams) error {
+	if o.spec.Init != nil {
+		return o.spec.Init(params)
+	}
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (o *FuncObject[Ctx, InitParams]) Start(params InitParams) error {
+	if o.spec.Start != nil {
+		return o.spec.Start(params)
+	}
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (o *FuncObject[Ctx, InitParams]) Stop() {
+	if o.spec.Stop != nil {
+		o.spec.Stop()
+	}
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (o *FuncObject[Ctx, InitParams]) Close() {
+	if o.spec.Close != nil {
+		o.spec.Close()
+	}
+}
+
+var _ SharedObject[context.Context, string] = &FuncObject[context.Context, string]{}
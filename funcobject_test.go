@@ -0,0 +1,40 @@
+package shdep_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncObject(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	var calls []string
+	c := newCounter()
+	var probe *shdep.FuncObject[context.Context, *InitParams]
+	probe = shdep.NewFuncObject("probe", []interface{}{1}, shdep.FuncObjectSpec[context.Context, *InitParams]{
+		RegisterDeps: func(store SharedStore) {
+			store.Register(&c)
+			c.SubscribeObj(probe)
+		},
+		Init:     func(params *InitParams) error { calls = append(calls, "init"); return nil },
+		OnUpdate: func(ctx context.Context, evtTime time.Time) { calls = append(calls, fmt.Sprint("update ", c.value)) },
+		Close:    func() { calls = append(calls, "close") },
+	})
+	store.Register(&probe)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	c.value = 5
+	c.NotifyUpdated(context.Background(), time.Now())
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	require.Equal(t, []string{"init", "update 5", "close"}, calls)
+}
@@ -0,0 +1,155 @@
+// Package httpdebug provides HTTP handler for live introspection of shared objects store.
+package httpdebug
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+type config struct {
+	lock sync.Locker
+}
+
+type Option func(c *config)
+
+// WithLock sets lock, which is held while reading the store.
+// See objstore.ReadLocked for which lock it must be.
+func WithLock(l sync.Locker) Option {
+	return func(c *config) {
+		c.lock = l
+	}
+}
+
+// Handler returns http.Handler, which serves description of the store:
+// dependencies, lifecycle state and debug info of each object.
+// It serves HTML view on the root path, JSON on path "json", Graphviz DOT on path "dot" and Mermaid flowchart
+// on path "mermaid", if the store is able to render them, and interactive graph of objects on path "ui".
+// Graph page is self-contained and renders dependencies and update subscriptions from the JSON description:
+// nodes are colored by lifecycle state and tooltips of update edges show counters of update nodes. Handler is intended to be mounted with trailing slash, e.g.:
+//
+//	mux.Handle("/debug/shdep/", httpdebug.Handler(store, httpdebug.WithLock(&lock)))
+func Handler(store objstore.Describer, opts ...Option) http.Handler {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return &handler{store: store, config: c}
+}
+
+type handler struct {
+	store  objstore.Describer
+	config *config
+}
+
+type dotRenderer interface {
+	DOT() string
+}
+
+type graphProvider interface {
+	Graph() objstore.ObjectGraph
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/json"):
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(h.describe()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	case strings.HasSuffix(r.URL.Path, "/ui"):
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(uiPage)
+
+	case strings.HasSuffix(r.URL.Path, "/dot"):
+		renderer, ok := h.store.(dotRenderer)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		var dot string
+		objstore.ReadLocked(h.config.lock, func() { dot = renderer.DOT() })
+
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, _ = w.Write([]byte(dot))
+
+	case strings.HasSuffix(r.URL.Path, "/mermaid"):
+		provider, ok := h.store.(graphProvider)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		var mermaid string
+		objstore.ReadLocked(h.config.lock, func() { mermaid = provider.Graph().Mermaid() })
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(mermaid))
+
+	default:
+		_, hasDOT := h.store.(dotRenderer)
+		_, hasMermaid := h.store.(graphProvider)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := pageTemplate.Execute(w, struct {
+			objstore.StoreDescription
+			HasDOT     bool
+			HasMermaid bool
+		}{h.describe(), hasDOT, hasMermaid})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func (h *handler) describe() objstore.StoreDescription {
+	return objstore.DescribeLocked(h.store, h.config.lock)
+}
+
+//go:embed ui.html
+var uiPage []byte
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"json": func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Shared objects</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; font-family: monospace; vertical-align: top; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Shared objects</h1>
+<p>{{len .Objects}} objects. <a href="ui">Graph</a> <a href="json">JSON</a>{{if .HasDOT}} <a href="dot">DOT</a>{{end}}{{if .HasMermaid}} <a href="mermaid">Mermaid</a>{{end}}</p>
+<table>
+<tr><th>ID</th><th>Type</th><th>State</th><th>Top-level</th><th>Dependencies</th><th>Info</th></tr>
+{{range .Objects}}<tr id="{{.ID}}">
+<td>{{.ID}}</td>
+<td>{{.Type}}</td>
+<td>{{.State}}{{if .Error}}<br>{{.Error}}{{end}}</td>
+<td>{{if .TopLevel}}yes{{end}}</td>
+<td>{{range .Dependencies}}<a href="#{{.}}">{{.}}</a><br>{{end}}</td>
+<td>{{if .Info}}{{json .Info}}{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,146 @@
+package httpdebug_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/httpdebug"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type SharedObject interface {
+	objstore.SharedObject[SharedObject, *InitParams]
+	ID() string
+}
+
+type testObj struct {
+	id   string
+	deps []*testObj
+}
+
+func (o *testObj) ID() string { return o.id }
+
+func (o *testObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	for i := range o.deps {
+		s.Register(&o.deps[i])
+	}
+}
+
+func (o *testObj) Init(p *InitParams) error  { return nil }
+func (o *testObj) Start(p *InitParams) error { return nil }
+func (o *testObj) Stop()                     {}
+func (o *testObj) Close()                    {}
+
+func (o *testObj) DebugInfo() map[string]interface{} {
+	return map[string]interface{}{"deps": len(o.deps)}
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	top := &testObj{id: "top", deps: []*testObj{{id: "provider"}}}
+	store.Register(&top)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/shdep/", httpdebug.Handler(store, httpdebug.WithLock(&sync.Mutex{})))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func get(t *testing.T, url string) (string, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(body), resp.Header.Get("Content-Type")
+}
+
+func TestHandler_JSON(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	body, contentType := get(t, srv.URL+"/debug/shdep/json")
+	require.Equal(t, "application/json", contentType)
+
+	var desc struct {
+		Objects []struct {
+			ID           string                 `json:"id"`
+			State        string                 `json:"state"`
+			TopLevel     bool                   `json:"topLevel"`
+			Dependencies []string               `json:"dependencies"`
+			Info         map[string]interface{} `json:"info"`
+		} `json:"objects"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &desc))
+
+	require.Len(t, desc.Objects, 2)
+	require.Equal(t, "provider", desc.Objects[0].ID)
+	require.Equal(t, "started", desc.Objects[0].State)
+	require.False(t, desc.Objects[0].TopLevel)
+	require.Equal(t, "top", desc.Objects[1].ID)
+	require.True(t, desc.Objects[1].TopLevel)
+	require.Equal(t, []string{"provider"}, desc.Objects[1].Dependencies)
+	require.Equal(t, float64(1), desc.Objects[1].Info["deps"])
+}
+
+func TestHandler_HTML(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	body, contentType := get(t, srv.URL+"/debug/shdep/")
+	require.Equal(t, "text/html; charset=utf-8", contentType)
+	require.Contains(t, body, `<a href="#provider">provider</a>`)
+	require.Contains(t, body, `<a href="dot">DOT</a>`)
+}
+
+func TestHandler_DOT(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	body, _ := get(t, srv.URL+"/debug/shdep/dot")
+	require.Contains(t, body, "digraph")
+	require.Contains(t, body, "->")
+}
+
+func TestHandler_Mermaid(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	body, _ := get(t, srv.URL+"/debug/shdep/mermaid")
+	require.Contains(t, body, "flowchart TD")
+	require.Contains(t, body, "n1 --> n0")
+
+	body, _ = get(t, srv.URL+"/debug/shdep/")
+	require.Contains(t, body, `<a href="mermaid">Mermaid</a>`)
+}
+
+func TestHandler_UI(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	body, contentType := get(t, srv.URL+"/debug/shdep/ui")
+	require.Equal(t, "text/html; charset=utf-8", contentType)
+	require.Contains(t, body, `fetch("json")`)
+
+	body, _ = get(t, srv.URL+"/debug/shdep/")
+	require.Contains(t, body, `<a href="ui">Graph</a>`)
+}
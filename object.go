@@ -1,7 +1,10 @@
 package shdep
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/nnikolash/go-shdep/objstore"
@@ -17,13 +20,107 @@ type SharedObject[Ctx, InitParams any] interface {
 	GetUpdateNode() updtree.Node[Ctx]
 }
 
+// Subscription is a handle of subscription returned by SubscribeObj.
+type Subscription = updtree.Subscription
+
 func NewSharedObjectBase[Ctx, InitParams any](name string, params ...interface{}) SharedObjectBase[Ctx, InitParams] {
 	if len(params) == 0 {
 		panic("no params provided for hash")
 	}
-	hash := utils.Must2(utils.Hash(append(params, name)...))
+
+	return newSharedObjectBase[Ctx, InitParams](name, params, "", false)
+}
+
+type sharedObjectBaseConfig struct {
+	params        []interface{}
+	hash          string
+	updateHandler interface{}
+	mutePolicy    updtree.MutePolicy
+	capture       bool
+}
+
+type SharedObjectBaseOption func(c *sharedObjectBaseConfig)
+
+// WithHashParams sets parameters of the object, from which its hash is calculated.
+// Same as parameters of NewSharedObjectBase.
+func WithHashParams(params ...interface{}) SharedObjectBaseOption {
+	return func(c *sharedObjectBaseConfig) {
+		c.params = append(c.params, params...)
+	}
+}
+
+// WithExplicitHash sets hash of the object instead of calculating it from parameters.
+// Caller is responsible for the hash to be different for objects with different parameters.
+// Parameters passed with WithHashParams are then used only for description of the object.
+func WithExplicitHash(hash string) SharedObjectBaseOption {
+	return func(c *sharedObjectBaseConfig) {
+		c.hash = hash
+	}
+}
+
+// WithUpdateHandler sets function, which will be called when any of subscriptions has updated.
+// Same as SharedObjectBase.SetUpdateHandler. Context type of the handler must match context type of the object.
+func WithUpdateHandler[Ctx any](handler func(ctx Ctx, evtTime time.Time)) SharedObjectBaseOption {
+	return func(c *sharedObjectBaseConfig) {
+		c.updateHandler = handler
+	}
+}
+
+// WithMutePolicy sets what happens with updates of subscriptions, while the object is disabled by SetEnabled.
+// By default they are dropped.
+func WithMutePolicy(policy updtree.MutePolicy) SharedObjectBaseOption {
+	return func(c *sharedObjectBaseConfig) {
+		c.mutePolicy = policy
+	}
+}
+
+// WithCapturedParams makes the object keep its parameters in the serialized form, from which its hash is calculated.
+// By default objects keep passed parameters as is, so if caller mutates passed slice or map afterwards, description
+// of parameters (e.g. in errors about objects with same ID) no longer matches the ID of the object.
+// Captured parameters are returned by SharedObjectBase.Params.
+func WithCapturedParams() SharedObjectBaseOption {
+	return func(c *sharedObjectBaseConfig) {
+		c.capture = true
+	}
+}
+
+// NewSharedObjectBaseOpts creates new SharedObjectBase configured by options.
+// Either WithHashParams or WithExplicitHash must be provided.
+func NewSharedObjectBaseOpts[Ctx, InitParams any](name string, opts ...SharedObjectBaseOption) SharedObjectBase[Ctx, InitParams] {
+	var c sharedObjectBaseConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if len(c.params) == 0 && c.hash == "" {
+		panic(fmt.Sprintf("neither params nor explicit hash provided for shared object %v", name))
+	}
+
+	o := newSharedObjectBase[Ctx, InitParams](name, c.params, c.hash, c.capture)
+	o.mutePolicy = c.mutePolicy
+
+	if c.updateHandler != nil {
+		handler, ok := c.updateHandler.(func(ctx Ctx, evtTime time.Time))
+		if !ok {
+			panic(fmt.Sprintf("update handler of shared object %v has type %T, but context of the object is %T", name, c.updateHandler, *new(Ctx)))
+		}
+		o.updateNode.SetUpdateHandler(handler)
+	}
+
+	return o
+}
+
+func newSharedObjectBase[Ctx, InitParams any](name string, params []interface{}, hash string, capture bool) SharedObjectBase[Ctx, InitParams] {
+	// Capacity is limited, so that append does not write into array of the caller.
+	hashed, err := utils.CanonicalJSON(append(params[:len(params):len(params)], name)...)
+	utils.MustMsg(err, "failed to calculate hash of shared object %v with params %v", name, params)
+
 	if hash == "" {
-		panic("hash is empty")
+		hash, err = utils.HashJSON(hashed)
+		utils.MustMsg(err, "failed to calculate hash of shared object %v with params %v", name, params)
+		if hash == "" {
+			panic("hash is empty")
+		}
 	}
 
 	o := SharedObjectBase[Ctx, InitParams]{
@@ -32,6 +129,14 @@ func NewSharedObjectBase[Ctx, InitParams any](name string, params ...interface{}
 		name:       name,
 	}
 
+	if capture {
+		var compacted bytes.Buffer
+		utils.MustMsg(json.Compact(&compacted, hashed), "failed to compact parameters of shared object %v", name)
+		o.capturedParams = compacted.String()
+	} else {
+		o.params = params
+	}
+
 	return o
 }
 
@@ -45,6 +150,10 @@ type SharedObjectBase[Ctx, InitParams any] struct {
 	updateNode updtree.NodeBase[Ctx]
 	name       string
 	hash       string
+	params     []interface{}
+	mutePolicy updtree.MutePolicy
+	// Parameters and name serialized as JSON array, if they were captured upon construction.
+	capturedParams string
 }
 
 // Hash is used as unique ID of the object.
@@ -62,6 +171,21 @@ func (o *SharedObjectBase[Ctx, InitParams]) Name() string {
 	return o.name
 }
 
+// DescribeParams returns name and parameters of the object, from which its hash is calculated.
+func (o *SharedObjectBase[Ctx, InitParams]) DescribeParams() string {
+	if o.capturedParams != "" {
+		return o.name + o.capturedParams
+	}
+
+	return fmt.Sprintf("%v%v", o.name, o.params)
+}
+
+// Params returns parameters and name of the object serialized as JSON array, from which its hash is calculated,
+// or empty string if parameters were not captured (see WithCapturedParams).
+func (o *SharedObjectBase[Ctx, InitParams]) Params() string {
+	return o.capturedParams
+}
+
 // SetUpdateHandler sets function, which will be called when any of subscriptions has updated.
 func (s *SharedObjectBase[Ctx, InitParams]) SetUpdateHandler(handler func(ctx Ctx, evtTime time.Time)) {
 	s.updateNode.SetUpdateHandler(handler)
@@ -85,11 +209,11 @@ func (o *SharedObjectBase[Ctx, InitParams]) Start(params InitParams) error {
 	return nil
 }
 
-// One of lifecycle methods. See SharedObject interface for details.
+// One of lifecycle methods. See SharedObject interface for details. Does nothing, so it is safe to call it multiple times.
 func (o *SharedObjectBase[Ctx, InitParams]) Stop() {
 }
 
-// One of lifecycle methods. See SharedObject interface for details.
+// One of lifecycle methods. See SharedObject interface for details. Does nothing, so it is safe to call it multiple times.
 func (o *SharedObjectBase[Ctx, InitParams]) Close() {
 }
 
@@ -99,12 +223,34 @@ func (s *SharedObjectBase[Ctx, InitParams]) NotifyUpdated(ctx Ctx, evtTime time.
 }
 
 // SubscribeObj subscribes given object to updates of receiver.
-func (o *SharedObjectBase[Ctx, InitParams]) SubscribeObj(subscriber SharedObject[Ctx, InitParams]) {
-	o.updateNode.Subscribe(subscriber.GetUpdateNode())
+// Returns handle of the subscription, which can be used to give it a name for diagnostics.
+func (o *SharedObjectBase[Ctx, InitParams]) SubscribeObj(subscriber SharedObject[Ctx, InitParams]) *Subscription {
+	return o.updateNode.Subscribe(subscriber.GetUpdateNode())
+}
+
+func (o *SharedObjectBase[Ctx, InitParams]) subscribeObjUntyped(subscriber interface{}) {
+	typed, ok := subscriber.(SharedObject[Ctx, InitParams])
+	if !ok {
+		utils.Fail(fmt.Errorf("object of type %T cannot subscribe to %v, because it does not implement shared object interface of the publisher", subscriber, o.name))
+		return
+	}
+
+	o.SubscribeObj(typed)
+}
+
+func (o *SharedObjectBase[Ctx, InitParams]) Subscribe(subscriber updtree.Node[Ctx]) *Subscription {
+	return o.updateNode.Subscribe(subscriber)
 }
 
-func (o *SharedObjectBase[Ctx, InitParams]) Subscribe(subscriber updtree.Node[Ctx]) {
-	o.updateNode.Subscribe(subscriber)
+// SetEnabled mutes or unmutes update handler of the object. See updtree.NodeBase.Mute for details.
+// Subscriptions and subscribers of the object are kept, so it can be enabled again at any time.
+// Updates received while disabled are dropped or buffered according to WithMutePolicy.
+func (o *SharedObjectBase[Ctx, InitParams]) SetEnabled(enabled bool) {
+	if enabled {
+		o.updateNode.Unmute()
+	} else {
+		o.updateNode.Mute(o.mutePolicy)
+	}
 }
 
 // Check that this node has been updated. Can be used, when processing
@@ -113,16 +259,41 @@ func (o *SharedObjectBase[Ctx, InitParams]) HasUpdated() bool {
 	return o.updateNode.HasUpdated()
 }
 
-var _ SharedObject[context.Context, string] = &SharedObjectBase[context.Context, string]{}
+// Keys of debug info provided by SharedObjectBase and SharedObjectBaseWithEvent.
+const (
+	DebugInfoName        = "name"        // string
+	DebugInfoNode        = "node"        // string, unique identifier of update node
+	DebugInfoSubscribers = "subscribers" // []string, identifiers of update nodes subscribed to the object
+	DebugInfoUpdates     = "updates"     // updtree.NodeStats
+	DebugInfoEvents      = "events"      // updtree.EventsStats
+	DebugInfoCache       = "cache"       // map[string]interface{}, validity and number of computations of CachedValue
+	DebugInfoState       = "state"       // string, current state of StateMachine
+	// []updtree.AbandonedPuller, present only if leak detection is enabled and some of pullers look abandoned.
+	DebugInfoAbandonedPullers = "abandonedPullers"
+)
 
-type EventPuller[Event any] interface {
-	// Pulls all events from the storage published since last pull.
-	Pull() []updtree.AccumulatedEvent[Event]
+// DebugInfo returns name of the object, subscribers and counters of its update node.
+func (o *SharedObjectBase[Ctx, InitParams]) DebugInfo() map[string]interface{} {
+	subscribers := o.updateNode.Subscribers()
+	subscriberIDs := make([]string, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		subscriberIDs = append(subscriberIDs, subscriber.ID())
+	}
 
-	// Discards all events except the last one. If no events were published since last pull, returns nil.
-	Last() (lastPublishedEventIfExists *Event)
+	return map[string]interface{}{
+		DebugInfoName:        o.name,
+		DebugInfoNode:        o.updateNode.ID(),
+		DebugInfoSubscribers: subscriberIDs,
+		DebugInfoUpdates:     o.updateNode.Stats(),
+	}
 }
 
+var _ SharedObject[context.Context, string] = &SharedObjectBase[context.Context, string]{}
+var _ objstore.DebugInfoProvider = &SharedObjectBase[context.Context, string]{}
+var _ objstore.ParamsDescriber = &SharedObjectBase[context.Context, string]{}
+var _ objstore.Switchable = &SharedObjectBase[context.Context, string]{}
+var _ EventPublisher[int] = &SharedObjectBaseWithEvent[context.Context, string, int]{}
+
 // NewSharedObjectBaseWithEvent creates new SharedObjectBaseWithEvent.
 // SharedObjectBaseWithEvent is same as SharedObjectBase, but with event publishing capabilities.
 func NewSharedObjectBaseWithEvent[Ctx, InitParams any, Event any](name string, params ...interface{}) SharedObjectBaseWithEvent[Ctx, InitParams, Event] {
@@ -132,6 +303,15 @@ func NewSharedObjectBaseWithEvent[Ctx, InitParams any, Event any](name string, p
 	}
 }
 
+// NewSharedObjectBaseWithEventOpts creates new SharedObjectBaseWithEvent configured by options.
+// See NewSharedObjectBaseOpts for details.
+func NewSharedObjectBaseWithEventOpts[Ctx, InitParams any, Event any](name string, opts ...SharedObjectBaseOption) SharedObjectBaseWithEvent[Ctx, InitParams, Event] {
+	return SharedObjectBaseWithEvent[Ctx, InitParams, Event]{
+		SharedObjectBase: NewSharedObjectBaseOpts[Ctx, InitParams](name, opts...),
+		evtPublisher:     updtree.NewEventsPullStorage[Event](),
+	}
+}
+
 // SharedObjectBaseWithEvent is same as SharedObjectBase, but with event publishing capabilities.
 type SharedObjectBaseWithEvent[Ctx, InitParams any, Event any] struct {
 	SharedObjectBase[Ctx, InitParams]
@@ -149,3 +329,14 @@ func (o *SharedObjectBaseWithEvent[Ctx, InitParams, Event]) PublishEvent(ctx Ctx
 	o.evtPublisher.Publish(evt)
 	o.NotifyUpdated(ctx, evtTime)
 }
+
+// DebugInfo returns same info as SharedObjectBase.DebugInfo and statistics of published events.
+func (o *SharedObjectBaseWithEvent[Ctx, InitParams, Event]) DebugInfo() map[string]interface{} {
+	info := o.SharedObjectBase.DebugInfo()
+	info[DebugInfoEvents] = o.evtPublisher.Stats()
+	if abandoned := o.evtPublisher.AbandonedPullers(); len(abandoned) > 0 {
+		info[DebugInfoAbandonedPullers] = abandoned
+	}
+
+	return info
+}
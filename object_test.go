@@ -0,0 +1,88 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureParams(t *testing.T) {
+	t.Parallel()
+
+	symbols := []string{"BTC", "ETH"}
+	obj := shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("portfolio",
+		shdep.WithHashParams(symbols, map[string]int{"b": 2, "a": 1}), shdep.WithCapturedParams())
+	symbols[0] = "XRP"
+
+	require.Equal(t, `[["BTC","ETH"],{"a":1,"b":2},"portfolio"]`, obj.Params())
+	require.Equal(t, `portfolio[["BTC","ETH"],{"a":1,"b":2},"portfolio"]`, obj.DescribeParams())
+	same := NewSharedObjectBase("portfolio", []string{"BTC", "ETH"}, map[string]int{"a": 1, "b": 2})
+	require.Equal(t, same.Hash(), obj.Hash())
+}
+
+func TestObjectBaseOpts(t *testing.T) {
+	t.Parallel()
+
+	updates := 0
+	obj := shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("ma",
+		shdep.WithHashParams("BTC", 10),
+		shdep.WithUpdateHandler(func(ctx context.Context, evtTime time.Time) { updates++ }),
+	)
+	positional := NewSharedObjectBase("ma", "BTC", 10)
+	require.Equal(t, positional.Hash(), obj.Hash())
+
+	src := NewSharedObjectBase("src", 1)
+	src.SubscribeObj(&obj)
+	src.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 1, updates)
+
+	explicit := shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("feed", shdep.WithExplicitHash("BTC"), shdep.WithHashParams("BTC"))
+	require.Equal(t, "BTC", explicit.Hash())
+	require.Equal(t, "feed[BTC]", explicit.DescribeParams())
+
+	require.Panics(t, func() { shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("empty") })
+	require.Panics(t, func() {
+		shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("wrongCtx", shdep.WithHashParams(1), shdep.WithUpdateHandler(func(ctx int, evtTime time.Time) {}))
+	})
+}
+
+func TestEventPuller(t *testing.T) {
+	t.Parallel()
+
+	tk := newTicker()
+	var puller shdep.EventPuller[int] = tk.NewEventPuller()
+	tk.PublishEvent(context.Background(), time.Now(), 1)
+	tk.PublishEvent(context.Background(), time.Now(), 2)
+	tk.PublishEvent(context.Background(), time.Now(), 3)
+
+	require.Equal(t, 3, puller.Unread())
+	first := puller.PullN(1)
+	require.Len(t, first, 1)
+	require.Equal(t, 1, *first[0].Event)
+	require.Equal(t, 3, *puller.Last())
+	require.Nil(t, puller.Last())
+
+	puller.Close()
+	tk.PublishEvent(context.Background(), time.Now(), 4)
+	require.Nil(t, puller.Pull())
+	require.Equal(t, 0, tk.DebugInfo()[shdep.DebugInfoEvents].(shdep.EventsStats).Pullers)
+}
+
+func TestSubscribeTo(t *testing.T) {
+	t.Parallel()
+
+	tk := newTicker()
+	s := &tickerSum{SharedObjectBase: NewSharedObjectBase("tickerSum", 3)}
+	s.ticks = shdep.SubscribeTo[int](tk, s)
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		s.sum += s.ticks.LastOr(0)
+	})
+
+	tk.PublishEvent(context.Background(), time.Now(), 7)
+	require.Equal(t, 7, s.sum)
+
+	require.Panics(t, func() { shdep.SubscribeTo[int](tk, "not an object") })
+}
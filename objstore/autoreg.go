@@ -0,0 +1,84 @@
+package objstore
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// AutoRegistered is implemented by fields of shared objects, which register dependencies automatically, e.g. shdep.Dep.
+// Store created by NewStore finds such fields of the object, including fields of embedded structs,
+// and registers them before calling RegisterDependencies of the object.
+type AutoRegistered interface {
+	AutoRegister(r AutoRegistrar)
+}
+
+// AutoRegistrar is passed into AutoRegistered fields of the object to register dependencies they hold.
+type AutoRegistrar interface {
+	// Register is same as SharedStore.Register.
+	Register(depPtr interface{})
+	// RegisterAndSubscribeOwner registers dependency and subscribes object owning the field to its updates.
+	// Unlike SharedStore.RegisterAndSubscribe, it does not create event puller.
+	RegisterAndSubscribeOwner(depPtr interface{})
+}
+
+type autoRegistrar[SharedObject any, ObjID comparable, InitParams any] struct {
+	store *GenericStore[SharedObject, ObjID, InitParams]
+	owner SharedObject
+}
+
+func (r *autoRegistrar[SharedObject, ObjID, InitParams]) Register(depPtr interface{}) {
+	r.store.Register(depPtr)
+}
+
+func (r *autoRegistrar[SharedObject, ObjID, InitParams]) RegisterAndSubscribeOwner(depPtr interface{}) {
+	r.store.Register(depPtr)
+	if _, err := subscribeRegistered(depPtr, r.owner, false); err != nil {
+		r.store.reportFailure(err)
+	}
+}
+
+var autoRegisteredType = reflect.TypeOf((*AutoRegistered)(nil)).Elem()
+
+// registerAutoDependencies registers dependencies held by AutoRegistered fields of the object.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) registerAutoDependencies(obj SharedObject) {
+	objV := reflect.ValueOf(obj)
+	if objV.Kind() != reflect.Pointer || objV.IsNil() || objV.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	fields, cached := s.autoRegisteredFields[objV.Type()]
+	if !cached {
+		fields = findAutoRegisteredFields(objV.Type().Elem(), nil)
+		if s.autoRegisteredFields == nil {
+			s.autoRegisteredFields = make(map[reflect.Type][][]int)
+		}
+		s.autoRegisteredFields[objV.Type()] = fields
+	}
+
+	r := &autoRegistrar[SharedObject, ObjID, InitParams]{store: s, owner: obj}
+	for _, index := range fields {
+		field := objV.Elem().FieldByIndex(index)
+		// Fields are usually unexported, so they are accessed through unsafe pointer.
+		fieldPtr := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr()))
+		fieldPtr.Interface().(AutoRegistered).AutoRegister(r)
+	}
+}
+
+// findAutoRegisteredFields returns indexes of fields of struct type t, pointers to which implement AutoRegistered.
+// Embedded structs are searched recursively.
+func findAutoRegisteredFields(t reflect.Type, parentIndex []int) [][]int {
+	var fields [][]int
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(parentIndex[:len(parentIndex):len(parentIndex)], i)
+
+		if reflect.PointerTo(field.Type).Implements(autoRegisteredType) {
+			fields = append(fields, index)
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, findAutoRegisteredFields(field.Type, index)...)
+		}
+	}
+
+	return fields
+}
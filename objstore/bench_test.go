@@ -0,0 +1,182 @@
+package objstore_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// benchObj is a lightweight shared object with precalculated ID,
+// so that benchmarks measure store itself and not hashing of parameters.
+type benchObj struct {
+	id   string
+	deps []*benchObj
+}
+
+func (o *benchObj) ID() string {
+	return o.id
+}
+
+func (o *benchObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	for i := range o.deps {
+		s.Register(&o.deps[i])
+	}
+}
+
+func (o *benchObj) Init(p *InitParams) error  { return nil }
+func (o *benchObj) Start(p *InitParams) error { return nil }
+func (o *benchObj) Stop()                     {}
+func (o *benchObj) Close()                    {}
+
+// benchChain creates chain of objects, where each object depends on the previous one.
+// Only the last object is top-level.
+func benchChain(size int) []*benchObj {
+	var prev *benchObj
+
+	for i := 0; i < size; i++ {
+		o := &benchObj{id: fmt.Sprintf("chain-%v", i)}
+		if prev != nil {
+			o.deps = []*benchObj{{id: prev.id, deps: prev.deps}}
+		}
+		prev = o
+	}
+
+	return []*benchObj{prev}
+}
+
+// benchFanOut creates top-level objects, which all depend on the same provider.
+func benchFanOut(size int) []*benchObj {
+	objs := make([]*benchObj, 0, size)
+
+	for i := 0; i < size; i++ {
+		objs = append(objs, &benchObj{
+			id:   fmt.Sprintf("fanout-%v", i),
+			deps: []*benchObj{{id: "provider"}},
+		})
+	}
+
+	return objs
+}
+
+// benchDiamonds creates layers of objects, where each object depends on two objects of the previous layer.
+func benchDiamonds(size int) []*benchObj {
+	const width = 10
+
+	var prevLayer []*benchObj
+
+	for layer := 0; layer*width < size; layer++ {
+		currLayer := make([]*benchObj, 0, width)
+
+		for i := 0; i < width; i++ {
+			o := &benchObj{id: fmt.Sprintf("diamond-%v-%v", layer, i)}
+			if prevLayer != nil {
+				d1, d2 := prevLayer[i], prevLayer[(i+1)%width]
+				o.deps = []*benchObj{{id: d1.id, deps: d1.deps}, {id: d2.id, deps: d2.deps}}
+			}
+			currLayer = append(currLayer, o)
+		}
+
+		prevLayer = currLayer
+	}
+
+	return prevLayer
+}
+
+var benchTopologies = []struct {
+	name  string
+	build func(size int) []*benchObj
+}{
+	{"Chain", benchChain},
+	{"FanOut", benchFanOut},
+	{"Diamonds", benchDiamonds},
+}
+
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+func newBenchStore() *objstore.GenericStore[SharedObject, string, *InitParams] {
+	return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+}
+
+// BenchmarkStore_Register measures registration of many top-level objects.
+// Registration of dependencies happens during Init and is measured by BenchmarkStore_Init.
+func BenchmarkStore_Register(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("FanOut/%v", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				store := newBenchStore()
+				objs := benchFanOut(size)
+				b.StartTimer()
+
+				for j := range objs {
+					store.Register(&objs[j])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStore_Init(b *testing.B) {
+	for _, topology := range benchTopologies {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("%v/%v", topology.name, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					store := newBenchStore()
+					objs := topology.build(size)
+					for j := range objs {
+						store.Register(&objs[j])
+					}
+					b.StartTimer()
+
+					if err := store.Init(&InitParams{}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkStore_RegisterExisting measures registration of already registered object,
+// which is the most frequent case when many objects share same dependency.
+func BenchmarkStore_RegisterExisting(b *testing.B) {
+	b.Run("Reflection", func(b *testing.B) {
+		store := newBenchStore()
+		provider := &benchObj{id: "provider"}
+		store.Register(&provider)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			local := &benchObj{id: "provider"}
+			store.Register(&local)
+
+			if i%1000 == 0 {
+				store.RecentlyRegisteredSharedObjects()
+			}
+		}
+	})
+
+	b.Run("Generic", func(b *testing.B) {
+		var store objstore.SharedStore[SharedObject, *InitParams] = newBenchStore()
+		provider := &benchObj{id: "provider"}
+		objstore.Register(store, &provider)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			local := &benchObj{id: "provider"}
+			objstore.Register(store, &local)
+
+			if i%1000 == 0 {
+				store.RecentlyRegisteredSharedObjects()
+			}
+		}
+	})
+}
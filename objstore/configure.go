@@ -0,0 +1,41 @@
+package objstore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigProvider is an optional interface of init params. It allows one configuration document to
+// parameterize objects of the store without passing configuration into constructors of each of them.
+type ConfigProvider interface {
+	// ConfigFor returns configuration of the object with the ID, or nil if there is none.
+	// IDs are formatted using fmt.Sprint.
+	ConfigFor(objID string) interface{}
+}
+
+// Configurable is an optional interface of shared objects, which receive their configuration from init params.
+// If init params implement ConfigProvider, Configure is called with configuration of the object right before its Init.
+// It is not called, if there is no configuration for the object. Returned error fails Init of the object.
+type Configurable interface {
+	Configure(cfg interface{}) error
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) configureObj(obj SharedObject, objID ObjID, params InitParams) error {
+	configurable, ok := any(obj).(Configurable)
+	if !ok {
+		return nil
+	}
+
+	provider, ok := any(params).(ConfigProvider)
+	if !ok {
+		return nil
+	}
+
+	cfg := provider.ConfigFor(fmt.Sprint(objID))
+	if cfg == nil {
+		return nil
+	}
+
+	return errors.Wrapf(configurable.Configure(cfg), "failed to configure object")
+}
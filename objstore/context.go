@@ -0,0 +1,31 @@
+package objstore
+
+import "context"
+
+// ContextInitializer is an optional interface of shared objects, which need context of the store initialization,
+// e.g. to cancel loading of historical data. If object implements it, InitContext is called instead of Init.
+type ContextInitializer[InitParams any] interface {
+	InitContext(ctx context.Context, p InitParams) error
+}
+
+// ContextStarter is an optional interface of shared objects, which need context of the store start,
+// e.g. to limit time of connecting to exchange. If object implements it, StartContext is called instead of Start.
+type ContextStarter[InitParams any] interface {
+	StartContext(ctx context.Context, p InitParams) error
+}
+
+// ContextStopper is an optional interface of shared objects, which need context of the store stop,
+// e.g. as deadline of graceful shutdown. If object implements it, StopContext is called instead of Stop.
+type ContextStopper interface {
+	StopContext(ctx context.Context)
+}
+
+// lifecycleContext returns context of running lifecycle method of the store, or background context,
+// e.g. for objects registered by RegisterLive.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) lifecycleContext() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
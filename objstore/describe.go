@@ -0,0 +1,253 @@
+package objstore
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectState is a lifecycle state of a shared object in the store.
+type ObjectState int
+
+const (
+	ObjectRegistered ObjectState = iota
+	ObjectInitialized
+	ObjectStarted
+	ObjectStopped
+	ObjectClosed
+	// Lifecycle method of the object has returned error.
+	ObjectFailed
+	// Init or Start of the object has returned ErrSkip, so the object is inactive.
+	ObjectSkipped
+
+	// State of objects, which are not known to the store.
+	ObjectUnknown ObjectState = -1
+)
+
+func (s ObjectState) String() string {
+	switch s {
+	case ObjectRegistered:
+		return "registered"
+	case ObjectInitialized:
+		return "initialized"
+	case ObjectStarted:
+		return "started"
+	case ObjectStopped:
+		return "stopped"
+	case ObjectClosed:
+		return "closed"
+	case ObjectFailed:
+		return "failed"
+	case ObjectSkipped:
+		return "skipped"
+	case ObjectUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+func (s ObjectState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ObjectState) UnmarshalText(text []byte) error {
+	for state := ObjectUnknown; state <= ObjectSkipped; state++ {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown object state: %v", string(text))
+}
+
+// DebugInfoProvider is an optional interface of shared objects.
+// Objects can implement it to provide details about their state to debugging tools.
+type DebugInfoProvider interface {
+	DebugInfo() map[string]interface{}
+}
+
+// ParamsDescriber is an optional interface of shared objects.
+// Objects can implement it to describe parameters, from which their ID is derived. The description is included
+// into errors about objects with same ID, so that it is clear which parameters have produced colliding IDs.
+type ParamsDescriber interface {
+	DescribeParams() string
+}
+
+// ObjectDescription describes shared object for debugging tools.
+// IDs are formatted using fmt.Sprint.
+type ObjectDescription struct {
+	// Store, which owns the object, if the store consists of multiple stores, e.g. shard of ShardedStore.
+	Store        string        `json:"store,omitempty"`
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	State        ObjectState   `json:"state"`
+	TopLevel     bool          `json:"topLevel"`
+	Dependencies []string      `json:"dependencies"`
+	InitDuration time.Duration `json:"initDuration"`
+	// Whether the object has been disabled by SetEnabled.
+	Disabled bool `json:"disabled,omitempty"`
+	// Error returned by lifecycle method of the object, if its state is ObjectFailed.
+	Error string                 `json:"error,omitempty"`
+	Info  map[string]interface{} `json:"info,omitempty"`
+}
+
+// StoreDescription describes content of the store for debugging tools.
+type StoreDescription struct {
+	// Objects in initialization order, or in registration order if store is not initialized yet.
+	Objects []ObjectDescription `json:"objects"`
+}
+
+// Describer is implemented by stores, which can describe their content.
+type Describer interface {
+	Describe() StoreDescription
+}
+
+// ReadLocked calls f, which reads the store, holding l, unless l is nil. Stores are not thread-safe,
+// so tools reading them from other goroutines, e.g. HTTP handlers or metrics collectors, must hold the lock,
+// which protects the store from concurrent updates, e.g. updtree.UpdateLock.
+func ReadLocked(l sync.Locker, f func()) {
+	if l != nil {
+		l.Lock()
+		defer l.Unlock()
+	}
+
+	f()
+}
+
+// DescribeLocked returns description of the store read by ReadLocked.
+func DescribeLocked(d Describer, l sync.Locker) StoreDescription {
+	var desc StoreDescription
+	ReadLocked(l, func() { desc = d.Describe() })
+
+	return desc
+}
+
+var _ Describer = &GenericStore[interface{}, string, interface{}]{}
+var _ Describer = &ShardedStore[interface{}, string, interface{}]{}
+
+// Describe returns description of all objects of the store.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Describe() StoreDescription {
+	order := s.initializationOrder
+	if len(order) == 0 {
+		order = s.objectsRegistrationOrder
+	}
+
+	topLevelIDs := s.topLevelDependencies
+	if len(s.initializationOrder) == 0 {
+		// Before initialization only top-level objects are registered.
+		topLevelIDs = s.dependencies
+	}
+
+	topLevel := make(map[ObjID]struct{}, len(topLevelIDs))
+	for _, objID := range topLevelIDs {
+		topLevel[objID] = struct{}{}
+	}
+
+	objects := make([]ObjectDescription, 0, len(order))
+	for _, objID := range order {
+		obj := s.objects[objID]
+		_, isTopLevel := topLevel[objID]
+
+		dependencies := make([]string, 0, len(s.dependenciesGraph[objID]))
+		for _, dependency := range s.dependenciesGraph[objID] {
+			dependencies = append(dependencies, fmt.Sprint(dependency))
+		}
+
+		desc := ObjectDescription{
+			ID:           fmt.Sprint(objID),
+			Type:         fmt.Sprintf("%T", obj),
+			State:        s.states[objID],
+			TopLevel:     isTopLevel,
+			Dependencies: dependencies,
+			InitDuration: s.initDurations[objID],
+		}
+		_, desc.Disabled = s.disabled[objID]
+
+		if failure, ok := s.failures[objID]; ok {
+			desc.Error = failure.Error()
+		}
+
+		if infoProvider, ok := any(obj).(DebugInfoProvider); ok {
+			desc.Info = infoProvider.DebugInfo()
+		}
+
+		objects = append(objects, desc)
+	}
+
+	return StoreDescription{Objects: objects}
+}
+
+// Describe returns description of objects of parent store followed by objects of all shards.
+// Field Store of objects is set to "parent" or "shard-N".
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Describe() StoreDescription {
+	desc := s.parent.Describe()
+	for i := range desc.Objects {
+		desc.Objects[i].Store = "parent"
+	}
+
+	for shardIdx, shard := range s.shards {
+		shardName := fmt.Sprintf("shard-%v", shardIdx)
+		for _, obj := range shard.Describe().Objects {
+			obj.Store = shardName
+			desc.Objects = append(desc.Objects, obj)
+		}
+	}
+
+	return desc
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) String() string {
+	return fmt.Sprintf("store(objects: %v, phase: %v)", len(s.objects), s.phase)
+}
+
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) String() string {
+	objects := len(s.parent.objects)
+	for _, shard := range s.shards {
+		objects += len(shard.objects)
+	}
+
+	return fmt.Sprintf("sharded store(shards: %v, objects: %v, phase: %v)", len(s.shards), objects, s.parent.phase)
+}
+
+// Dump returns multi-line summary of the store: one line per object with its state and dependencies,
+// in initialization order. Intended for test failures and logs.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Dump() string {
+	return dumpDescription(s.String(), s.Describe())
+}
+
+// Dump returns same summary as GenericStore.Dump for parent store and all shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Dump() string {
+	return dumpDescription(s.String(), s.Describe())
+}
+
+func dumpDescription(header string, desc StoreDescription) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("\n")
+
+	for _, obj := range desc.Objects {
+		b.WriteString("  ")
+		if obj.Store != "" {
+			b.WriteString(obj.Store + "/")
+		}
+		fmt.Fprintf(&b, "%v [%v]", obj.ID, obj.State)
+		if obj.TopLevel {
+			b.WriteString(" top-level")
+		}
+		if obj.Disabled {
+			b.WriteString(" disabled")
+		}
+		if len(obj.Dependencies) > 0 {
+			fmt.Fprintf(&b, " deps: %v", strings.Join(obj.Dependencies, ", "))
+		}
+		if obj.Error != "" {
+			fmt.Fprintf(&b, " error: %v", obj.Error)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package objstore
+
+// Switchable is an optional interface of shared objects, which can be disabled without tearing them down,
+// e.g. shdep.SharedObjectBase, which mutes its update handler. Disabled objects keep their state and dependencies,
+// so objects depending on them or sharing their dependencies are not affected.
+type Switchable interface {
+	SetEnabled(enabled bool)
+}
+
+// SetEnabled enables or disables the object by calling its method SetEnabled. Object must implement Switchable.
+// Intended for operators to temporarily mute misbehaving object, while keeping its dependencies alive for others.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) SetEnabled(objID ObjID, enabled bool) {
+	obj, ok := s.objects[objID]
+	if !ok {
+		if s.parent != nil {
+			s.parent.SetEnabled(objID, enabled)
+			return
+		}
+
+		s.fail("Object with ID %v is not registered", objID)
+		return
+	}
+
+	switchable, ok := any(obj).(Switchable)
+	if !ok {
+		s.fail("Object with ID %v of type %T cannot be disabled, because it does not have method SetEnabled", objID, obj)
+		return
+	}
+
+	switchable.SetEnabled(enabled)
+
+	if enabled {
+		delete(s.disabled, objID)
+		return
+	}
+	if s.disabled == nil {
+		s.disabled = make(map[ObjID]struct{})
+	}
+	s.disabled[objID] = struct{}{}
+}
+
+// IsEnabled reports whether the object has not been disabled by SetEnabled.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) IsEnabled(objID ObjID) bool {
+	if _, ok := s.objects[objID]; !ok && s.parent != nil {
+		return s.parent.IsEnabled(objID)
+	}
+
+	_, disabled := s.disabled[objID]
+	return !disabled
+}
+
+// SetEnabled is same as GenericStore.SetEnabled.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) SetEnabled(objID ObjID, enabled bool) {
+	s.shards[s.ShardOf(objID)].SetEnabled(objID, enabled)
+}
+
+// IsEnabled is same as GenericStore.IsEnabled.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) IsEnabled(objID ObjID) bool {
+	return s.shards[s.ShardOf(objID)].IsEnabled(objID)
+}
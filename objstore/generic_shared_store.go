@@ -1,13 +1,16 @@
 package objstore
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"runtime/debug"
 	"slices"
-	"sort"
+	"sync"
+	"time"
 
 	"github.com/nnikolash/go-shdep/utils"
 	"github.com/pkg/errors"
-	"golang.org/x/exp/maps"
 )
 
 type ObjRequirementsFunc[SharedObject any, ObjID comparable, InitParams any] func(obj SharedObject, s *GenericStore[SharedObject, ObjID, InitParams])
@@ -28,21 +31,41 @@ func NewGenericStore[SharedObject any, ObjID comparable, InitParams any](
 	stopObj ObjStopFunc[SharedObject], // optional
 	closeObj ObjCloseFunc[SharedObject], // optional
 	l utils.Logger, // optional
+	opts ...StoreOption,
 ) *GenericStore[SharedObject, ObjID, InitParams] {
 	if l == nil {
 		l = &utils.NoopLogger{}
 	}
 
+	var config storeConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	return &GenericStore[SharedObject, ObjID, InitParams]{
-		getID:              getID,
-		idLess:             idLess,
-		gatherRequirements: gatherRequirements,
-		initObj:            initObj,
-		startObj:           startObj,
-		stopObj:            stopObj,
-		closeObj:           closeObj,
-		objects:            make(map[ObjID]SharedObject),
-		l:                  l,
+		getID:               getID,
+		idLess:              idLess,
+		gatherRequirements:  gatherRequirements,
+		initObj:             initObj,
+		startObj:            startObj,
+		stopObj:             stopObj,
+		closeObj:            closeObj,
+		objects:             make(map[ObjID]SharedObject),
+		states:              make(map[ObjID]ObjectState),
+		failures:            make(map[ObjID]*ObjectFailure),
+		initDurations:       make(map[ObjID]time.Duration),
+		l:                   l,
+		observer:            config.observer,
+		registrationStacks:  config.registrationStacks,
+		skipHandlers:        config.skipHandlers,
+		replacementHandlers: config.replacementHandlers,
+		initValidators:      config.initValidators,
+		gatherHandlers:      config.gatherHandlers,
+		errorPolicy:         config.errorPolicy,
+		parallelWorkers:     config.parallelWorkers,
+		timeouts:            config.timeouts,
+		timeoutHandlers:     config.timeoutHandlers,
+		skipped:             make(map[ObjID]LifecyclePhase),
 	}
 }
 
@@ -55,77 +78,274 @@ type GenericStore[SharedObject any, ObjID comparable, InitParams any] struct {
 	stopObj                         ObjStopFunc[SharedObject]
 	closeObj                        ObjCloseFunc[SharedObject]
 	objects                         map[ObjID]SharedObject
+	states                          map[ObjID]ObjectState
+	failures                        map[ObjID]*ObjectFailure
+	initDurations                   map[ObjID]time.Duration
 	objectsRegistrationOrder        []ObjID
 	topLevelDependencies            []ObjID
 	recentlyRegisteredSharedObjects []ObjID
 	dependencies                    []ObjID
+	dependenciesSet                 map[ObjID]struct{}
+	dependenciesGraph               utils.Graph[ObjID]
 	initializationOrder             []ObjID
 	initParams                      InitParams
-	l                               utils.Logger
+	failureHandler                  utils.FailureHandler
+	registeredTypes                 map[reflect.Type]error
+	autoRegisteredFields            map[reflect.Type][][]int
+	profilerLabels                  bool
+	observer                        Observer
+	phase                           storePhase
+	registrationStacks              bool
+	skipHandlers                    []func(obj interface{})
+	replacementHandlers             []func(obj, replica interface{})
+	initValidators                  []func() error
+	gatherHandlers                  []func(obj interface{})
+	// Phase, in which object has been skipped.
+	skipped map[ObjID]LifecyclePhase
+	// Objects disabled by SetEnabled.
+	disabled    map[ObjID]struct{}
+	errorPolicy utils.ErrorPolicy
+	// Misuse errors recorded under lenient error policy.
+	misuses []error
+	// Stack traces of first registrations of objects. Recorded only if registrationStacks is set.
+	registeredAt map[ObjID][]byte
+	// Store owning objects shared between multiple stores. Its objects are used instead of registering them again.
+	parent *GenericStore[SharedObject, ObjID, InitParams]
+	// Number of goroutines initializing and starting objects, or zero, if they are initialized and started sequentially.
+	parallelWorkers int
+	// Limits of duration of lifecycle methods of each object.
+	timeouts        map[LifecyclePhase]time.Duration
+	timeoutHandlers []func(phase LifecyclePhase, id string, obj interface{})
+	// Protects bookkeeping of callObj, when objects are called concurrently.
+	callLock sync.Mutex
+	// Context of running lifecycle method of the store, or nil.
+	ctx context.Context
+	// Whether misuse is returned by RegisterE instead of being reported, and the first of misuses.
+	capturingFailure bool
+	capturedFailure  error
+	// Number of registrations of top-level objects, which have not been undone by Unregister.
+	topLevelRefs map[ObjID]int
+	// Objects registered instead of objects with same IDs, set by Replace.
+	replacements map[ObjID]SharedObject
+	// Whether started store has been paused by Pause.
+	paused bool
+	// Functions creating top-level objects again on Reset, and IDs of objects created by them.
+	constructors []func() SharedObject
+	constructed  map[ObjID]struct{}
+	// Objects closed by rollback of failed RegisterLive.
+	rolledBack map[ObjID]struct{}
+	// Object, requirements of which are being gathered, if gathering is set.
+	gatheringObj SharedObject
+	gathering    bool
+	l            utils.Logger
+	// Loggers prefixing messages with type and ID of objects, created on first message about the object.
+	// Objects can be logged about concurrently by parallel workers, so they are protected by the lock.
+	objLoggersLock sync.Mutex
+	objLoggers     map[ObjID]objLogger
+}
+
+type objLogger struct {
+	objType reflect.Type
+	l       utils.Logger
 }
 
 // Register object to be shared with other users.
 // Expects pointer to pointer.
+// Function Register of this package does the same without using reflection.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Register(obj interface{}) {
 	objV := reflect.ValueOf(obj)
 	objT := objV.Type()
 
-	if objT.Kind() != reflect.Ptr || objT.Elem().Kind() != reflect.Ptr {
-		s.l.Panicf("Register method accepts only pointers to pointers, got %T", obj)
-		// TODO: maybe pointers to interfaces also makes sense?
+	if err := s.checkRegisteredType(objT); err != nil {
+		s.fail("%v", err)
+		return
 	}
 
 	if objV.IsNil() {
-		s.l.Panicf("Pointer to object pointer must not be nil")
+		s.fail("Pointer to object pointer must not be nil")
+		return
 	}
 	if objV.Elem().IsNil() {
-		s.l.Panicf("Pointer to object must not be nil. Construct a desired object before registering it.")
+		s.fail("Pointer to object must not be nil. Construct a desired object before registering it.")
+		return
 	}
 
-	var objAsSharedType SharedObject = objV.Elem().Interface().(SharedObject)
-	objID := s.getID(objAsSharedType)
+	objAsSharedType := objV.Elem().Interface().(SharedObject)
 
-	if !slices.Contains(s.dependencies, objID) {
-		s.dependencies = append(s.dependencies, objID)
+	replica, ok := s.RegisterObject(objAsSharedType, func(registered SharedObject) bool {
+		return reflect.TypeOf(registered).AssignableTo(objT.Elem())
+	})
+	if !ok {
+		return
 	}
 
-	s.recentlyRegisteredSharedObjects = append(s.recentlyRegisteredSharedObjects, objID)
+	if any(replica) != any(objAsSharedType) {
+		objV.Elem().Set(reflect.ValueOf(replica))
+	}
+}
 
-	if existing, ok := s.objects[objID]; ok {
-		existingT := reflect.TypeOf(existing)
-		if !existingT.AssignableTo(objT.Elem()) {
-			s.l.Panicf("Object with id %v of type %v is already registered and has different type: %v", objID, objT.Elem(), existingT)
+// dependenciesSetThreshold is the number of dependencies, after which they are deduplicated using set.
+// Short lists are faster to check linearly.
+const dependenciesSetThreshold = 16
+
+// RegisterE is same as Register, but returns error instead of reporting failure, e.g. if object has invalid type
+// or another object with same ID and different type is already registered. Failed registration has no effect.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterE(obj interface{}) error {
+	prevCapturing, prevCaptured := s.capturingFailure, s.capturedFailure
+	s.capturingFailure, s.capturedFailure = true, nil
+	defer func() { s.capturingFailure, s.capturedFailure = prevCapturing, prevCaptured }()
+
+	s.Register(obj)
+
+	return s.capturedFailure
+}
+
+// addDependency adds object into the list of dependencies of the currently processed object, if it is not there yet.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) addDependency(objID ObjID) {
+	if s.dependenciesSet != nil {
+		if _, ok := s.dependenciesSet[objID]; ok {
+			return
 		}
-		objV.Elem().Set(reflect.ValueOf(existing))
+		s.dependenciesSet[objID] = struct{}{}
+	} else if slices.Contains(s.dependencies, objID) {
 		return
 	}
-	s.l.Debugf("Registering shared object %T/%v", obj, objID)
-	s.objects[objID] = objAsSharedType
+
+	s.dependencies = append(s.dependencies, objID)
+
+	if s.dependenciesSet == nil && len(s.dependencies) > dependenciesSetThreshold {
+		s.dependenciesSet = make(map[ObjID]struct{}, len(s.dependencies)*2)
+		for _, depID := range s.dependencies {
+			s.dependenciesSet[depID] = struct{}{}
+		}
+	}
+}
+
+// checkRegisteredType checks that values of the type can be passed into Register.
+// Results are cached, because same types are registered over and over again.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) checkRegisteredType(objT reflect.Type) error {
+	if err, checked := s.registeredTypes[objT]; checked {
+		return err
+	}
+
+	var err error
+	if objT.Kind() != reflect.Ptr || objT.Elem().Kind() != reflect.Ptr {
+		err = fmt.Errorf("Register method accepts only pointers to pointers, got %v", objT)
+		// TODO: maybe pointers to interfaces also makes sense?
+	} else if sharedObjT := reflect.TypeOf((*SharedObject)(nil)).Elem(); !objT.Elem().Implements(sharedObjT) {
+		err = fmt.Errorf("Object of type %v does not implement %v", objT.Elem(), sharedObjT)
+	}
+
+	if s.registeredTypes == nil {
+		s.registeredTypes = make(map[reflect.Type]error)
+	}
+	s.registeredTypes[objT] = err
+
+	return err
+}
+
+// RegisterObject is a reflection-free version of Register.
+// It registers object and returns shared replica of it, which must be used instead of the object.
+// Function isSameType must report whether already registered object with same ID can be used as a replica,
+// e.g. whether it has same type. If it is nil, any registered object is accepted.
+// Returns false if registration failed.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterObject(obj SharedObject, isSameType func(registered SharedObject) bool) (replica SharedObject, ok bool) {
+	objID := s.getID(obj)
+
+	existing, alreadyRegistered := s.objects[objID]
+	if !alreadyRegistered && s.parent != nil {
+		if parentObj, ownedByParent := s.parent.objects[objID]; ownedByParent {
+			if isSameType != nil && !isSameType(parentObj) {
+				s.fail("Object with id %v of type %T%v is already registered in parent store and has different type: %T%v",
+					objID, obj, describeParams(obj), parentObj, s.parent.describeRegistration(objID, parentObj))
+				return obj, false
+			}
+
+			// Lifecycle of the object is managed by parent store, so it is not a dependency in this store.
+			s.recentlyRegisteredSharedObjects = append(s.recentlyRegisteredSharedObjects, objID)
+			s.replaced(obj, parentObj)
+			return parentObj, true
+		}
+	}
+
+	if alreadyRegistered && isSameType != nil && !isSameType(existing) {
+		s.fail("Object with id %v of type %T%v is already registered and has different type: %T%v",
+			objID, obj, describeParams(obj), existing, s.describeRegistration(objID, existing))
+		return obj, false
+	}
+
+	replacement := obj
+	if !alreadyRegistered {
+		if replacement, ok = s.replacement(objID, obj, isSameType); !ok {
+			return obj, false
+		}
+	}
+
+	s.addDependency(objID)
+	if !s.gathering {
+		if s.topLevelRefs == nil {
+			s.topLevelRefs = make(map[ObjID]int)
+		}
+		s.topLevelRefs[objID]++
+	}
+
+	s.recentlyRegisteredSharedObjects = append(s.recentlyRegisteredSharedObjects, objID)
+
+	if alreadyRegistered {
+		s.replaced(obj, existing)
+		return existing, true
+	}
+
+	s.logObj(utils.LevelDebug, "Registering shared object", replacement, objID)
+	s.objects[objID] = replacement
+	if s.registrationStacks {
+		if s.registeredAt == nil {
+			s.registeredAt = make(map[ObjID][]byte)
+		}
+		s.registeredAt[objID] = debug.Stack()
+	}
 	s.objectsRegistrationOrder = append(s.objectsRegistrationOrder, objID)
+
+	return replacement, true
 }
 
 // Init must be called first of all lifecycle methods.
 // It is intended for gathering objects requirements and then setting their initial state.
 // After Init has finished, object must be able to receive calls from other objects.
+// Returns ErrAlreadyInitialized if Init was already called, even if it has failed.
+// If Init fails, Close can be called to close objects, which have been initialized.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Init(initParams InitParams) error {
-	if len(s.topLevelDependencies) != 0 {
-		return errors.New("shared objects store is already initialized")
+	return s.InitContext(context.Background(), initParams)
+}
+
+// InitContext is same as Init, but ctx is passed into objects implementing ContextInitializer, and if ctx is done,
+// initialization stops before the next object with error of ctx.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) InitContext(ctx context.Context, initParams InitParams) error {
+	if err := s.checkPhase(storeCreated); err != nil {
+		return err
 	}
 
+	s.ctx = ctx
+	defer func() { s.ctx = nil }()
+
+	s.phase = storeInitFailed
 	s.topLevelDependencies = s.dependencies
-	dependenciesGraph := make(map[ObjID][]ObjID, len(s.topLevelDependencies))
+	dependenciesGraph := make(utils.Graph[ObjID], len(s.topLevelDependencies))
 	s.collectDependencies(dependenciesGraph)
 
-	utils.Assert(len(dependenciesGraph) == len(s.objects), "failed to collect all shared objects dependencies")
+	if len(s.misuses) > 0 {
+		return errors.Wrapf(s.misuses[0], "shared objects store has been misused")
+	}
+
+	if len(dependenciesGraph) != len(s.objects) {
+		s.fail("failed to collect all shared objects dependencies")
+		return errors.New("failed to collect all shared objects dependencies")
+	}
 
-	s.l.Debugf("Dependecies graph: %v", dependenciesGraph)
+	utils.LogKV(s.l, utils.LevelDebug, "Collected dependencies graph", "graph", dependenciesGraph)
 	stability := s.objectsRegistrationOrder
 	if s.idLess != nil {
-		stability = maps.Keys(s.objects)
-
-		sort.Slice(stability, func(i, j int) bool {
-			return s.idLess(stability[i], stability[j])
-		})
+		stability = utils.SortedKeysFunc(s.objects, utils.CompareByLess(s.idLess))
 	}
 
 	initializationOrder, err := utils.StableTopologicalSortWithSortedKeys(dependenciesGraph, stability)
@@ -134,35 +354,105 @@ func (s *GenericStore[SharedObject, ObjID, InitParams]) Init(initParams InitPara
 	}
 
 	slices.Reverse(initializationOrder)
-	s.l.Debugf("Shared objects initialization order: %v", initializationOrder)
+	utils.LogKV(s.l, utils.LevelDebug, "Determined shared objects initialization order", "order", initializationOrder)
 
-	if s.initObj != nil {
+	// Order is kept even if initialization fails, so that initialized objects can be closed.
+	s.dependenciesGraph = dependenciesGraph
+	s.initializationOrder = initializationOrder
+	s.initParams = initParams
+
+	if s.parallelWorkers > 0 {
+		if err := s.runParallel(initializationOrder, "initialization", s.runInit, s.finishInit); err != nil {
+			return err
+		}
+	} else {
 		for _, objID := range initializationOrder {
-			object := s.objects[objID]
-			s.l.Debugf("Initializing object %T/%v", object, objID)
-			if err := s.initObj(object, initParams); err != nil {
+			if err := s.initObject(objID); err != nil {
 				return err
 			}
 		}
 	}
 
-	s.initializationOrder = initializationOrder
-	s.initParams = initParams
+	for _, validate := range s.initValidators {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+
+	s.phase = storeInitialized
 
 	return nil
 }
 
-func (s *GenericStore[SharedObject, ObjID, InitParams]) collectDependencies(dependenciesGraph map[ObjID][]ObjID) {
+// initObject initializes the object using init params of the store. Skipped object is not an error.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) initObject(objID ObjID) error {
+	ctx := s.lifecycleContext()
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "initialization is cancelled before object %v", objID)
+	}
+
+	return s.finishInit(objID, s.runInit(ctx, objID))
+}
+
+// runInit calls initialization of the object without changing state of the store, so that it can be called
+// for multiple objects concurrently. Result must be passed into finishInit.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) runInit(ctx context.Context, objID ObjID) error {
+	if s.initObj == nil {
+		return nil
+	}
+
+	ctx, cancel := s.phaseContext(ctx, PhaseInit)
+	defer cancel()
+
+	object := s.objects[objID]
+	s.logObj(utils.LevelDebug, "Initializing object", object, objID)
+	return s.callObj(PhaseInit, object, objID, func() error {
+		if err := s.configureObj(object, objID, s.initParams); err != nil {
+			return err
+		}
+		if initializer, ok := any(object).(ContextInitializer[InitParams]); ok {
+			return initializer.InitContext(ctx, s.initParams)
+		}
+		return s.initObj(object, s.initParams)
+	})
+}
+
+// finishInit records result of initialization of the object.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) finishInit(objID ObjID, err error) error {
+	if errors.Is(err, ErrSkip) {
+		s.setSkipped(objID, PhaseInit, err)
+		return nil
+	}
+	if err != nil {
+		s.setFailed(objID, PhaseInit, err)
+		return s.wrapObjectError(err, PhaseInit, s.objects[objID], objID)
+	}
+
+	s.states[objID] = ObjectInitialized
+	return nil
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) collectDependencies(dependenciesGraph utils.Graph[ObjID]) {
 	dependencies := s.dependencies
 	s.dependencies = make([]ObjID, 0, len(s.dependencies))
+	s.dependenciesSet = nil
 
 	for _, objID := range dependencies {
 		if _, processed := dependenciesGraph[objID]; processed {
 			continue
 		}
 		obj := s.objects[objID]
-		s.l.Debugf("Gathering requirements for object %T/%v", obj, objID)
-		s.gatherRequirements(obj, s)
+		s.logObj(utils.LevelDebug, "Gathering requirements for object", obj, objID)
+		_ = s.callObj(PhaseGatherRequirements, obj, objID, func() error {
+			for _, h := range s.gatherHandlers {
+				h(obj)
+			}
+			prevObj, prevGathering := s.gatheringObj, s.gathering
+			s.gatheringObj, s.gathering = obj, true
+			defer func() { s.gatheringObj, s.gathering = prevObj, prevGathering }()
+			s.gatherRequirements(obj, s)
+			return nil
+		})
 
 		dependenciesGraph[objID] = s.dependencies
 		s.collectDependencies(dependenciesGraph)
@@ -172,19 +462,34 @@ func (s *GenericStore[SharedObject, ObjID, InitParams]) collectDependencies(depe
 // Start must be called after Init. It is used as PostInit hook.
 // It is intended for starting background processes, timers, etc.
 // The onlt thing it does is calls Start() on all objects in the store.
+// If Start fails, store still must be stopped and closed, because some of objects could be started.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Start() error {
+	return s.StartContext(context.Background())
+}
+
+// StartContext is same as Start, but ctx is passed into objects implementing ContextStarter, and if ctx is done,
+// starting stops before the next object with error of ctx.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) StartContext(ctx context.Context) error {
+	if err := s.checkPhase(storeInitialized); err != nil {
+		return err
+	}
+
+	s.ctx = ctx
+	defer func() { s.ctx = nil }()
+
+	s.phase = storeStarted
+
 	if s.startObj == nil {
+		s.setStateOfAll(ObjectStarted)
 		return nil
 	}
 
-	if len(s.initializationOrder) == 0 && len(s.objects) != 0 {
-		return errors.New("shared objects store was not initialized")
+	if s.parallelWorkers > 0 {
+		return s.runParallel(s.initializationOrder, "start", s.runStart, s.finishStart)
 	}
 
 	for _, objID := range s.initializationOrder {
-		object := s.objects[objID]
-		s.l.Debugf("Starting object %T/%v", object, objID)
-		if err := s.startObj(object, s.initParams); err != nil {
+		if err := s.startObject(objID); err != nil {
 			return err
 		}
 	}
@@ -192,43 +497,274 @@ func (s *GenericStore[SharedObject, ObjID, InitParams]) Start() error {
 	return nil
 }
 
+// startObject starts the object, unless it has been skipped. Skipping the object in Start is not an error.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) startObject(objID ObjID) error {
+	if s.states[objID] == ObjectSkipped {
+		return nil
+	}
+
+	ctx := s.lifecycleContext()
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "start is cancelled before object %v", objID)
+	}
+
+	return s.finishStart(objID, s.runStart(ctx, objID))
+}
+
+// runStart calls start of the object, unless it has been skipped, without changing state of the store,
+// so that it can be called for multiple objects concurrently. Result must be passed into finishStart.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) runStart(ctx context.Context, objID ObjID) error {
+	if s.startObj == nil || s.states[objID] == ObjectSkipped {
+		return nil
+	}
+
+	ctx, cancel := s.phaseContext(ctx, PhaseStart)
+	defer cancel()
+
+	object := s.objects[objID]
+	s.logObj(utils.LevelDebug, "Starting object", object, objID)
+	return s.callObj(PhaseStart, object, objID, func() error {
+		if starter, ok := any(object).(ContextStarter[InitParams]); ok {
+			return starter.StartContext(ctx, s.initParams)
+		}
+		return s.startObj(object, s.initParams)
+	})
+}
+
+// finishStart records result of start of the object.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) finishStart(objID ObjID, err error) error {
+	if s.states[objID] == ObjectSkipped {
+		return nil
+	}
+	if errors.Is(err, ErrSkip) {
+		s.setSkipped(objID, PhaseStart, err)
+		return nil
+	}
+	if err != nil {
+		s.setFailed(objID, PhaseStart, err)
+		return s.wrapObjectError(err, PhaseStart, s.objects[objID], objID)
+	}
+
+	s.states[objID] = ObjectStarted
+	return nil
+}
+
 // Stop must be called after Start. It is used as PreClose hook.
 // It is intended for stopping background processes, timers, etc.
-// The only thing it does is calls Stop() on all objects in the store.
+// The only thing it does is calls Stop() on all objects in the store, which have been started.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Stop() {
-	if s.stopObj == nil {
-		return
+	if err := s.StopE(); err != nil {
+		s.fail("%v", err)
+	}
+}
+
+// StopE is same as Stop, but returns error, e.g. ErrNotStarted, instead of reporting misuse.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) StopE() error {
+	return s.StopContext(context.Background())
+}
+
+// StopContext is same as Stop, but ctx is passed into objects implementing ContextStopper, e.g. as deadline
+// of graceful shutdown. All started objects are stopped even if ctx is done.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) StopContext(ctx context.Context) error {
+	if s.alreadyPassed(storeStopped, "Stop") {
+		return nil
+	}
+
+	if err := s.checkPhase(storeStarted); err != nil {
+		return err
 	}
 
+	s.ctx = ctx
+	defer func() { s.ctx = nil }()
+
+	s.phase = storeStopped
+	s.paused = false
+
 	for i := len(s.initializationOrder) - 1; i >= 0; i-- {
 		objID := s.initializationOrder[i]
-		object := s.objects[objID]
+		if s.states[objID] != ObjectStarted {
+			// Start has failed before reaching the object.
+			continue
+		}
 
-		s.l.Debugf("Stopping object %T/%v", object, objID)
-		s.stopObj(object)
+		if s.stopObj != nil {
+			object := s.objects[objID]
+			s.logObj(utils.LevelDebug, "Stopping object", object, objID)
+			_ = s.callObj(PhaseStop, object, objID, func() error {
+				if stopper, ok := any(object).(ContextStopper); ok {
+					ctx, cancel := s.phaseContext(s.lifecycleContext(), PhaseStop)
+					defer cancel()
+					stopper.StopContext(ctx)
+				} else {
+					s.stopObj(object)
+				}
+				return nil
+			})
+		}
+
+		s.states[objID] = ObjectStopped
 	}
+
+	return nil
 }
 
 // Close must be called after Stop. It is used to finalize objects.
 // Can be used to free resources and ensure they are not used anywhere else.
-// The only thing it does is calls Close() on all objects in the store.
+// The only thing it does is calls Close() on all objects in the store, which have been initialized.
+// It can also be called right after failed Init.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Close() {
-	if s.closeObj == nil {
-		return
+	if err := s.CloseE(); err != nil {
+		s.fail("%v", err)
 	}
+}
+
+// CloseE is same as Close, but returns error, e.g. ErrNotStopped, instead of reporting misuse.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) CloseE() error {
+	if s.alreadyPassed(storeClosed, "Close") {
+		return nil
+	}
+
+	if s.phase != storeInitFailed {
+		if err := s.checkPhase(storeStopped); err != nil {
+			return err
+		}
+	}
+
+	s.phase = storeClosed
 
 	for i := len(s.initializationOrder) - 1; i >= 0; i-- {
 		objID := s.initializationOrder[i]
-		object := s.objects[objID]
+		if !s.isInitialized(objID) {
+			continue
+		}
+
+		if s.closeObj != nil {
+			object := s.objects[objID]
+			s.logObj(utils.LevelDebug, "Closing object", object, objID)
+			_ = s.callObj(PhaseClose, object, objID, func() error {
+				s.closeObj(object)
+				return nil
+			})
+		}
+
+		s.setState(objID, ObjectClosed)
+	}
+
+	return nil
+}
 
-		s.l.Debugf("Closing object %T/%v", object, objID)
-		s.closeObj(object)
+// Abort stops and closes objects of the store in whatever phase it is, e.g. after failed Init or Start, so that
+// background processes of objects, which have been initialized, don't keep running. Objects are stopped and closed
+// in reverse initialization order, as by Stop and Close. Store can't be used after that. Repeated calls are ignored.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Abort() error {
+	switch s.phase {
+	case storeClosed:
+		return nil
+	case storeCreated:
+		s.phase = storeClosed
+		return nil
+	case storeInitialized:
+		// Nothing has been started yet.
+		s.phase = storeStopped
+	case storeStarted:
+		if err := s.StopE(); err != nil {
+			return err
+		}
 	}
+
+	return s.CloseE()
+}
+
+// isInitialized returns true if Init of the object has succeeded, even if it has failed on later phases,
+// unless the object has been closed by rollback of RegisterLive.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) isInitialized(objID ObjID) bool {
+	if _, closed := s.rolledBack[objID]; closed {
+		return false
+	}
+
+	switch s.states[objID] {
+	case ObjectInitialized, ObjectStarted, ObjectStopped:
+		return true
+	case ObjectFailed:
+		return s.failures[objID].Phase != PhaseInit
+	case ObjectSkipped:
+		return s.skipped[objID] != PhaseInit
+	default:
+		return false
+	}
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) setStateOfAll(state ObjectState) {
+	for _, objID := range s.initializationOrder {
+		s.setState(objID, state)
+	}
+}
+
+// setState sets state of the object, unless it has failed or has been skipped, so that it stays visible after shutdown.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) setState(objID ObjID, state ObjectState) {
+	if current := s.states[objID]; current != ObjectFailed && current != ObjectSkipped {
+		s.states[objID] = state
+	}
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) setSkipped(objID ObjID, phase LifecyclePhase, err error) {
+	object := s.objects[objID]
+	s.logObj(utils.LevelInfo, fmt.Sprintf("Object is skipped on %v: %v", phase, err), object, objID)
+
+	s.states[objID] = ObjectSkipped
+	s.skipped[objID] = phase
+
+	for _, h := range s.skipHandlers {
+		h(object)
+	}
+}
+
+// replaced calls replacement handlers, if registered object is replaced by different replica.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) replaced(obj, replica SharedObject) {
+	if any(obj) == any(replica) {
+		return
+	}
+
+	for _, h := range s.replacementHandlers {
+		h(obj, replica)
+	}
+}
+
+// wrapObjectError wraps error returned by lifecycle method of the object with the phase and identity of the object,
+// e.g. "start failed for MA (a1b2c3d4): ...". Objects providing Name and Hash, like objects based on shdep.SharedObjectBase,
+// are identified by them, other objects by their ID.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) wrapObjectError(err error, phase LifecyclePhase, obj SharedObject, objID ObjID) error {
+	identity := fmt.Sprint(objID)
+	if named, ok := any(obj).(interface {
+		Name() string
+		Hash() string
+	}); ok {
+		hash := named.Hash()
+		if len(hash) > shortHashLen {
+			hash = hash[:shortHashLen]
+		}
+		identity = fmt.Sprintf("%v (%v)", named.Name(), hash)
+	}
+
+	return errors.Wrapf(err, "%v failed for %v", phase, identity)
+}
+
+// shortHashLen is the number of characters of object hash used to identify the object in errors.
+const shortHashLen = 8
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) setFailed(objID ObjID, phase LifecyclePhase, err error) {
+	s.states[objID] = ObjectFailed
+	s.failures[objID] = &ObjectFailure{ID: fmt.Sprint(objID), Phase: phase, Err: err}
 }
 
 // Returns object by its ID.
+// Objects of parent store are returned too.
 func (s *GenericStore[SharedObject, ObjID, InitParams]) Get(objID ObjID) SharedObject {
-	return s.objects[objID]
+	if obj, ok := s.objects[objID]; ok || s.parent == nil {
+		return obj
+	}
+
+	return s.parent.Get(objID)
 }
 
 // Returns all objects, which were registered in the store before Init() was called.
@@ -246,3 +782,154 @@ func (s *GenericStore[SharedObject, ObjID, InitParams]) RecentlyRegisteredShared
 	s.recentlyRegisteredSharedObjects = nil
 	return ind
 }
+
+// DOT renders dependencies graph of the objects in Graphviz DOT format.
+// Edges are directed from the object to its dependencies. Graph is available only after Init.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) DOT() string {
+	return utils.GraphToDOT(s.dependenciesGraph, func(objID ObjID) string {
+		return fmt.Sprint(objID)
+	})
+}
+
+// SetFailureHandler sets handler for misuse of the store, e.g. registration of invalid objects.
+// By default package-level handler from utils is used, which panics.
+// If handler returns, failed operation is skipped. Handler is not used under lenient error policy.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) SetFailureHandler(h utils.FailureHandler) {
+	s.failureHandler = h
+}
+
+// SetProfilerLabels enables pprof labels "shdep.object" and "shdep.phase" around lifecycle calls of objects,
+// so that CPU profiles attribute time spent in them to specific objects. Disabled by default.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) SetProfilerLabels(enabled bool) {
+	s.profilerLabels = enabled
+}
+
+// callObj calls lifecycle function of the object, attaching profiler labels if they are enabled,
+// and reports its duration.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) callObj(phase LifecyclePhase, obj SharedObject, objID ObjID, f func() error) error {
+	var err error
+	start := time.Now()
+	stopWatching := s.watchTimeout(phase, obj, objID, start)
+
+	if s.profilerLabels {
+		utils.DoWithProfilerLabels(context.Background(), func(context.Context) {
+			err = f()
+		}, "shdep.object", fmt.Sprintf("%T/%v", obj, objID), "shdep.phase", string(phase))
+	} else {
+		err = f()
+	}
+
+	duration := time.Since(start)
+	stopWatching()
+	err = s.checkTimeout(phase, duration, err)
+
+	// Objects may be called concurrently in parallel lifecycle.
+	s.callLock.Lock()
+	defer s.callLock.Unlock()
+
+	if phase == PhaseInit {
+		s.initDurations[objID] = duration
+	}
+
+	if s.observer != nil {
+		s.observer.LifecyclePhaseFinished(phase, obj, duration, err)
+	}
+
+	return err
+}
+
+// describeParams returns description of parameters of the object for error messages, if object provides it.
+func describeParams(obj interface{}) string {
+	if d, ok := obj.(ParamsDescriber); ok {
+		return fmt.Sprintf(" (params: %v)", d.DescribeParams())
+	}
+
+	return ""
+}
+
+// describeRegistration returns description of parameters and of the place of first registration
+// of the registered object for error messages.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) describeRegistration(objID ObjID, registered SharedObject) string {
+	desc := describeParams(registered)
+	if stack, ok := s.registeredAt[objID]; ok {
+		desc += fmt.Sprintf("\nFirst registered at:\n%s", stack)
+	}
+
+	return desc
+}
+
+// Misuses returns misuse errors recorded under lenient error policy.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Misuses() []error {
+	return s.misuses
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) fail(format string, args ...interface{}) {
+	s.reportFailure(fmt.Errorf(format, args...))
+}
+
+// reportFailure reports misuse of the store according to its error policy.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) reportFailure(err error) {
+	if s.capturingFailure {
+		if s.capturedFailure == nil {
+			s.capturedFailure = err
+		}
+		return
+	}
+
+	s.l.Errorf("%v", err)
+
+	if s.errorPolicy == utils.PolicyLenient {
+		s.misuses = append(s.misuses, err)
+		return
+	}
+
+	if s.failureHandler != nil {
+		s.failureHandler(err)
+		return
+	}
+
+	utils.Fail(err)
+}
+
+// logObj writes message about the object. If logger supports structured logging, type and ID of the object
+// are written as fields. Otherwise they are written as the message prefix.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) logObj(level utils.LogLevel, msg string, obj SharedObject, objID ObjID) {
+	if !utils.LogEnabled(s.l, level) {
+		return
+	}
+
+	if _, ok := s.l.(utils.KVLogger); ok {
+		utils.LogKV(s.l, level, msg, "objectType", fmt.Sprintf("%T", obj), "objectID", objID)
+		return
+	}
+
+	utils.LogKV(s.objLogger(obj, objID), level, msg)
+}
+
+// objLogger returns logger, which prefixes all messages with type and ID of the object.
+// Logger is created once for each object and created again only if object with the ID has been replaced by object of other type.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) objLogger(obj SharedObject, objID ObjID) utils.Logger {
+	s.objLoggersLock.Lock()
+	defer s.objLoggersLock.Unlock()
+
+	objType := reflect.TypeOf(obj)
+	if cached, ok := s.objLoggers[objID]; ok && cached.objType == objType {
+		return cached.l
+	}
+
+	if s.objLoggers == nil {
+		s.objLoggers = make(map[ObjID]objLogger)
+	}
+	l := utils.WithPrefix(s.l, fmt.Sprintf("%T/%v: ", obj, objID))
+	s.objLoggers[objID] = objLogger{objType: objType, l: l}
+
+	return l
+}
+
+// forgetObjLogger drops logger of the object, which is no longer in the store.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) forgetObjLogger(objID ObjID) {
+	s.objLoggersLock.Lock()
+	defer s.objLoggersLock.Unlock()
+
+	delete(s.objLoggers, objID)
+}
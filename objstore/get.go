@@ -0,0 +1,84 @@
+package objstore
+
+import "reflect"
+
+// ObjectGetter is implemented by stores, which return objects by their IDs.
+type ObjectGetter[ObjID comparable, SharedObject any] interface {
+	Get(objID ObjID) SharedObject
+}
+
+// GetAs returns object with the ID as T, e.g. as pointer to the object of concrete type, so that callers don't need
+// type assertions. Returns false, if there is no such object or it is not T.
+func GetAs[T any, ObjID comparable, SharedObject any](s ObjectGetter[ObjID, SharedObject], objID ObjID) (T, bool) {
+	obj, ok := any(s.Get(objID)).(T)
+	return obj, ok
+}
+
+// GetByExample finds registered object with same ID as the object, to which ptr points, e.g. constructed with same
+// parameters, and rewrites the pointer with it, as Register does. Expects pointer to pointer. Nothing is registered:
+// if there is no such object or it has another type, false is returned and the pointer is kept.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) GetByExample(ptr interface{}) bool {
+	objV := reflect.ValueOf(ptr)
+	if err := s.checkRegisteredType(objV.Type()); err != nil {
+		s.fail("%v", err)
+		return false
+	}
+	if objV.IsNil() || objV.Elem().IsNil() {
+		s.fail("Pointer to object and the object must not be nil")
+		return false
+	}
+
+	example := objV.Elem().Interface().(SharedObject)
+	found := reflect.ValueOf(s.Get(s.getID(example)))
+	if !found.IsValid() || found.IsZero() || !found.Type().AssignableTo(objV.Type().Elem()) {
+		return false
+	}
+
+	objV.Elem().Set(found)
+	return true
+}
+
+// GetByExample is same as GenericStore.GetByExample. Object is looked up in the shard, to which it belongs,
+// and in parent store.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) GetByExample(ptr interface{}) bool {
+	return s.shardFor(ptr).GetByExample(ptr)
+}
+
+// ObjectIterator is implemented by stores, which can iterate over their objects.
+type ObjectIterator[ObjID comparable, SharedObject any] interface {
+	ForEach(fn func(objID ObjID, obj SharedObject))
+}
+
+// ForEach calls fn for each object of the store in initialization order, or in registration order if store is not
+// initialized yet. Objects of parent store are not included. Fn must not register or unregister objects.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) ForEach(fn func(objID ObjID, obj SharedObject)) {
+	order := s.initializationOrder
+	if len(order) == 0 {
+		order = s.objectsRegistrationOrder
+	}
+
+	for _, objID := range order {
+		fn(objID, s.objects[objID])
+	}
+}
+
+// ForEach calls fn for each object of parent store and then of each shard. See GenericStore.ForEach.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ForEach(fn func(objID ObjID, obj SharedObject)) {
+	s.parent.ForEach(fn)
+	for _, shard := range s.shards {
+		shard.ForEach(fn)
+	}
+}
+
+// AllOfType returns all objects of the store, which are T, e.g. all price providers to dump their state,
+// in order of ForEach.
+func AllOfType[T any, ObjID comparable, SharedObject any](s ObjectIterator[ObjID, SharedObject]) []T {
+	var result []T
+	s.ForEach(func(_ ObjID, obj SharedObject) {
+		if t, ok := any(obj).(T); ok {
+			result = append(result, t)
+		}
+	})
+
+	return result
+}
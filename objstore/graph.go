@@ -0,0 +1,97 @@
+package objstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ObjectGraph is a structured dependencies graph of objects of the store, e.g. for dashboards and debug endpoints.
+// It can be encoded as JSON or rendered in Mermaid format using ObjectGraph.Mermaid.
+type ObjectGraph struct {
+	// Objects in initialization order, or in registration order if store is not initialized yet.
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode describes object of the store. IDs are formatted using fmt.Sprint.
+type GraphNode struct {
+	ID    string      `json:"id"`
+	Type  string      `json:"type"`
+	State ObjectState `json:"state"`
+	// Position of the object in registration order.
+	RegistrationOrder int `json:"registrationOrder"`
+	// Position of the object in initialization order, or -1 if store is not initialized yet.
+	InitOrder int `json:"initOrder"`
+}
+
+// GraphEdge is directed from the object to its dependency.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph returns dependencies graph of the objects. Before Init it contains only top-level objects without edges.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Graph() ObjectGraph {
+	registrationOrder := make(map[ObjID]int, len(s.objectsRegistrationOrder))
+	for i, objID := range s.objectsRegistrationOrder {
+		registrationOrder[objID] = i
+	}
+
+	order := s.initializationOrder
+	if len(order) == 0 {
+		order = s.objectsRegistrationOrder
+	}
+
+	graph := ObjectGraph{Nodes: make([]GraphNode, 0, len(order))}
+	for i, objID := range order {
+		initOrder := i
+		if len(s.initializationOrder) == 0 {
+			initOrder = -1
+		}
+
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:                fmt.Sprint(objID),
+			Type:              fmt.Sprintf("%T", s.objects[objID]),
+			State:             s.states[objID],
+			RegistrationOrder: registrationOrder[objID],
+			InitOrder:         initOrder,
+		})
+
+		for _, depID := range s.dependenciesGraph[objID] {
+			graph.Edges = append(graph.Edges, GraphEdge{From: fmt.Sprint(objID), To: fmt.Sprint(depID)})
+		}
+	}
+
+	return graph
+}
+
+// Mermaid renders the graph as Mermaid flowchart. Nodes are labeled with ID and state of the object.
+func (g ObjectGraph) Mermaid() string {
+	ids := make(map[string]int, len(g.Nodes))
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+
+	for i, node := range g.Nodes {
+		ids[node.ID] = i
+		fmt.Fprintf(&b, "  n%d[\"%s<br>%v\"]\n", i, escapeMermaid(node.ID), node.State)
+	}
+
+	for _, edge := range g.Edges {
+		from, fromOk := ids[edge.From]
+		to, toOk := ids[edge.To]
+		if !fromOk || !toOk {
+			// Dependency is owned by another store, e.g. by parent store of the shard.
+			continue
+		}
+		fmt.Fprintf(&b, "  n%d --> n%d\n", from, to)
+	}
+
+	return b.String()
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+func escapeMermaid(s string) string {
+	return mermaidEscaper.Replace(s)
+}
@@ -0,0 +1,89 @@
+package objstore
+
+import (
+	"reflect"
+)
+
+// Group is a set of homogeneous dependencies registered together using RegisterGroup,
+// e.g. one indicator per configured period. Subscriber of the group is subscribed to all its members,
+// so its update handler is called once per propagation, however many members have updated.
+type Group[SharedObject any] struct {
+	name    string
+	ptrs    []interface{}
+	members []SharedObject
+	report  func(err error)
+}
+
+// RegisterGroup registers each of dependencies like Register and returns group of them.
+// Name of the group is used for diagnostics.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterGroup(name string, ptrs ...interface{}) *Group[SharedObject] {
+	return newGroup[SharedObject](name, ptrs, s.Register, s.reportFailure)
+}
+
+// RegisterGroup is same as GenericStore.RegisterGroup. Each member is registered like by Register.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterGroup(name string, ptrs ...interface{}) *Group[SharedObject] {
+	return newGroup[SharedObject](name, ptrs, s.Register, s.reportFailure)
+}
+
+func newGroup[SharedObject any](name string, ptrs []interface{}, register func(ptr interface{}), report func(err error)) *Group[SharedObject] {
+	g := &Group[SharedObject]{
+		name:    name,
+		ptrs:    ptrs,
+		members: make([]SharedObject, 0, len(ptrs)),
+		report:  report,
+	}
+
+	for _, ptr := range ptrs {
+		register(ptr)
+
+		ptrV := reflect.ValueOf(ptr)
+		if ptrV.Kind() != reflect.Pointer || ptrV.IsNil() {
+			// Already reported by Register.
+			continue
+		}
+		if member, ok := ptrV.Elem().Interface().(SharedObject); ok {
+			g.members = append(g.members, member)
+		}
+	}
+
+	return g
+}
+
+// Name returns name of the group.
+func (g *Group[SharedObject]) Name() string {
+	return g.name
+}
+
+// Members returns registered members of the group, i.e. shared replicas of passed objects, in order of registration.
+// Objects with same ID are replaced by the same replica, so it can be present multiple times.
+func (g *Group[SharedObject]) Members() []SharedObject {
+	return g.members
+}
+
+// Len returns number of members of the group.
+func (g *Group[SharedObject]) Len() int {
+	return len(g.members)
+}
+
+// SubscribeObj subscribes subscriber to updates of all members of the group
+// by calling method SubscribeObj of each member, e.g. provided by shdep.SharedObjectBase.
+func (g *Group[SharedObject]) SubscribeObj(subscriber SharedObject) {
+	for _, ptr := range g.ptrs {
+		if _, err := subscribeRegistered(ptr, subscriber, false); err != nil {
+			g.report(err)
+		}
+	}
+}
+
+// Updated returns members of the group, which have been updated in current propagation.
+// Members must have method HasUpdated, e.g. provided by shdep.SharedObjectBase.
+func (g *Group[SharedObject]) Updated() []SharedObject {
+	var updated []SharedObject
+	for _, member := range g.members {
+		if m, ok := any(member).(interface{ HasUpdated() bool }); ok && m.HasUpdated() {
+			updated = append(updated, member)
+		}
+	}
+
+	return updated
+}
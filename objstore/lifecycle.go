@@ -0,0 +1,205 @@
+package objstore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned by lifecycle methods of the store, when they are called in wrong order.
+var (
+	ErrAlreadyInitialized = errors.New("shared objects store is already initialized")
+	ErrNotInitialized     = errors.New("shared objects store was not initialized")
+	ErrAlreadyStarted     = errors.New("shared objects store is already started")
+	ErrNotStarted         = errors.New("shared objects store was not started")
+	ErrAlreadyStopped     = errors.New("shared objects store is already stopped")
+	ErrNotStopped         = errors.New("shared objects store was not stopped")
+	ErrAlreadyClosed      = errors.New("shared objects store is already closed")
+	ErrNotClosed          = errors.New("shared objects store was not closed")
+	ErrAlreadyPaused      = errors.New("shared objects store is already paused")
+	ErrNotPaused          = errors.New("shared objects store was not paused")
+)
+
+// Lifecycle is a part of the store, which is required to run its lifecycle, e.g. by helpers running the store
+// in application or in tests. It is implemented by GenericStore and ShardedStore.
+type Lifecycle[InitParams any] interface {
+	Init(params InitParams) error
+	Start() error
+	StopE() error
+	CloseE() error
+}
+
+var _ Lifecycle[interface{}] = &GenericStore[interface{}, string, interface{}]{}
+var _ Lifecycle[interface{}] = &ShardedStore[interface{}, string, interface{}]{}
+
+// ErrSkip can be returned (possibly wrapped) by Init or Start of the object to disable the object instead of
+// failing the whole store, e.g. if provider is intentionally unavailable in this environment.
+// Skipped object is not started and stopped. It is closed only if it was skipped in Start.
+// Its dependants are still initialized and started, so they must handle absence of the object, e.g. using StateOf.
+var ErrSkip = errors.New("object is skipped")
+
+// storePhase is the last lifecycle method, which was called on the store.
+type storePhase int
+
+const (
+	storeCreated storePhase = iota
+	// Init was called, but has failed.
+	storeInitFailed
+	storeInitialized
+	// Start was called. It could fail after starting some of objects, so they still must be stopped.
+	storeStarted
+	storeStopped
+	storeClosed
+)
+
+func (p storePhase) String() string {
+	switch p {
+	case storeCreated:
+		return "created"
+	case storeInitFailed:
+		return "init failed"
+	case storeInitialized:
+		return "initialized"
+	case storeStarted:
+		return "started"
+	case storeStopped:
+		return "stopped"
+	case storeClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// checkPhase returns error if lifecycle method, which must be called after the required phase, can't be called now.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) checkPhase(required storePhase) error {
+	switch {
+	case s.phase == required:
+		return nil
+	case s.phase == storeClosed:
+		return ErrAlreadyClosed
+	case s.phase == storeStopped && required < storeStopped:
+		return ErrAlreadyStopped
+	case s.phase == storeStarted && required < storeStarted:
+		return ErrAlreadyStarted
+	case s.phase >= storeInitFailed && required < storeInitialized:
+		return ErrAlreadyInitialized
+	case required == storeStarted:
+		return ErrNotStarted
+	case required == storeStopped:
+		return ErrNotStopped
+	default:
+		return ErrNotInitialized
+	}
+}
+
+// alreadyPassed reports whether the store has already reached the phase, so that repeated call of the lifecycle
+// method can be ignored. Cleanup paths, e.g. deferred calls, often call Stop and Close more than once.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) alreadyPassed(phase storePhase, method string) bool {
+	if s.phase < phase {
+		return false
+	}
+
+	s.l.Debugf("%v is called on shared objects store again, so the call is ignored", method)
+	return true
+}
+
+// ObjectFailure describes failure of lifecycle method of the object.
+type ObjectFailure struct {
+	// Store, which owns the object, if the store consists of multiple stores (see ObjectDescription).
+	Store string
+	ID    string
+	Phase LifecyclePhase
+	Err   error
+}
+
+func (f *ObjectFailure) Error() string {
+	return fmt.Sprintf("%v of object %v failed: %v", f.Phase, f.ID, f.Err)
+}
+
+func (f *ObjectFailure) Unwrap() error {
+	return f.Err
+}
+
+// StateSummary describes in which lifecycle states objects of the store are.
+type StateSummary struct {
+	// Number of objects in each state.
+	Counts map[ObjectState]int
+	// Failures of objects in state ObjectFailed in initialization order.
+	Failures []ObjectFailure
+}
+
+// StateOf returns lifecycle state of the object, or ObjectUnknown if there is no such object.
+// Objects of parent store are checked too.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) StateOf(objID ObjID) ObjectState {
+	if _, ok := s.objects[objID]; ok {
+		return s.states[objID]
+	}
+
+	if s.parent != nil {
+		return s.parent.StateOf(objID)
+	}
+
+	return ObjectUnknown
+}
+
+// ErrorOf returns failure of the object, if its state is ObjectFailed. Otherwise returns nil.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) ErrorOf(objID ObjID) error {
+	if failure, ok := s.failures[objID]; ok {
+		return failure
+	}
+
+	if _, ok := s.objects[objID]; !ok && s.parent != nil {
+		return s.parent.ErrorOf(objID)
+	}
+
+	return nil
+}
+
+// StateSummary returns number of objects in each lifecycle state and failures of objects.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) StateSummary() StateSummary {
+	summary := StateSummary{Counts: make(map[ObjectState]int)}
+	s.addToSummary(&summary, "")
+
+	return summary
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) addToSummary(summary *StateSummary, store string) {
+	order := s.initializationOrder
+	if len(order) == 0 {
+		order = s.objectsRegistrationOrder
+	}
+
+	for _, objID := range order {
+		summary.Counts[s.states[objID]]++
+
+		if failure, ok := s.failures[objID]; ok {
+			f := *failure
+			f.Store = store
+			summary.Failures = append(summary.Failures, f)
+		}
+	}
+}
+
+// StateOf returns lifecycle state of the object in the shard, to which top-level object with the ID belongs,
+// or in parent store. Returns ObjectUnknown if there is no such object.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) StateOf(objID ObjID) ObjectState {
+	return s.shards[s.ShardOf(objID)].StateOf(objID)
+}
+
+// ErrorOf is same as StateOf, but returns failure of the object.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ErrorOf(objID ObjID) error {
+	return s.shards[s.ShardOf(objID)].ErrorOf(objID)
+}
+
+// StateSummary returns summary of parent store and all shards. Field Store of failures is set to "parent" or "shard-N".
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) StateSummary() StateSummary {
+	summary := StateSummary{Counts: make(map[ObjectState]int)}
+	s.parent.addToSummary(&summary, "parent")
+
+	for shardIdx, shard := range s.shards {
+		shard.addToSummary(&summary, fmt.Sprintf("shard-%v", shardIdx))
+	}
+
+	return summary
+}
@@ -0,0 +1,178 @@
+package objstore
+
+import (
+	"slices"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+)
+
+// RegisterLive registers top-level objects into the store, which is already initialized or started, e.g. to add
+// strategy to running process. Expects pointers to pointers, as Register. Requirements of new objects are gathered,
+// and then objects, which were not in the store yet, are initialized and, if the store is started, started. Objects,
+// which are already in the store, are shared with new ones as usual and are not affected.
+// If any of new objects fails, new objects, which have been started or initialized, are stopped and closed,
+// skip handlers are called for all of them, e.g. to detach them from update tree, and they stay in the store
+// in their final states. Must not be called while updates are propagated.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterLive(ptrs ...interface{}) error {
+	if s.phase != storeStarted {
+		if err := s.checkPhase(storeInitialized); err != nil {
+			return err
+		}
+	}
+
+	misusesBefore := len(s.misuses)
+	registeredBefore := len(s.objectsRegistrationOrder)
+
+	s.dependencies, s.dependenciesSet = nil, nil
+	for _, ptr := range ptrs {
+		s.Register(ptr)
+	}
+	for _, objID := range s.dependencies {
+		if !slices.Contains(s.topLevelDependencies, objID) {
+			s.topLevelDependencies = append(s.topLevelDependencies, objID)
+		}
+	}
+
+	s.collectDependencies(s.dependenciesGraph)
+
+	added := slices.Clone(s.objectsRegistrationOrder[registeredBefore:])
+	utils.LogKV(s.l, utils.LevelDebug, "Registered objects into live store", "objects", added)
+
+	if len(s.misuses) > misusesBefore {
+		s.rollbackLive(added)
+		return errors.Wrapf(s.misuses[misusesBefore], "shared objects store has been misused")
+	}
+
+	order, err := s.liveInitializationOrder(added)
+	if err != nil {
+		s.rollbackLive(added)
+		return errors.Wrapf(err, "failed to determine objects initialization order")
+	}
+	s.initializationOrder = append(s.initializationOrder, order...)
+
+	for _, objID := range order {
+		if err := s.initObject(objID); err != nil {
+			s.rollbackLive(order)
+			return err
+		}
+	}
+
+	for _, validate := range s.initValidators {
+		if err := validate(); err != nil {
+			s.rollbackLive(order)
+			return err
+		}
+	}
+
+	if s.phase == storeStarted {
+		for _, objID := range order {
+			if err := s.startObject(objID); err != nil {
+				s.rollbackLive(order)
+				return err
+			}
+		}
+
+		if s.paused {
+			s.pauseObjects(order)
+		}
+	}
+
+	return nil
+}
+
+// liveInitializationOrder sorts added objects, so that each of them goes after its dependencies.
+// Dependencies, which were already in the store, are initialized already, so they are ignored.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) liveInitializationOrder(added []ObjID) ([]ObjID, error) {
+	graph := make(utils.Graph[ObjID], len(added))
+	for _, objID := range added {
+		var deps []ObjID
+		for _, depID := range s.dependenciesGraph[objID] {
+			if slices.Contains(added, depID) {
+				deps = append(deps, depID)
+			}
+		}
+		graph[objID] = deps
+	}
+
+	stability := added
+	if s.idLess != nil {
+		stability = slices.SortedFunc(slices.Values(added), utils.CompareByLess(s.idLess))
+	}
+
+	order, err := utils.StableTopologicalSortWithSortedKeys(graph, stability)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(order)
+	return order, nil
+}
+
+// rollbackLive stops and closes objects added by failed RegisterLive in reverse order and calls skip handlers for them.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) rollbackLive(added []ObjID) {
+	for i := len(added) - 1; i >= 0; i-- {
+		objID := added[i]
+		object := s.objects[objID]
+
+		if s.states[objID] == ObjectStarted {
+			if s.stopObj != nil {
+				s.logObj(utils.LevelDebug, "Stopping object", object, objID)
+				_ = s.callObj(PhaseStop, object, objID, func() error {
+					s.stopObj(object)
+					return nil
+				})
+			}
+			s.states[objID] = ObjectStopped
+		}
+
+		if s.isInitialized(objID) {
+			if s.closeObj != nil {
+				s.logObj(utils.LevelDebug, "Closing object", object, objID)
+				_ = s.callObj(PhaseClose, object, objID, func() error {
+					s.closeObj(object)
+					return nil
+				})
+			}
+			s.setState(objID, ObjectClosed)
+
+			if s.rolledBack == nil {
+				s.rolledBack = make(map[ObjID]struct{})
+			}
+			s.rolledBack[objID] = struct{}{}
+		}
+
+		// Skipped objects are already handled.
+		if s.states[objID] != ObjectSkipped {
+			for _, h := range s.skipHandlers {
+				h(object)
+			}
+		}
+	}
+}
+
+// RegisterLive registers top-level objects into live shards, to which they belong. See GenericStore.RegisterLive.
+// Lock of each affected shard is held while registering objects into it.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterLive(ptrs ...interface{}) error {
+	byShard := make(map[*GenericStore[SharedObject, ObjID, InitParams]][]interface{})
+	for _, ptr := range ptrs {
+		shard := s.shardFor(ptr)
+		byShard[shard] = append(byShard[shard], ptr)
+	}
+
+	for i, shard := range s.shards {
+		if len(byShard[shard]) == 0 {
+			continue
+		}
+
+		s.locks[i].Lock()
+		err := shard.RegisterLive(byShard[shard]...)
+		s.locks[i].Unlock()
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to register objects into shard %v", i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package objstore
+
+// DependenciesOf returns objects, on which the object depends, directly or, if transitive is set, through other
+// objects, in initialization order. Dependencies are known only after Init, so before it nil is returned.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) DependenciesOf(objID ObjID, transitive bool) []ObjID {
+	return s.neighbours(objID, transitive, func(id ObjID) []ObjID {
+		return s.dependenciesGraph[id]
+	})
+}
+
+// DependentsOf returns objects, which depend on the object directly or, if transitive is set, through other objects,
+// in initialization order, e.g. to find out what stops working, if provider fails. Dependents are known only after Init.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) DependentsOf(objID ObjID, transitive bool) []ObjID {
+	dependents := make(map[ObjID][]ObjID, len(s.dependenciesGraph))
+	for id, deps := range s.dependenciesGraph {
+		for _, depID := range deps {
+			dependents[depID] = append(dependents[depID], id)
+		}
+	}
+
+	return s.neighbours(objID, transitive, func(id ObjID) []ObjID {
+		return dependents[id]
+	})
+}
+
+// neighbours returns objects reachable from the object by edges, in initialization order.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) neighbours(objID ObjID, transitive bool, edges func(id ObjID) []ObjID) []ObjID {
+	found := make(map[ObjID]struct{})
+	queue := []ObjID{objID}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, next := range edges(id) {
+			if _, ok := found[next]; ok {
+				continue
+			}
+			found[next] = struct{}{}
+
+			if transitive {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var result []ObjID
+	for _, id := range s.initializationOrder {
+		if _, ok := found[id]; ok && id != objID {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,139 @@
+package objstore
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// LifecyclePhase is a phase of lifecycle of shared object.
+type LifecyclePhase string
+
+const (
+	PhaseGatherRequirements LifecyclePhase = "gather_requirements"
+	PhaseInit               LifecyclePhase = "init"
+	PhaseStart              LifecyclePhase = "start"
+	PhaseStop               LifecyclePhase = "stop"
+	PhaseClose              LifecyclePhase = "close"
+	PhasePause              LifecyclePhase = "pause"
+	PhaseResume             LifecyclePhase = "resume"
+)
+
+// Observer receives notifications about lifecycle of objects, e.g. to collect metrics.
+// It is called synchronously from lifecycle methods of the store.
+type Observer interface {
+	// LifecyclePhaseFinished is called after lifecycle method of the object has returned.
+	// Err is the error returned by the method, if any.
+	LifecyclePhaseFinished(phase LifecyclePhase, obj interface{}, duration time.Duration, err error)
+}
+
+type storeConfig struct {
+	observer            Observer
+	registrationStacks  bool
+	skipHandlers        []func(obj interface{})
+	replacementHandlers []func(obj, replica interface{})
+	initValidators      []func() error
+	gatherHandlers      []func(obj interface{})
+	errorPolicy         utils.ErrorPolicy
+	parallelWorkers     int
+	timeouts            map[LifecyclePhase]time.Duration
+	timeoutHandlers     []func(phase LifecyclePhase, id string, obj interface{})
+}
+
+// StoreOption configures optional features of the store.
+type StoreOption func(c *storeConfig)
+
+// WithObserver sets observer of lifecycle of objects.
+func WithObserver(o Observer) StoreOption {
+	return func(c *storeConfig) {
+		c.observer = o
+	}
+}
+
+// WithRegistrationStacks enables recording of stack trace of first registration of each object.
+// It is included into errors about objects registered with same ID and different type.
+// Disabled by default, because capturing stack trace on each registration is slow.
+func WithRegistrationStacks() StoreOption {
+	return func(c *storeConfig) {
+		c.registrationStacks = true
+	}
+}
+
+// WithSkipHandler adds function, which is called when Init or Start of the object returns ErrSkip,
+// e.g. to remove the object from update tree. Can be used multiple times.
+func WithSkipHandler(h func(obj interface{})) StoreOption {
+	return func(c *storeConfig) {
+		c.skipHandlers = append(c.skipHandlers, h)
+	}
+}
+
+// WithErrorPolicy sets how misuse of the store is reported, e.g. registration of invalid objects.
+// With utils.PolicyLenient store never panics: misuse is logged, failed operation is skipped,
+// and the first error is returned by Init. By default utils.PolicyStrict is used.
+func WithErrorPolicy(p utils.ErrorPolicy) StoreOption {
+	return func(c *storeConfig) {
+		c.errorPolicy = p
+	}
+}
+
+// WithReplacementHandler adds function, which is called when registered object is replaced by already registered
+// object with same ID, e.g. to check that the replaced object is not used anywhere. Can be used multiple times.
+func WithReplacementHandler(h func(obj, replica interface{})) StoreOption {
+	return func(c *storeConfig) {
+		c.replacementHandlers = append(c.replacementHandlers, h)
+	}
+}
+
+// WithInitValidator adds function, which is called after all objects have been initialized.
+// If it returns error, Init fails with it. Can be used multiple times.
+func WithInitValidator(v func() error) StoreOption {
+	return func(c *storeConfig) {
+		c.initValidators = append(c.initValidators, v)
+	}
+}
+
+// WithGatherHandler adds function, which is called for each object before its requirements are gathered,
+// e.g. to configure the object in the same way for all objects of the store. Can be used multiple times.
+func WithGatherHandler(h func(obj interface{})) StoreOption {
+	return func(c *storeConfig) {
+		c.gatherHandlers = append(c.gatherHandlers, h)
+	}
+}
+
+// WithParallelLifecycle enables concurrent Init and Start of objects, e.g. for graphs of thousands of indicators.
+// Objects are processed by levels: object is processed only after all its dependencies, and objects of the same level
+// are processed by the given number of goroutines, or by GOMAXPROCS goroutines, if it is not positive.
+// The first error cancels the rest: objects, which have not been called yet, are not called, and context passed into
+// ContextInitializer and ContextStarter is cancelled. Lifecycle methods of objects and logger must be safe to be called
+// concurrently, calls of observer are serialized. Stop and Close are still sequential.
+func WithParallelLifecycle(workers int) StoreOption {
+	return func(c *storeConfig) {
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		c.parallelWorkers = workers
+	}
+}
+
+// WithPhaseTimeout limits duration of lifecycle method of each object in the phase (init, start, stop or close).
+// Running method can't be interrupted, but when timeout is exceeded, timeout handlers are called or, if there are none,
+// warning is logged, so that object blocking the store is visible. If Init or Start of the object succeeds after
+// the timeout, it fails with ErrTimeout. Context passed into context-aware methods has deadline of the timeout.
+func WithPhaseTimeout(phase LifecyclePhase, timeout time.Duration) StoreOption {
+	return func(c *storeConfig) {
+		if c.timeouts == nil {
+			c.timeouts = make(map[LifecyclePhase]time.Duration)
+		}
+		c.timeouts[phase] = timeout
+	}
+}
+
+// WithTimeoutHandler adds function, which is called with ID of the object, when its lifecycle method exceeds timeout
+// set by WithPhaseTimeout, e.g. to dump goroutines. It is called from another goroutine, while the method is still
+// running, and the store waits for it after the method returns. Can be used multiple times.
+func WithTimeoutHandler(h func(phase LifecyclePhase, id string, obj interface{})) StoreOption {
+	return func(c *storeConfig) {
+		c.timeoutHandlers = append(c.timeoutHandlers, h)
+	}
+}
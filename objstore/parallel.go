@@ -0,0 +1,108 @@
+package objstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// runParallel runs objects level by level using parallelWorkers goroutines. Run is called concurrently and must not
+// change state of the store, finish is called after all objects of the level have returned, in order of objects.
+// Returns the first error, which occurred.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) runParallel(
+	order []ObjID,
+	what string,
+	run func(ctx context.Context, objID ObjID) error,
+	finish func(objID ObjID, err error) error,
+) error {
+	parent := s.lifecycleContext()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	for _, level := range s.dependencyLevels(order) {
+		results := make([]error, len(level))
+		called := make([]bool, len(level))
+		// Index of the object, which failed first, or -1.
+		failedFirst := -1
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, s.parallelWorkers)
+
+		var cancelledErr error
+		for i, objID := range level {
+			workers <- struct{}{}
+			if err := ctx.Err(); err != nil {
+				<-workers
+				if parent.Err() != nil {
+					cancelledErr = errors.Wrapf(parent.Err(), "%v is cancelled before object %v", what, objID)
+				}
+				break
+			}
+
+			called[i] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-workers }()
+
+				err := run(ctx, objID)
+				results[i] = err
+				if err == nil || errors.Is(err, ErrSkip) {
+					return
+				}
+
+				lock.Lock()
+				if failedFirst < 0 {
+					failedFirst = i
+				}
+				lock.Unlock()
+				cancel()
+			}()
+		}
+		wg.Wait()
+
+		var firstErr error
+		for i, objID := range level {
+			if !called[i] {
+				continue
+			}
+			if err := finish(objID, results[i]); err != nil && i == failedFirst {
+				firstErr = err
+			}
+		}
+
+		if firstErr != nil {
+			return firstErr
+		}
+		if cancelledErr != nil {
+			return cancelledErr
+		}
+	}
+
+	return nil
+}
+
+// dependencyLevels splits objects into levels, so that all dependencies of each object are in previous levels.
+// Order must be initialization order. Order of objects inside of each level is kept.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) dependencyLevels(order []ObjID) [][]ObjID {
+	levelOf := make(map[ObjID]int, len(order))
+	var levels [][]ObjID
+
+	for _, objID := range order {
+		level := 0
+		for _, depID := range s.dependenciesGraph[objID] {
+			if depLevel, ok := levelOf[depID]; ok && depLevel+1 > level {
+				level = depLevel + 1
+			}
+		}
+
+		levelOf[objID] = level
+		if level == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], objID)
+	}
+
+	return levels
+}
@@ -0,0 +1,113 @@
+package objstore
+
+import (
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+)
+
+// Pausable is an optional interface of shared objects, which can temporarily suspend their background activity,
+// e.g. feeds stopping to publish updates during reconfiguration, without being stopped.
+type Pausable interface {
+	Pause()
+	Resume()
+}
+
+// Pause pauses started store: calls Pause() of started objects, which implement Pausable, in reverse initialization
+// order, so that dependants are paused before their dependencies. Store stays started, so it can be stopped without
+// being resumed. Objects added by RegisterLive into paused store are paused too.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Pause() error {
+	if err := s.checkPhase(storeStarted); err != nil {
+		return err
+	}
+	if s.paused {
+		return ErrAlreadyPaused
+	}
+
+	s.paused = true
+	s.pauseObjects(s.initializationOrder)
+
+	return nil
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) pauseObjects(order []ObjID) {
+	for i := len(order) - 1; i >= 0; i-- {
+		objID := order[i]
+		pausable, ok := any(s.objects[objID]).(Pausable)
+		if !ok || s.states[objID] != ObjectStarted {
+			continue
+		}
+
+		s.logObj(utils.LevelDebug, "Pausing object", s.objects[objID], objID)
+		_ = s.callObj(PhasePause, s.objects[objID], objID, func() error {
+			pausable.Pause()
+			return nil
+		})
+	}
+}
+
+// Resume resumes paused store: calls Resume() of paused objects in initialization order.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Resume() error {
+	if err := s.checkPhase(storeStarted); err != nil {
+		return err
+	}
+	if !s.paused {
+		return ErrNotPaused
+	}
+
+	s.paused = false
+
+	for _, objID := range s.initializationOrder {
+		pausable, ok := any(s.objects[objID]).(Pausable)
+		if !ok || s.states[objID] != ObjectStarted {
+			continue
+		}
+
+		s.logObj(utils.LevelDebug, "Resuming object", s.objects[objID], objID)
+		_ = s.callObj(PhaseResume, s.objects[objID], objID, func() error {
+			pausable.Resume()
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// Paused reports whether the store has been paused and not resumed or stopped after that.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Paused() bool {
+	return s.paused
+}
+
+// Pause pauses all shards and then parent store. See GenericStore.Pause.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Pause() error {
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		if err := s.shards[i].Pause(); err != nil {
+			return errors.Wrapf(err, "failed to pause shard %v", i)
+		}
+	}
+
+	if err := s.parent.Pause(); err != nil {
+		return errors.Wrapf(err, "failed to pause parent store")
+	}
+
+	return nil
+}
+
+// Resume resumes parent store and then all shards. See GenericStore.Resume.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Resume() error {
+	if err := s.parent.Resume(); err != nil {
+		return errors.Wrapf(err, "failed to resume parent store")
+	}
+
+	for i, shard := range s.shards {
+		if err := shard.Resume(); err != nil {
+			return errors.Wrapf(err, "failed to resume shard %v", i)
+		}
+	}
+
+	return nil
+}
+
+// Paused reports whether parent store has been paused.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Paused() bool {
+	return s.parent.Paused()
+}
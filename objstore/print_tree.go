@@ -0,0 +1,77 @@
+package objstore
+
+import (
+	"fmt"
+	"io"
+)
+
+// PrintTree writes indented tree of transitive dependencies of the object, e.g. for visualization in a terminal.
+// Dependencies shared with objects printed above are marked with "(shared)" and are not expanded again.
+// Dependencies are known only after Init.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) PrintTree(w io.Writer, rootID ObjID) error {
+	if _, ok := s.objects[rootID]; !ok {
+		return fmt.Errorf("object %v is not registered in the store", rootID)
+	}
+
+	return printTree(w, rootID, s.treeNode)
+}
+
+// PrintTree is same as GenericStore.PrintTree. Dependencies owned by parent store are printed with their dependencies too.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) PrintTree(w io.Writer, rootID ObjID) error {
+	if _, ok := s.parent.objects[rootID]; ok {
+		return s.parent.PrintTree(w, rootID)
+	}
+
+	shard := s.shards[s.ShardOf(rootID)]
+	if _, ok := shard.objects[rootID]; !ok {
+		return fmt.Errorf("object %v is not registered in the store", rootID)
+	}
+
+	return printTree(w, rootID, func(objID ObjID) ([]ObjID, ObjectState) {
+		if _, ok := shard.objects[objID]; ok {
+			return shard.treeNode(objID)
+		}
+		return s.parent.treeNode(objID)
+	})
+}
+
+// treeNode returns dependencies and state of the object.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) treeNode(objID ObjID) ([]ObjID, ObjectState) {
+	return s.dependenciesGraph[objID], s.StateOf(objID)
+}
+
+func printTree[ObjID comparable](w io.Writer, rootID ObjID, node func(objID ObjID) ([]ObjID, ObjectState)) error {
+	printed := make(map[ObjID]struct{})
+
+	var printNode func(objID ObjID, prefix, branch, childPrefix string) error
+	printNode = func(objID ObjID, prefix, branch, childPrefix string) error {
+		deps, state := node(objID)
+
+		_, shared := printed[objID]
+		marker := ""
+		if shared {
+			marker = " (shared)"
+		}
+		if _, err := fmt.Fprintf(w, "%v%v%v [%v]%v\n", prefix, branch, objID, state, marker); err != nil {
+			return err
+		}
+		if shared {
+			return nil
+		}
+		printed[objID] = struct{}{}
+
+		for i, depID := range deps {
+			depBranch, depChildPrefix := "├── ", "│   "
+			if i == len(deps)-1 {
+				depBranch, depChildPrefix = "└── ", "    "
+			}
+			if err := printNode(depID, prefix+childPrefix, depBranch, depChildPrefix); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return printNode(rootID, "", "", "")
+}
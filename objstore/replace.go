@@ -0,0 +1,74 @@
+package objstore
+
+import (
+	"fmt"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// Replace makes the store use obj instead of the object with the ID, e.g. to inject stub of price provider in tests,
+// while the rest of the graph keeps registering the original one. Object is stored under the ID regardless of its
+// own ID, and registrations of the original object are rewritten with it as with shared replica, so it must have
+// type assignable to fields holding the original object. Must be called before Init. If object with the ID is already
+// registered, error is returned, unless force is set: then the object is swapped, but pointers already rewritten by
+// previous registrations keep the original object.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Replace(objID ObjID, obj SharedObject, force bool) error {
+	if err := s.checkPhase(storeCreated); err != nil {
+		return err
+	}
+
+	if _, registered := s.objects[objID]; registered {
+		if !force {
+			return fmt.Errorf("object %v is already registered, so it can't be replaced without force", objID)
+		}
+
+		s.logObj(utils.LevelDebug, "Replacing registered object", obj, objID)
+		s.objects[objID] = obj
+	}
+
+	if s.replacements == nil {
+		s.replacements = make(map[ObjID]SharedObject)
+	}
+	s.replacements[objID] = obj
+
+	return nil
+}
+
+// replacement returns object, which must be registered instead of the object with the ID, if it is set by Replace.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) replacement(objID ObjID, obj SharedObject, isSameType func(registered SharedObject) bool) (SharedObject, bool) {
+	replacement, ok := s.replacements[objID]
+	if !ok {
+		return obj, true
+	}
+
+	if isSameType != nil && !isSameType(replacement) {
+		s.fail("Object with id %v of type %T is replaced by object of different type: %T", objID, obj, replacement)
+		return obj, false
+	}
+
+	s.replaced(obj, replacement)
+	return replacement, true
+}
+
+// Replace is same as GenericStore.Replace, but applies to the parent store and to all shards, because the object
+// can be registered as dependency by objects of any shard or shared by all of them using RegisterShared.
+// If the original object is registered by several shards without RegisterShared, each of them registers obj,
+// so it must be safe for concurrent use as objects of the parent store. Nothing is replaced, if any of stores fails.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Replace(objID ObjID, obj SharedObject, force bool) error {
+	stores := append([]*GenericStore[SharedObject, ObjID, InitParams]{s.parent}, s.shards...)
+	for _, store := range stores {
+		if err := store.checkPhase(storeCreated); err != nil {
+			return err
+		}
+
+		if _, registered := store.objects[objID]; registered && !force {
+			return fmt.Errorf("object %v is already registered, so it can't be replaced without force", objID)
+		}
+	}
+
+	for _, store := range stores {
+		utils.Must(store.Replace(objID, obj, force))
+	}
+
+	return nil
+}
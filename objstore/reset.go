@@ -0,0 +1,121 @@
+package objstore
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RegisterConstructor registers top-level object created by construct, and remembers construct, so that Reset can
+// create the object again, e.g. for the next run of backtest. Created object can be captured by construct to be used
+// after Init. Must be called before Init.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterConstructor(construct func() SharedObject) {
+	if s.phase != storeCreated {
+		s.fail("constructor can be registered only before Init, but store is %v", s.phase)
+		return
+	}
+
+	s.constructors = append(s.constructors, construct)
+	s.construct(construct)
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) construct(construct func() SharedObject) {
+	obj := construct()
+	objType := reflect.TypeOf(obj)
+
+	if _, ok := s.RegisterObject(obj, func(registered SharedObject) bool {
+		return reflect.TypeOf(registered) == objType
+	}); !ok {
+		return
+	}
+
+	if s.constructed == nil {
+		s.constructed = make(map[ObjID]struct{})
+	}
+	s.constructed[s.getID(obj)] = struct{}{}
+}
+
+// Reset returns closed store into the state, in which it was created, and registers objects created by constructors
+// added with RegisterConstructor again, so that the same store can be initialized and run once more.
+// Options, logger and lifecycle functions of the store are kept, while replacements set by Replace are dropped.
+// Returns ErrNotClosed, if the store has been initialized, but not closed yet, and error, if top-level object
+// has been registered without constructor, because it can't be created again.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Reset() error {
+	if s.phase != storeClosed && s.phase != storeCreated {
+		return ErrNotClosed
+	}
+
+	for _, objID := range s.objectsRegistrationOrder {
+		if _, constructed := s.constructed[objID]; !constructed && s.topLevelRefs[objID] > 0 {
+			return fmt.Errorf("object %v is registered without constructor, so it can't be created again", objID)
+		}
+	}
+
+	s.objects = make(map[ObjID]SharedObject)
+	s.states = make(map[ObjID]ObjectState)
+	s.failures = make(map[ObjID]*ObjectFailure)
+	s.initDurations = make(map[ObjID]time.Duration)
+	s.skipped = make(map[ObjID]LifecyclePhase)
+	s.objectsRegistrationOrder = nil
+	s.topLevelDependencies = nil
+	s.recentlyRegisteredSharedObjects = nil
+	s.dependencies, s.dependenciesSet = nil, nil
+	s.dependenciesGraph = nil
+	s.initializationOrder = nil
+	s.initParams = *new(InitParams)
+	s.disabled = nil
+	s.misuses = nil
+	s.registeredAt = nil
+	s.topLevelRefs = nil
+	s.rolledBack = nil
+	s.constructed = nil
+	s.replacements = nil
+	s.objLoggersLock.Lock()
+	s.objLoggers = nil
+	s.objLoggersLock.Unlock()
+	s.phase = storeCreated
+
+	for _, construct := range s.constructors {
+		s.construct(construct)
+	}
+
+	if len(s.misuses) > 0 {
+		return errors.Wrapf(s.misuses[0], "failed to register constructed objects")
+	}
+
+	return nil
+}
+
+// RegisterConstructor registers object created by construct into the shard, to which it belongs.
+// See GenericStore.RegisterConstructor.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterConstructor(construct func() SharedObject) {
+	obj := construct()
+	created := true
+
+	s.shards[s.ShardOf(s.getID(obj))].RegisterConstructor(func() SharedObject {
+		if created {
+			// Object has been created to determine its shard already.
+			created = false
+			return obj
+		}
+		return construct()
+	})
+}
+
+// Reset resets all shards and then parent store. See GenericStore.Reset.
+// Objects registered by RegisterShared can't be created again, so Reset fails, if there are any.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Reset() error {
+	for i, shard := range s.shards {
+		if err := shard.Reset(); err != nil {
+			return errors.Wrapf(err, "failed to reset shard %v", i)
+		}
+	}
+
+	if err := s.parent.Reset(); err != nil {
+		return errors.Wrapf(err, "failed to reset parent store")
+	}
+
+	return nil
+}
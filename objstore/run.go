@@ -0,0 +1,108 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Runner is an optional interface of shared objects, which have long-running background loops.
+// When store is run using Run, method Run of each such object is called in its own goroutine after Start.
+// It must return when context is done. Error other than context cancellation stops the whole store.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// Run initializes and starts the store, runs objects implementing Runner until context is done
+// or any of them fails, and then stops and closes the store. Objects stop only after all runners returned.
+// Returned error combines errors of all lifecycle methods and runners.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Run(ctx context.Context, initParams InitParams) error {
+	return runStore[InitParams](ctx, s, initParams, s.runners)
+}
+
+// Run is same as GenericStore.Run, but runs objects of parent store and all shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Run(ctx context.Context, initParams InitParams) error {
+	return runStore[InitParams](ctx, s, initParams, func() []namedRunner {
+		runners := s.parent.runners()
+		for _, shard := range s.shards {
+			runners = append(runners, shard.runners()...)
+		}
+		return runners
+	})
+}
+
+type namedRunner struct {
+	name   string
+	runner Runner
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) runners() []namedRunner {
+	var runners []namedRunner
+	for _, objID := range s.initializationOrder {
+		if s.states[objID] != ObjectStarted {
+			continue
+		}
+
+		if r, ok := any(s.objects[objID]).(Runner); ok {
+			runners = append(runners, namedRunner{name: fmt.Sprint(objID), runner: r})
+		}
+	}
+
+	return runners
+}
+
+func runStore[InitParams any](ctx context.Context, store Lifecycle[InitParams], initParams InitParams, getRunners func() []namedRunner) error {
+	if err := store.Init(initParams); err != nil {
+		return errors.Join(err, store.CloseE())
+	}
+
+	var errs []error
+
+	if err := store.Start(); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, runAll(ctx, getRunners())...)
+	}
+
+	if err := store.StopE(); err != nil {
+		errs = append(errs, err)
+	} else if err := store.CloseE(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runAll runs all runners until context is done or one of them fails, and waits for all of them to return.
+func runAll(ctx context.Context, runners []namedRunner) []error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+
+	for _, r := range runners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := r.runner.Run(ctx)
+			if err == nil || (errors.Is(err, context.Canceled) && ctx.Err() != nil) {
+				return
+			}
+
+			lock.Lock()
+			errs = append(errs, fmt.Errorf("object %v failed: %w", r.name, err))
+			lock.Unlock()
+
+			cancel()
+		}()
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	return errs
+}
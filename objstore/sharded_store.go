@@ -0,0 +1,340 @@
+package objstore
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+)
+
+// NewShardedStore creates store, which partitions top-level objects by hash of their ID across
+// shardsCount independent stores. Function newStore is called to create each shard and the common parent.
+// Function hash is optional, by default FNV hash of formatted ID is used. Custom hash can be used
+// to keep related objects (e.g. objects of same strategy group) in the same shard.
+func NewShardedStore[SharedObject any, ObjID comparable, InitParams any](
+	shardsCount int,
+	getID func(obj SharedObject) ObjID,
+	hash func(objID ObjID) uint64, // optional
+	newStore func() *GenericStore[SharedObject, ObjID, InitParams],
+) *ShardedStore[SharedObject, ObjID, InitParams] {
+	if shardsCount <= 0 {
+		panic(fmt.Sprintf("invalid shards count: %v", shardsCount))
+	}
+
+	if hash == nil {
+		hash = func(objID ObjID) uint64 {
+			h := fnv.New64a()
+			fmt.Fprint(h, objID)
+			return h.Sum64()
+		}
+	}
+
+	parent := newStore()
+	shards := make([]*GenericStore[SharedObject, ObjID, InitParams], shardsCount)
+	for i := range shards {
+		shards[i] = newStore()
+		shards[i].parent = parent
+	}
+
+	return &ShardedStore[SharedObject, ObjID, InitParams]{
+		getID:  getID,
+		hash:   hash,
+		parent: parent,
+		shards: shards,
+		locks:  make([]sync.Mutex, shardsCount),
+	}
+}
+
+// ShardedStore is a facade over multiple independent stores (shards).
+// Each top-level object is registered in one of the shards together with its dependencies.
+// Objects registered using RegisterShared are owned by the common parent store and are used
+// by all shards instead of creating a replica in each shard.
+//
+// Lifecycle methods must be called from a single goroutine. After Start, shards can be used
+// concurrently: each shard has its own lock, which must be held while working with its objects (see Do).
+// Objects of the parent store are accessed from all shards, so they must be safe for concurrent use.
+// Their updates are propagated to subscribers in any shard, so they must be made holding locks of all shards
+// (see DoParent and ParentLocker), e.g. provider in the parent store must notify about update inside DoParent.
+type ShardedStore[SharedObject any, ObjID comparable, InitParams any] struct {
+	getID  func(obj SharedObject) ObjID
+	hash   func(objID ObjID) uint64
+	parent *GenericStore[SharedObject, ObjID, InitParams]
+	shards []*GenericStore[SharedObject, ObjID, InitParams]
+	locks  []sync.Mutex
+}
+
+// Register top-level object in the shard it belongs to.
+// Expects pointer to pointer.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Register(obj interface{}) {
+	s.shardFor(obj).Register(obj)
+}
+
+// RegisterE is same as Register, but returns error instead of reporting misuse. See GenericStore.RegisterE.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterE(obj interface{}) error {
+	return s.shardFor(obj).RegisterE(obj)
+}
+
+// shardFor returns shard, to which object passed into Register belongs.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) shardFor(obj interface{}) *GenericStore[SharedObject, ObjID, InitParams] {
+	objV := reflect.ValueOf(obj)
+	if objV.Kind() != reflect.Pointer || objV.IsNil() || objV.Elem().Kind() != reflect.Pointer || objV.Elem().IsNil() {
+		// Let the store report invalid object.
+		return s.shards[0]
+	}
+
+	sharedObj, ok := objV.Elem().Interface().(SharedObject)
+	if !ok {
+		return s.shards[0]
+	}
+
+	return s.shards[s.ShardOf(s.getID(sharedObj))]
+}
+
+// RegisterShared registers object in the parent store, so that it is shared by all shards.
+// Must be called before objects of shards, which depend on it, are registered.
+// Expects pointer to pointer.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterShared(obj interface{}) {
+	s.parent.Register(obj)
+}
+
+// Init initializes parent store and then all shards.
+// If Init fails, Close can be called to close objects, which have been initialized.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Init(initParams InitParams) error {
+	return s.InitContext(context.Background(), initParams)
+}
+
+// InitContext is same as Init, but passes ctx into parent store and all shards. See GenericStore.InitContext.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) InitContext(ctx context.Context, initParams InitParams) error {
+	if err := s.parent.InitContext(ctx, initParams); err != nil {
+		return errors.Wrapf(err, "failed to initialize parent store")
+	}
+
+	for i, shard := range s.shards {
+		if err := shard.InitContext(ctx, initParams); err != nil {
+			// Initialization of the whole store has failed, so initialized shards can only be closed.
+			s.parent.phase = storeInitFailed
+			for _, initialized := range s.shards[:i] {
+				initialized.phase = storeInitFailed
+			}
+
+			return errors.Wrapf(err, "failed to initialize shard %v", i)
+		}
+	}
+
+	return nil
+}
+
+// Start starts parent store and then all shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Start() error {
+	return s.StartContext(context.Background())
+}
+
+// StartContext is same as Start, but passes ctx into parent store and all shards. See GenericStore.StartContext.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) StartContext(ctx context.Context) error {
+	if err := s.parent.StartContext(ctx); err != nil {
+		return errors.Wrapf(err, "failed to start parent store")
+	}
+
+	for i, shard := range s.shards {
+		if err := shard.StartContext(ctx); err != nil {
+			return errors.Wrapf(err, "failed to start shard %v", i)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops all shards and then parent store.
+// It can be called after failed Start: shards, which were not started, are only marked as stopped.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Stop() {
+	if err := s.StopE(); err != nil {
+		s.parent.fail("%v", err)
+	}
+}
+
+// StopE is same as Stop, but returns error instead of reporting misuse. See GenericStore.StopE.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) StopE() error {
+	return s.StopContext(context.Background())
+}
+
+// StopContext is same as Stop, but passes ctx into all shards and parent store. See GenericStore.StopContext.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) StopContext(ctx context.Context) error {
+	if s.parent.alreadyPassed(storeStopped, "Stop") {
+		return nil
+	}
+
+	if err := s.parent.checkPhase(storeStarted); err != nil {
+		return err
+	}
+
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		if s.shards[i].phase == storeInitialized {
+			// Shard was not started, because start of the parent store or of one of previous shards has failed.
+			s.shards[i].phase = storeStopped
+			continue
+		}
+
+		if err := s.shards[i].StopContext(ctx); err != nil {
+			return errors.Wrapf(err, "failed to stop shard %v", i)
+		}
+	}
+
+	if err := s.parent.StopContext(ctx); err != nil {
+		return errors.Wrapf(err, "failed to stop parent store")
+	}
+
+	return nil
+}
+
+// Close closes all shards and then parent store. It can also be called right after failed Init.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Close() {
+	if err := s.CloseE(); err != nil {
+		s.parent.fail("%v", err)
+	}
+}
+
+// CloseE is same as Close, but returns error instead of reporting misuse. See GenericStore.CloseE.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) CloseE() error {
+	if s.parent.alreadyPassed(storeClosed, "Close") {
+		return nil
+	}
+
+	if s.parent.phase != storeInitFailed {
+		if err := s.parent.checkPhase(storeStopped); err != nil {
+			return err
+		}
+	}
+
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		if s.shards[i].phase == storeCreated {
+			// Shard was not initialized, because initialization of one of previous shards has failed.
+			continue
+		}
+
+		if err := s.shards[i].CloseE(); err != nil {
+			return errors.Wrapf(err, "failed to close shard %v", i)
+		}
+	}
+
+	if err := s.parent.CloseE(); err != nil {
+		return errors.Wrapf(err, "failed to close parent store")
+	}
+
+	return nil
+}
+
+// Abort aborts all shards and then parent store. See GenericStore.Abort.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Abort() error {
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		if err := s.shards[i].Abort(); err != nil {
+			return errors.Wrapf(err, "failed to abort shard %v", i)
+		}
+	}
+
+	if err := s.parent.Abort(); err != nil {
+		return errors.Wrapf(err, "failed to abort parent store")
+	}
+
+	return nil
+}
+
+// Returns object by its ID. For an object, which is not top-level, returns its replica from parent store or
+// from the shard of top-level object with the same ID.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Get(objID ObjID) SharedObject {
+	return s.shards[s.ShardOf(objID)].Get(objID)
+}
+
+// ShardOf returns index of the shard, to which top-level object with the ID belongs.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ShardOf(objID ObjID) int {
+	return int(s.hash(objID) % uint64(len(s.shards)))
+}
+
+// Shard returns shard by index.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Shard(i int) *GenericStore[SharedObject, ObjID, InitParams] {
+	return s.shards[i]
+}
+
+// ShardsCount returns number of shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ShardsCount() int {
+	return len(s.shards)
+}
+
+// Parent returns store, which owns objects shared between shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Parent() *GenericStore[SharedObject, ObjID, InitParams] {
+	return s.parent
+}
+
+// ShardLocker returns lock of the shard, to which top-level object with the ID belongs.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ShardLocker(objID ObjID) sync.Locker {
+	return &s.locks[s.ShardOf(objID)]
+}
+
+// Do calls f while holding lock of the shard, to which top-level object with the ID belongs.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Do(objID ObjID, f func(shard *GenericStore[SharedObject, ObjID, InitParams])) {
+	i := s.ShardOf(objID)
+
+	s.locks[i].Lock()
+	defer s.locks[i].Unlock()
+
+	f(s.shards[i])
+}
+
+// ParentLocker returns lock, which holds locks of all shards, so that updates of objects of the parent store
+// can be propagated to their subscribers in shards, e.g. to be passed as lock of updtree.NewSynchronizedNode.
+// Locks of shards are taken in order of shards, so it must not be acquired while holding lock of any shard.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) ParentLocker() sync.Locker {
+	return allShardsLocker{locks: s.locks}
+}
+
+// DoParent calls f while holding locks of all shards (see ParentLocker), e.g. to notify about update of object
+// of the parent store.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) DoParent(f func(parent *GenericStore[SharedObject, ObjID, InitParams])) {
+	l := s.ParentLocker()
+	l.Lock()
+	defer l.Unlock()
+
+	f(s.parent)
+}
+
+type allShardsLocker struct {
+	locks []sync.Mutex
+}
+
+func (l allShardsLocker) Lock() {
+	for i := range l.locks {
+		l.locks[i].Lock()
+	}
+}
+
+func (l allShardsLocker) Unlock() {
+	for i := len(l.locks) - 1; i >= 0; i-- {
+		l.locks[i].Unlock()
+	}
+}
+
+// Misuses returns misuse errors recorded by parent store and all shards under lenient error policy.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Misuses() []error {
+	misuses := slices.Clone(s.parent.Misuses())
+	for _, shard := range s.shards {
+		misuses = append(misuses, shard.Misuses()...)
+	}
+
+	return misuses
+}
+
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) reportFailure(err error) {
+	s.shards[0].reportFailure(err)
+}
+
+// SetFailureHandler sets failure handler of parent store and all shards.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) SetFailureHandler(h utils.FailureHandler) {
+	s.parent.SetFailureHandler(h)
+	for _, shard := range s.shards {
+		shard.SetFailureHandler(h)
+	}
+}
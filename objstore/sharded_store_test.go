@@ -0,0 +1,451 @@
+package objstore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+type shardTestObj struct {
+	id      string
+	deps    []*shardTestObj
+	inits   int
+	updates int
+}
+
+func (o *shardTestObj) ID() string {
+	return o.id
+}
+
+func (o *shardTestObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	for i := range o.deps {
+		s.Register(&o.deps[i])
+	}
+}
+
+func (o *shardTestObj) Init(p *InitParams) error {
+	o.inits++
+	return nil
+}
+
+func (o *shardTestObj) Start(p *InitParams) error { return nil }
+func (o *shardTestObj) Stop()                     {}
+func (o *shardTestObj) Close()                    {}
+
+// hashGroup puts objects of the same group into the same shard.
+func hashGroup(objID string) uint64 {
+	group, _, _ := strings.Cut(objID, "/")
+	h := fnv.New64a()
+	h.Write([]byte(group))
+	return h.Sum64()
+}
+
+func newShardedTestStore(shardsCount int) *objstore.ShardedStore[SharedObject, string, *InitParams] {
+	getID := func(obj SharedObject) string {
+		return obj.ID()
+	}
+
+	return objstore.NewShardedStore(shardsCount, getID, hashGroup, func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](getID, nil)
+	})
+}
+
+func TestShardedStore_Basic(t *testing.T) {
+	t.Parallel()
+
+	const groupsCount = 10
+	const strategiesPerGroup = 10
+
+	store := newShardedTestStore(4)
+
+	provider := &shardTestObj{id: "provider"}
+	store.RegisterShared(&provider)
+
+	strategies := make([]*shardTestObj, 0, groupsCount*strategiesPerGroup)
+	for g := 0; g < groupsCount; g++ {
+		for i := 0; i < strategiesPerGroup; i++ {
+			strategy := &shardTestObj{
+				id: fmt.Sprintf("group-%v/strategy-%v", g, i),
+				deps: []*shardTestObj{
+					{id: "provider"},
+					{id: fmt.Sprintf("group-%v/feed", g)},
+				},
+			}
+			store.Register(&strategy)
+			strategies = append(strategies, strategy)
+		}
+	}
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	require.Equal(t, 1, provider.inits)
+	require.Same(t, provider, store.Get("provider"))
+
+	for _, strategy := range strategies {
+		require.Equal(t, 1, strategy.inits)
+		require.Same(t, provider, strategy.deps[0])
+
+		feed := strategy.deps[1]
+		require.Equal(t, 1, feed.inits)
+		require.Same(t, feed, store.Get(feed.id))
+		require.Equal(t, store.ShardOf(strategy.id), store.ShardOf(feed.id))
+	}
+
+	require.Nil(t, store.Parent().Get("group-0/feed"))
+
+	store.Stop()
+	store.Close()
+}
+
+func TestShardedStore_ConcurrentShards(t *testing.T) {
+	t.Parallel()
+
+	const groupsCount = 8
+	const updatesCount = 1000
+
+	store := newShardedTestStore(4)
+
+	strategies := make([]*shardTestObj, 0, groupsCount)
+	for g := 0; g < groupsCount; g++ {
+		strategy := &shardTestObj{id: fmt.Sprintf("group-%v/strategy", g)}
+		store.Register(&strategy)
+		strategies = append(strategies, strategy)
+	}
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	var wg sync.WaitGroup
+	for _, strategy := range strategies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < updatesCount; i++ {
+				store.Do(strategy.id, func(shard *objstore.GenericStore[SharedObject, string, *InitParams]) {
+					shard.Get(strategy.id).(*shardTestObj).updates++
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, strategy := range strategies {
+		require.Equal(t, updatesCount, strategy.updates)
+	}
+
+	store.Stop()
+	store.Close()
+}
+
+func TestShardedStore_ParentUpdates(t *testing.T) {
+	t.Parallel()
+
+	const groupsCount = 8
+	const updatesCount = 500
+
+	store := newShardedTestStore(4)
+
+	provider := &shardTestObj{id: "provider"}
+	store.RegisterShared(&provider)
+
+	strategies := make([]*shardTestObj, 0, groupsCount)
+	for g := 0; g < groupsCount; g++ {
+		strategy := &shardTestObj{id: fmt.Sprintf("group-%v/strategy", g), deps: []*shardTestObj{{id: "provider"}}}
+		store.Register(&strategy)
+		strategies = append(strategies, strategy)
+	}
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	// Provider updates its subscribers in all shards, while strategies are updated by their own shards.
+	// Counters are not synchronized otherwise: race detector reports, if parent updates are not serialized with shards.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < updatesCount; i++ {
+			store.DoParent(func(parent *objstore.GenericStore[SharedObject, string, *InitParams]) {
+				require.Same(t, provider, parent.Get("provider"))
+				provider.updates++
+				for _, strategy := range strategies {
+					strategy.updates++
+				}
+			})
+		}
+	}()
+	for _, strategy := range strategies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < updatesCount; i++ {
+				store.Do(strategy.id, func(shard *objstore.GenericStore[SharedObject, string, *InitParams]) {
+					shard.Get(strategy.id).(*shardTestObj).updates++
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, updatesCount, provider.updates)
+	for _, strategy := range strategies {
+		require.Equal(t, 2*updatesCount, strategy.updates)
+	}
+
+	store.Stop()
+	store.Close()
+}
+
+type runnerObj struct {
+	shardTestObj
+	started chan struct{}
+	fail    error
+	runs    int
+	stopped bool
+}
+
+func (o *runnerObj) Run(ctx context.Context) error {
+	o.runs++
+	if o.started != nil {
+		o.started <- struct{}{}
+	}
+
+	if o.fail != nil {
+		return o.fail
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (o *runnerObj) Stop() {
+	o.stopped = true
+}
+
+func TestShardedStore_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Cancel", func(t *testing.T) {
+		const runnersCount = 4
+
+		store := newShardedTestStore(2)
+		started := make(chan struct{}, runnersCount)
+
+		objs := make([]*runnerObj, 0, runnersCount)
+		for i := 0; i < runnersCount; i++ {
+			obj := &runnerObj{shardTestObj: shardTestObj{id: fmt.Sprintf("group-%v/runner", i)}, started: started}
+			store.Register(&obj)
+			objs = append(objs, obj)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			for i := 0; i < runnersCount; i++ {
+				<-started
+			}
+			cancel()
+		}()
+
+		require.NoError(t, store.Run(ctx, &InitParams{}))
+		for _, obj := range objs {
+			require.Equal(t, 1, obj.runs)
+			require.True(t, obj.stopped)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		store := newShardedTestStore(2)
+
+		failing := &runnerObj{shardTestObj: shardTestObj{id: "group-0/failing"}, fail: errors.New("connection lost")}
+		waiting := &runnerObj{shardTestObj: shardTestObj{id: "group-1/waiting"}}
+		store.Register(&failing)
+		store.Register(&waiting)
+
+		err := store.Run(context.Background(), &InitParams{})
+		require.ErrorContains(t, err, "object group-0/failing failed: connection lost")
+		require.Equal(t, 1, waiting.runs)
+		require.True(t, waiting.stopped)
+	})
+}
+
+type failingStartObj struct {
+	shardTestObj
+	stopped bool
+	closed  bool
+}
+
+func (o *failingStartObj) Start(p *InitParams) error { return fmt.Errorf("failed to start %v", o.id) }
+func (o *failingStartObj) Stop()                     { o.stopped = true }
+func (o *failingStartObj) Close()                    { o.closed = true }
+
+func TestShardedStore_StopAfterFailedStart(t *testing.T) {
+	t.Parallel()
+
+	store := newShardedTestStore(4)
+
+	objs := make([]*failingStartObj, 0, 4)
+	for i := 0; i < 4; i++ {
+		obj := &failingStartObj{shardTestObj: shardTestObj{id: fmt.Sprintf("group-%v/obj", i)}}
+		store.Register(&obj)
+		objs = append(objs, obj)
+	}
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.ErrorContains(t, store.Start(), "failed to start")
+	require.ErrorIs(t, store.Start(), objstore.ErrAlreadyStarted)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+	// Repeated calls are ignored and don't stop or close objects again.
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	// Objects, which have failed to start or were not started, are not stopped, but they are initialized, so they are closed.
+	for _, obj := range objs {
+		require.False(t, obj.stopped)
+		require.True(t, obj.closed)
+	}
+}
+
+func TestShardedStore_CloseAfterFailedInit(t *testing.T) {
+	t.Parallel()
+
+	store := newShardedTestStore(4)
+	provider := &shardTestObj{id: "provider"}
+	store.RegisterShared(&provider)
+
+	objs := make([]*failingInitObj, 0, 8)
+	for i := 0; i < 8; i++ {
+		obj := &failingInitObj{shardTestObj: shardTestObj{id: fmt.Sprintf("group-%v/obj", i)}, fail: i == 5}
+		store.Register(&obj)
+		objs = append(objs, obj)
+	}
+
+	require.ErrorContains(t, store.Init(&InitParams{}), "failed to init group-5/obj")
+	require.ErrorIs(t, store.StopE(), objstore.ErrNotStarted)
+	require.NoError(t, store.CloseE())
+
+	for _, obj := range objs {
+		require.Equal(t, obj.inits > 0 && !obj.fail, obj.closed, obj.id)
+	}
+	require.Equal(t, objstore.ObjectClosed, store.StateOf("provider"))
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("group-5/obj"))
+}
+
+type failingInitObj struct {
+	shardTestObj
+	fail   bool
+	closed bool
+}
+
+func (o *failingInitObj) Init(p *InitParams) error {
+	o.inits++
+	if o.fail {
+		return fmt.Errorf("failed to init %v", o.id)
+	}
+	return nil
+}
+
+func (o *failingInitObj) Stop() {
+	panic(fmt.Sprintf("object %v is not started", o.id))
+}
+
+func (o *failingInitObj) Close() {
+	if o.inits == 0 || o.fail {
+		panic(fmt.Sprintf("object %v is not initialized", o.id))
+	}
+	o.closed = true
+}
+
+func TestShardedStore_Reset(t *testing.T) {
+	t.Parallel()
+
+	store := newShardedTestStore(2)
+
+	var created []*shardTestObj
+	for _, id := range []string{"a/strategy", "b/strategy"} {
+		store.RegisterConstructor(func() SharedObject {
+			obj := &shardTestObj{id: id, deps: []*shardTestObj{{id: id + "/price"}}}
+			created = append(created, obj)
+			return obj
+		})
+	}
+	require.Len(t, created, 2)
+
+	for run := 0; run < 2; run++ {
+		require.NoError(t, store.Init(&InitParams{}))
+		require.NoError(t, store.Start())
+		require.NoError(t, store.StopE())
+		require.NoError(t, store.CloseE())
+		require.NoError(t, store.Reset())
+	}
+
+	require.Len(t, created, 6)
+	for _, obj := range created[:4] {
+		require.Equal(t, 1, obj.inits)
+	}
+	require.Same(t, created[4], store.Get("a/strategy"))
+	require.Same(t, created[5], store.Get("b/strategy"))
+}
+
+func TestShardedStore_Replace(t *testing.T) {
+	t.Parallel()
+
+	const groupsCount = 8
+
+	store := newShardedTestStore(4)
+
+	// Stubs are used by all shards: shared object is replaced in the parent store, and dependencies in each shard.
+	providerStub := &shardTestObj{id: "provider-stub"}
+	feedStub := &shardTestObj{id: "feed-stub"}
+	require.NoError(t, store.Replace("provider", providerStub, false))
+	require.NoError(t, store.Replace("feed", feedStub, false))
+
+	provider := &shardTestObj{id: "provider"}
+	store.RegisterShared(&provider)
+	require.Same(t, providerStub, provider)
+
+	strategies := make([]*shardTestObj, 0, groupsCount)
+	for g := 0; g < groupsCount; g++ {
+		strategy := &shardTestObj{
+			id:   fmt.Sprintf("group-%v/strategy", g),
+			deps: []*shardTestObj{{id: "provider"}, {id: "feed"}},
+		}
+		store.Register(&strategy)
+		strategies = append(strategies, strategy)
+	}
+
+	// Registered objects are replaced only with force, and nothing is replaced without it.
+	strategyStub := &shardTestObj{id: "strategy-stub"}
+	require.EqualError(t, store.Replace("group-0/strategy", strategyStub, false),
+		"object group-0/strategy is already registered, so it can't be replaced without force")
+	require.Same(t, strategies[0], store.Get("group-0/strategy"))
+	require.NoError(t, store.Replace("group-0/strategy", strategyStub, true))
+	require.Same(t, strategyStub, store.Get("group-0/strategy"))
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.ErrorIs(t, store.Replace("feed", &shardTestObj{id: "feed"}, true), objstore.ErrAlreadyInitialized)
+
+	require.Equal(t, 1, providerStub.inits)
+	require.Equal(t, 1, strategyStub.inits)
+	// First strategy has been replaced, so its dependencies are not registered.
+	for _, strategy := range strategies[1:] {
+		require.Same(t, providerStub, strategy.deps[0])
+		require.Same(t, feedStub, strategy.deps[1])
+	}
+	require.Same(t, providerStub, store.Get("provider"))
+	require.Nil(t, store.Parent().Get("feed"))
+
+	require.NoError(t, store.Abort())
+}
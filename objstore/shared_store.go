@@ -35,12 +35,113 @@ type SharedRegistry[ObjType any] interface {
 	// Register object to be shared with other users.
 	// Expects pointer to pointer.
 	Register(obj interface{})
+
+	// RegisterE is same as Register, but returns error instead of reporting misuse according to error policy.
+	RegisterE(obj interface{}) error
+
+	// RegisterAndSubscribe registers dependency like Register and subscribes subscriber to its updates.
+	// If dependency supports events, returns new event puller of it, otherwise returns nil.
+	RegisterAndSubscribe(depPtr interface{}, subscriber ObjType) (puller interface{})
+
+	// RegisterWith registers dependency like Register and then does what is requested by opts,
+	// e.g. subscribes the registering object to the dependency.
+	RegisterWith(depPtr interface{}, opts RegisterOpts)
+
+	// RegisterGroup registers each of dependencies like Register and returns group of them,
+	// which can be subscribed to as a unit.
+	RegisterGroup(name string, ptrs ...interface{}) *Group[ObjType]
+}
+
+// Register is same as SharedRegistry.Register, but does not use reflection.
+// T is the type of the field holding object, e.g. pointer to the object of concrete type.
+func Register[T any, CustomSharedObject any, InitParams any](s SharedStore[CustomSharedObject, InitParams], obj *T) {
+	if obj == nil {
+		reportStoreFailure(s, fmt.Errorf("Pointer to object pointer must not be nil"))
+		return
+	}
+	if isNil(any(*obj)) {
+		reportStoreFailure(s, fmt.Errorf("Pointer to object must not be nil. Construct a desired object before registering it."))
+		return
+	}
+
+	objAsSharedType, ok := any(*obj).(CustomSharedObject)
+	if !ok {
+		reportStoreFailure(s, fmt.Errorf("object of type %T does not implement shared object interface of the store", *obj))
+		return
+	}
+
+	replica, ok := RegisterObject(s, objAsSharedType, isOfType[T, CustomSharedObject])
+	if !ok {
+		return
+	}
+
+	*obj = any(replica).(T)
+}
+
+// RegisterObject registers object in the store without using reflection and returns shared replica of it,
+// which must be used instead of the object. The store must be GenericStore, e.g. the one passed into RegisterDependencies.
+// Function isSameType must report whether already registered object with same ID can be used as a replica,
+// e.g. whether it has same type. If it is nil, any registered object is accepted.
+// Returns false if registration failed.
+func RegisterObject[CustomSharedObject any, InitParams any](
+	s SharedStore[CustomSharedObject, InitParams],
+	obj CustomSharedObject,
+	isSameType func(registered CustomSharedObject) bool,
+) (replica CustomSharedObject, ok bool) {
+	r, ok := s.(objectRegistry[CustomSharedObject])
+	if !ok {
+		reportStoreFailure(s, fmt.Errorf("store of type %T does not support registration without reflection", s))
+		return obj, false
+	}
+
+	return r.RegisterObject(obj, isSameType)
+}
+
+// objectRegistry is implemented by GenericStore to register objects without reflection.
+type objectRegistry[ObjType any] interface {
+	RegisterObject(obj ObjType, isSameType func(registered ObjType) bool) (replica ObjType, ok bool)
+}
+
+// failureReporter is implemented by stores of this package, which report misuse according to their error policy.
+type failureReporter interface {
+	reportFailure(err error)
+}
+
+func reportStoreFailure(s any, err error) {
+	if r, ok := s.(failureReporter); ok {
+		r.reportFailure(err)
+	} else {
+		utils.Fail(err)
+	}
+}
+
+// isNil reports whether v is nil or holds nil pointer, e.g. typed nil registered through an interface.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+func isOfType[T any, CustomSharedObject any](registered CustomSharedObject) bool {
+	_, ok := any(registered).(T)
+	return ok
 }
 
+// SharedStore is the store as seen by shared objects and by code running its lifecycle.
+// Other methods, e.g. introspection or live registration, are provided by GenericStore.
 type SharedStore[CustomSharedObject any, InitParams any] interface {
 	SharedRegistry[CustomSharedObject]
 
 	// Lifecycle methods. Must be called in order, and only once.
+	// Otherwise Init and Start return errors, e.g. ErrNotInitialized, and Stop and Close report misuse
+	// using error policy of the store. Repeated calls of Stop and Close are ignored.
 
 	// Init must be called first of all lifecycle methods.
 	// It gathers objects requirements and then calls Init() on all objects.
@@ -73,7 +174,7 @@ type SharedStore[CustomSharedObject any, InitParams any] interface {
 	RecentlyRegisteredSharedObjects() []string
 }
 
-func NewStore[CustomSharedObject SharedObject[CustomSharedObject, InitParams], InitParams any](getID func(obj CustomSharedObject) string, l utils.Logger) *GenericStore[CustomSharedObject, string, InitParams] {
+func NewStore[CustomSharedObject SharedObject[CustomSharedObject, InitParams], InitParams any](getID func(obj CustomSharedObject) string, l utils.Logger, opts ...StoreOption) *GenericStore[CustomSharedObject, string, InitParams] {
 	var customObjType = reflect.TypeOf((*CustomSharedObject)(nil)).Elem()
 	var genericObjType = reflect.TypeOf((*SharedObject[CustomSharedObject, InitParams])(nil)).Elem()
 
@@ -87,6 +188,7 @@ func NewStore[CustomSharedObject SharedObject[CustomSharedObject, InitParams], I
 			return id1 < id2
 		},
 		func(obj CustomSharedObject, s *GenericStore[CustomSharedObject, string, InitParams]) {
+			s.registerAutoDependencies(obj)
 			interface{}(obj).(SharedObject[CustomSharedObject, InitParams]).RegisterDependencies(s)
 		},
 		func(obj CustomSharedObject, params InitParams) error {
@@ -102,6 +204,7 @@ func NewStore[CustomSharedObject SharedObject[CustomSharedObject, InitParams], I
 			interface{}(obj).(SharedObject[CustomSharedObject, InitParams]).Close()
 		},
 		l,
+		opts...,
 	)
 }
 
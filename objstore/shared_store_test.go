@@ -1,9 +1,17 @@
 package objstore_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/nnikolash/go-shdep/objstore"
 	"github.com/nnikolash/go-shdep/utils"
@@ -92,16 +100,196 @@ func TestSharedStore_SameNameDifferentType(t *testing.T) {
 	require.True(t, false, "panic expected")
 }
 
+func TestSharedStore_FailureHandler(t *testing.T) {
+	t.Parallel()
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+
+	type SharedObj5Copied struct {
+		SharedObj5
+	}
+	var s5c *SharedObj5Copied = &SharedObj5Copied{}
+	s5c.SharedObj5 = *NewSharedObj5(1, 2.0)
+	s5c.SharedObjectBase = *NewSharedObjectBase("5", s5c.param1, s5c.param2)
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	var failures []error
+	store.SetFailureHandler(func(err error) {
+		failures = append(failures, err)
+	})
+
+	store.Register(&so1)
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+
+	store.Register(&s5c)
+	require.Len(t, failures, 1)
+	require.Contains(t, failures[0].Error(), "is already registered and has different type")
+
+	var nilObj *SharedObj5
+	store.Register(&nilObj)
+	require.Len(t, failures, 2)
+}
+
+func TestSharedStore_LenientErrorPolicy(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil, objstore.WithErrorPolicy(utils.PolicyLenient))
+	store.SetFailureHandler(func(err error) {
+		t.Fatalf("failure handler must not be called: %v", err)
+	})
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	store.Register(&so1)
+
+	var nilObj *SharedObj5
+	require.NotPanics(t, func() { store.Register(&nilObj) })
+	require.NotPanics(t, func() { store.Register(so1) })
+	require.Len(t, store.Misuses(), 2)
+
+	err := store.Init(&InitParams{InitParam: 1})
+	require.ErrorContains(t, err, "shared objects store has been misused: Pointer to object must not be nil")
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf(so1.ID()))
+	require.NoError(t, store.CloseE())
+}
+
+func TestSharedStore_RegisterE(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	require.NoError(t, store.RegisterE(&so1))
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+
+	type SharedObj5Copied struct {
+		SharedObj5
+	}
+	s5c := &SharedObj5Copied{SharedObj5: *NewSharedObj5(1, 2.0)}
+	s5c.SharedObjectBase = *NewSharedObjectBase("5", s5c.param1, s5c.param2)
+
+	var err error
+	require.NotPanics(t, func() { err = store.RegisterE(&s5c) })
+	require.ErrorContains(t, err, "is already registered and has different type")
+
+	var nilObj *SharedObj5
+	require.EqualError(t, store.RegisterE(&nilObj), "Pointer to object must not be nil. Construct a desired object before registering it.")
+	require.ErrorContains(t, store.RegisterE(so1), "accepts only pointers to pointers")
+	require.Empty(t, store.Misuses())
+
+	// Failures of Register are still reported as usual.
+	require.Panics(t, func() { store.Register(&nilObj) })
+}
+
+func TestSharedStore_TypeMismatchDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type SharedObj5Copied struct {
+		SharedObj5
+	}
+	s5 := NewSharedObj5(1, 2.0)
+	s5c := &SharedObj5Copied{SharedObj5: *NewSharedObj5(1, 2.0)}
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil, objstore.WithRegistrationStacks())
+
+	var failures []error
+	store.SetFailureHandler(func(err error) {
+		failures = append(failures, err)
+	})
+
+	store.Register(&s5)
+	store.Register(&s5c)
+	require.Len(t, failures, 1)
+
+	msg := failures[0].Error()
+	require.Contains(t, msg, "of type *objstore_test.SharedObj5Copied (params: [1 2]) is already registered and has different type: *objstore_test.SharedObj5 (params: [1 2])")
+	require.Contains(t, msg, "First registered at:")
+	require.Contains(t, msg, "TestSharedStore_TypeMismatchDiagnostics")
+}
+
+func TestSharedStore_RegisterWithoutReflection(t *testing.T) {
+	t.Parallel()
+
+	var store objstore.SharedStore[SharedObject, *InitParams] = objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	s5a := NewSharedObj5(1, 2.0)
+	s5b := NewSharedObj5(1, 2.0)
+	require.NotSame(t, s5a, s5b)
+
+	objstore.Register(store, &s5a)
+	objstore.Register(store, &s5b)
+	require.Same(t, s5a, s5b)
+
+	type SharedObj5Copied struct {
+		SharedObj5
+	}
+	var s5c *SharedObj5Copied = &SharedObj5Copied{}
+	s5c.SharedObj5 = *NewSharedObj5(1, 2.0)
+	s5c.SharedObjectBase = *NewSharedObjectBase("5", s5c.param1, s5c.param2)
+
+	require.PanicsWithError(t, fmt.Sprintf("Object with id %v of type *objstore_test.SharedObj5Copied (params: [1 2]) is already registered and has different type: *objstore_test.SharedObj5 (params: [1 2])", s5a.ID()), func() {
+		objstore.Register(store, &s5c)
+	})
+
+	var nilObj *SharedObj5
+	require.PanicsWithError(t, "Pointer to object must not be nil. Construct a desired object before registering it.", func() {
+		objstore.Register(store, &nilObj)
+	})
+	require.PanicsWithError(t, "Pointer to object pointer must not be nil", func() {
+		objstore.Register[*SharedObj5](store, nil)
+	})
+
+	var nilIface SharedObject = nilObj
+	require.PanicsWithError(t, "Pointer to object must not be nil. Construct a desired object before registering it.", func() {
+		objstore.Register(store, &nilIface)
+	})
+
+	var so1 SharedObject = NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	replica, ok := objstore.RegisterObject(store, so1, nil)
+	require.True(t, ok)
+	require.Same(t, so1, replica)
+}
+
+func TestSharedStore_ProfilerLabels(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+	store.SetProfilerLabels(true)
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	store.Register(&so1)
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+	require.NoError(t, store.Start())
+	store.Stop()
+	store.Close()
+
+	so1.Verify(t)
+}
+
 func NewSharedObjectBase(name string, params ...interface{}) *SharedObjectBase {
 	hash := utils.Must2(utils.Hash(params...))
 
 	return &SharedObjectBase{
-		id: fmt.Sprintf("%v-%v", name, hash),
+		id:     fmt.Sprintf("%v-%v", name, hash),
+		params: fmt.Sprintf("%v", params),
 	}
 }
 
 type SharedObjectBase struct {
 	id          string
+	params      string
 	initialized bool
 	started     bool
 	stopped     bool
@@ -112,6 +300,10 @@ func (so *SharedObjectBase) ID() string {
 	return so.id
 }
 
+func (so *SharedObjectBase) DescribeParams() string {
+	return so.params
+}
+
 func (so *SharedObjectBase) Init(p *InitParams) error {
 	if so.initialized {
 		return fmt.Errorf("object %v is already initialized", so.id)
@@ -172,6 +364,12 @@ func (so *SharedObjectBase) Verify(t *testing.T) {
 
 type InitParams struct {
 	InitParam int
+	// Configurations of objects by their IDs.
+	Configs map[string]interface{}
+}
+
+func (p *InitParams) ConfigFor(objID string) interface{} {
+	return p.Configs[objID]
 }
 
 func NewSharedObj1(param1 []string, param2 string, param3 bool, param4 int, param5 float32) *SharedObj1 {
@@ -270,3 +468,1141 @@ type SharedObj5 struct {
 
 func (so *SharedObj5) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
 }
+
+func TestSharedStore_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	store.Register(&so1)
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+	require.NoError(t, store.Start())
+
+	var buf bytes.Buffer
+	require.NoError(t, store.WriteSnapshot(&buf))
+
+	snapshot, err := objstore.ReadSnapshot(&buf)
+	require.NoError(t, err)
+	require.Equal(t, objstore.SnapshotFormatVersion, snapshot.FormatVersion)
+
+	desc := store.Describe()
+	require.Len(t, snapshot.Objects, len(desc.Objects))
+	for i, obj := range snapshot.Objects {
+		require.Equal(t, desc.Objects[i].ID, obj.ID)
+		require.Equal(t, desc.Objects[i].Type, obj.Type)
+		require.Equal(t, objstore.ObjectStarted, obj.State)
+		require.Equal(t, desc.Objects[i].Dependencies, obj.Dependencies)
+	}
+
+	_, err = objstore.ReadSnapshot(strings.NewReader(`{"formatVersion": 100}`))
+	require.Error(t, err)
+}
+
+// lockRecorder is a lock, which records whether it is held.
+type lockRecorder struct {
+	sync.Mutex
+	held bool
+}
+
+func (l *lockRecorder) Lock()   { l.Mutex.Lock(); l.held = true }
+func (l *lockRecorder) Unlock() { l.held = false; l.Mutex.Unlock() }
+
+type describerFunc func() objstore.StoreDescription
+
+func (f describerFunc) Describe() objstore.StoreDescription { return f() }
+
+func TestSharedStore_DescribeLocked(t *testing.T) {
+	t.Parallel()
+
+	var lock lockRecorder
+	describer := describerFunc(func() objstore.StoreDescription {
+		require.True(t, lock.held)
+		return objstore.StoreDescription{Objects: []objstore.ObjectDescription{{ID: "a"}}}
+	})
+
+	desc := objstore.DescribeLocked(describer, &lock)
+	require.Equal(t, "a", desc.Objects[0].ID)
+	require.False(t, lock.held)
+
+	// Without lock the store is read directly.
+	called := false
+	objstore.ReadLocked(nil, func() { called = true })
+	require.True(t, called)
+}
+
+func TestSharedStore_ObjectLogPrefix(t *testing.T) {
+	t.Parallel()
+
+	// Logger without structured logging, so type and ID of objects are written as prefix of messages.
+	var buf bytes.Buffer
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, struct{ utils.Logger }{utils.NewStdLoggerTo(&buf, utils.LevelDebug)})
+
+	obj := &shardTestObj{id: "a", deps: []*shardTestObj{{id: "b"}}}
+	store.Register(&obj)
+	require.NoError(t, store.Init(&InitParams{}))
+
+	var initLines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if _, msg, ok := strings.Cut(line, "DEBUG "); ok && strings.HasSuffix(msg, "Initializing object") {
+			initLines = append(initLines, msg)
+		}
+	}
+	require.Equal(t, []string{
+		"*objstore_test.shardTestObj/b: Initializing object",
+		"*objstore_test.shardTestObj/a: Initializing object",
+	}, initLines)
+}
+
+func TestSharedStore_LifecycleOrder(t *testing.T) {
+	t.Parallel()
+
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil)
+
+		so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+		store.Register(&so1)
+
+		return store
+	}
+
+	store := newStore()
+	require.ErrorIs(t, store.Start(), objstore.ErrNotInitialized)
+	require.ErrorIs(t, store.StopE(), objstore.ErrNotStarted)
+	require.ErrorIs(t, store.CloseE(), objstore.ErrNotStopped)
+
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+	require.ErrorIs(t, store.Init(&InitParams{InitParam: 1}), objstore.ErrAlreadyInitialized)
+	require.ErrorIs(t, store.StopE(), objstore.ErrNotStarted)
+
+	require.NoError(t, store.Start())
+	require.ErrorIs(t, store.Start(), objstore.ErrAlreadyStarted)
+	require.ErrorIs(t, store.CloseE(), objstore.ErrNotStopped)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.StopE())
+	require.ErrorIs(t, store.Start(), objstore.ErrAlreadyStopped)
+
+	require.NoError(t, store.CloseE())
+	require.NoError(t, store.CloseE())
+	require.NoError(t, store.StopE())
+	require.ErrorIs(t, store.Init(&InitParams{InitParam: 1}), objstore.ErrAlreadyClosed)
+
+	// Stop and Close report misuse using error policy.
+	store = newStore()
+	require.PanicsWithError(t, objstore.ErrNotStarted.Error(), func() { store.Stop() })
+	require.PanicsWithError(t, objstore.ErrNotStopped.Error(), func() { store.Close() })
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+	require.NoError(t, store.Start())
+	require.NotPanics(t, func() {
+		store.Stop()
+		store.Stop()
+		store.Close()
+	})
+
+	// Failed initialization can't be retried.
+	failingStore := objstore.NewGenericStore[string, string, int](
+		func(obj string) string { return obj },
+		nil,
+		func(obj string, s *objstore.GenericStore[string, string, int]) {},
+		func(obj string, params int) error { return fmt.Errorf("failed to init %v", obj) },
+		nil, nil, nil, nil,
+	)
+	failingStore.RegisterObject("a", nil)
+	require.EqualError(t, failingStore.Init(1), "init failed for a: failed to init a")
+	require.ErrorIs(t, failingStore.Init(1), objstore.ErrAlreadyInitialized)
+	require.ErrorIs(t, failingStore.Start(), objstore.ErrNotInitialized)
+}
+
+func TestSharedStore_StateOf(t *testing.T) {
+	t.Parallel()
+
+	// Each object depends on the previous one: a <- b <- c.
+	deps := map[string]string{"b": "a", "c": "b"}
+	store := objstore.NewGenericStore[string, string, int](
+		func(obj string) string { return obj },
+		nil,
+		func(obj string, s *objstore.GenericStore[string, string, int]) {
+			if dep, ok := deps[obj]; ok {
+				s.RegisterObject(dep, nil)
+			}
+		},
+		func(obj string, params int) error {
+			if obj == "b" {
+				return fmt.Errorf("connection refused")
+			}
+			return nil
+		},
+		nil, nil, nil, nil,
+	)
+	store.RegisterObject("c", nil)
+
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("c"))
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("a"))
+
+	err := store.Init(1)
+	require.EqualError(t, err, "init failed for b: connection refused")
+
+	require.Equal(t, objstore.ObjectInitialized, store.StateOf("a"))
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("b"))
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("c"))
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("d"))
+
+	require.NoError(t, store.ErrorOf("a"))
+	require.EqualError(t, store.ErrorOf("b"), "init of object b failed: connection refused")
+
+	summary := store.StateSummary()
+	require.Equal(t, map[objstore.ObjectState]int{
+		objstore.ObjectRegistered:  1,
+		objstore.ObjectInitialized: 1,
+		objstore.ObjectFailed:      1,
+	}, summary.Counts)
+	require.Len(t, summary.Failures, 1)
+	require.Equal(t, "b", summary.Failures[0].ID)
+	require.Equal(t, objstore.PhaseInit, summary.Failures[0].Phase)
+
+	desc := store.Describe()
+	for _, obj := range desc.Objects {
+		if obj.ID == "b" {
+			require.Equal(t, "init of object b failed: connection refused", obj.Error)
+		}
+	}
+}
+
+func TestSharedStore_Skip(t *testing.T) {
+	t.Parallel()
+
+	// Object "disabled" is skipped in Init, object "lazy" is skipped in Start, "c" depends on both.
+	deps := map[string][]string{"c": {"disabled", "lazy"}}
+	var started, stopped, closed, skipped []string
+
+	store := objstore.NewGenericStore[string, string, int](
+		func(obj string) string { return obj },
+		nil,
+		func(obj string, s *objstore.GenericStore[string, string, int]) {
+			for _, dep := range deps[obj] {
+				s.RegisterObject(dep, nil)
+			}
+		},
+		func(obj string, params int) error {
+			if obj == "disabled" {
+				return fmt.Errorf("disabled by config: %w", objstore.ErrSkip)
+			}
+			return nil
+		},
+		func(obj string, params int) error {
+			if obj == "lazy" {
+				return objstore.ErrSkip
+			}
+			started = append(started, obj)
+			return nil
+		},
+		func(obj string) { stopped = append(stopped, obj) },
+		func(obj string) { closed = append(closed, obj) },
+		nil,
+		objstore.WithSkipHandler(func(obj interface{}) { skipped = append(skipped, obj.(string)) }),
+	)
+	store.RegisterObject("c", nil)
+
+	require.NoError(t, store.Init(1))
+	require.Equal(t, objstore.ObjectSkipped, store.StateOf("disabled"))
+	require.NoError(t, store.ErrorOf("disabled"))
+
+	require.NoError(t, store.Start())
+	require.Equal(t, objstore.ObjectSkipped, store.StateOf("lazy"))
+	require.Equal(t, objstore.ObjectStarted, store.StateOf("c"))
+	require.Equal(t, []string{"c"}, started)
+	require.ElementsMatch(t, []string{"disabled", "lazy"}, skipped)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+	require.Equal(t, []string{"c"}, stopped)
+	require.ElementsMatch(t, []string{"lazy", "c"}, closed)
+	require.Equal(t, objstore.ObjectSkipped, store.StateOf("lazy"))
+	require.Equal(t, 2, store.StateSummary().Counts[objstore.ObjectSkipped])
+}
+
+func TestSharedStore_ReplacementHandlerAndInitValidator(t *testing.T) {
+	t.Parallel()
+
+	var replaced []interface{}
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil,
+		objstore.WithReplacementHandler(func(obj, replica interface{}) {
+			require.NotSame(t, obj, replica)
+			replaced = append(replaced, obj)
+		}),
+		objstore.WithInitValidator(func() error { return fmt.Errorf("validation failed") }),
+	)
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	so1Copy := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	store.Register(&so1)
+	store.Register(&so1)
+	require.Empty(t, replaced)
+
+	local := so1Copy
+	store.Register(&so1Copy)
+	require.Equal(t, []interface{}{local}, replaced)
+
+	require.EqualError(t, store.Init(&InitParams{InitParam: 1}), "validation failed")
+	require.Equal(t, objstore.ObjectInitialized, store.StateOf(so1.ID()))
+}
+
+func TestSharedStore_Dump(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	so4 := NewSharedObj4(1, 2.0)
+	store.Register(&so4)
+	require.Equal(t, "store(objects: 1, phase: created)", store.String())
+
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+	require.Equal(t, "store(objects: 2, phase: initialized)", store.String())
+	require.Equal(t, "store(objects: 2, phase: initialized)\n"+
+		"  "+so4.s5.id+" [initialized]\n"+
+		"  "+so4.id+" [initialized] top-level deps: "+so4.s5.id+"\n", store.Dump())
+
+	require.NoError(t, store.Start())
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+func TestSharedStore_PrintTree(t *testing.T) {
+	t.Parallel()
+
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	so1 := NewSharedObj1([]string{"a", "b"}, "c", true, 1, 2.0)
+	store.Register(&so1)
+	require.NoError(t, store.Init(&InitParams{InitParam: 1}))
+
+	var buf bytes.Buffer
+	require.NoError(t, store.PrintTree(&buf, so1.id))
+	s2, s3, s4, s5 := so1.s2.id, so1.s3.id, so1.s4.id, so1.s4.s5.id
+	require.Equal(t, so1.id+" [initialized]\n"+
+		"├── "+s2+" [initialized]\n"+
+		"│   ├── "+s3+" [initialized]\n"+
+		"│   │   └── "+s5+" [initialized]\n"+
+		"│   └── "+s5+" [initialized] (shared)\n"+
+		"├── "+s3+" [initialized] (shared)\n"+
+		"└── "+s4+" [initialized]\n"+
+		"    └── "+s5+" [initialized] (shared)\n", buf.String())
+	require.Error(t, store.PrintTree(&buf, "unknown"))
+
+	require.NoError(t, store.Start())
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+type configuredObj struct {
+	shardTestObj
+	threshold int
+}
+
+func (o *configuredObj) Configure(cfg interface{}) error {
+	threshold, ok := cfg.(int)
+	if !ok {
+		return fmt.Errorf("unexpected config %v", cfg)
+	}
+
+	o.threshold = threshold
+	return nil
+}
+
+func TestSharedStore_Configure(t *testing.T) {
+	t.Parallel()
+
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil)
+	}
+	params := &InitParams{Configs: map[string]interface{}{"a": 5, "c": "high"}}
+
+	store := newStore()
+	a := &configuredObj{shardTestObj: shardTestObj{id: "a"}}
+	b := &configuredObj{shardTestObj: shardTestObj{id: "b"}, threshold: 1}
+	store.Register(&a)
+	store.Register(&b)
+
+	require.NoError(t, store.Init(params))
+	require.Equal(t, 5, a.threshold)
+	// Objects without configuration are not configured.
+	require.Equal(t, 1, b.threshold)
+
+	require.NoError(t, store.Start())
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	store = newStore()
+	c := &configuredObj{shardTestObj: shardTestObj{id: "c"}}
+	store.Register(&c)
+
+	require.EqualError(t, store.Init(params), "init failed for c: failed to configure object: unexpected config high")
+	require.Equal(t, 0, c.inits)
+	require.NoError(t, store.CloseE())
+}
+
+// liveObj records calls of its lifecycle methods.
+type liveObj struct {
+	id        string
+	deps      []*liveObj
+	calls     *[]string
+	failInit  bool
+	failStart bool
+}
+
+func newLiveObj(calls *[]string, id string, deps ...*liveObj) *liveObj {
+	return &liveObj{id: id, deps: deps, calls: calls}
+}
+
+func (o *liveObj) ID() string {
+	return o.id
+}
+
+func (o *liveObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	for i := range o.deps {
+		s.Register(&o.deps[i])
+	}
+}
+
+func (o *liveObj) Init(p *InitParams) error {
+	*o.calls = append(*o.calls, "init "+o.id)
+	if o.failInit {
+		return fmt.Errorf("no data")
+	}
+	return nil
+}
+
+func (o *liveObj) Start(p *InitParams) error {
+	*o.calls = append(*o.calls, "start "+o.id)
+	if o.failStart {
+		return fmt.Errorf("no connection")
+	}
+	return nil
+}
+
+func (o *liveObj) Stop() {
+	*o.calls = append(*o.calls, "stop "+o.id)
+}
+
+func (o *liveObj) Close() {
+	*o.calls = append(*o.calls, "close "+o.id)
+}
+
+func TestSharedStore_RegisterLive(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil, objstore.WithSkipHandler(func(obj interface{}) {
+		calls = append(calls, "detach "+obj.(*liveObj).id)
+	}))
+
+	s1 := newLiveObj(&calls, "s1", newLiveObj(&calls, "prices"))
+	store.Register(&s1)
+
+	s2 := newLiveObj(&calls, "s2", newLiveObj(&calls, "prices"), newLiveObj(&calls, "ma", newLiveObj(&calls, "prices")))
+	require.ErrorIs(t, store.RegisterLive(&s2), objstore.ErrNotInitialized)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	calls = nil
+
+	// Only new objects are initialized and started, after their dependencies.
+	require.NoError(t, store.RegisterLive(&s2))
+	require.Equal(t, []string{"init ma", "init s2", "start ma", "start s2"}, calls)
+	require.Same(t, s1.deps[0], s2.deps[0])
+	require.Same(t, s1.deps[0], s2.deps[1].deps[0])
+	require.Equal(t, []string{"s1", "s2"}, store.TopLevelDependencies())
+	require.Equal(t, objstore.ObjectStarted, store.StateOf("s2"))
+
+	// New objects are rolled back, if any of them fails.
+	calls = nil
+	broken := newLiveObj(&calls, "broken", newLiveObj(&calls, "rsi"))
+	broken.failStart = true
+	require.EqualError(t, store.RegisterLive(&broken), "start failed for broken: no connection")
+	require.Equal(t, []string{"init rsi", "init broken", "start rsi", "start broken",
+		"close broken", "detach broken", "stop rsi", "close rsi", "detach rsi"}, calls)
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("broken"))
+	require.Equal(t, objstore.ObjectClosed, store.StateOf("rsi"))
+
+	calls = nil
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+	require.Equal(t, []string{"stop s2", "stop ma", "stop s1", "stop prices", "close s2", "close ma", "close s1", "close prices"}, calls)
+}
+
+func TestSharedStore_Unregister(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil, objstore.WithSkipHandler(func(obj interface{}) {
+		calls = append(calls, "detach "+obj.(*liveObj).id)
+	}))
+
+	s1 := newLiveObj(&calls, "s1", newLiveObj(&calls, "prices"))
+	store.Register(&s1)
+	s2 := newLiveObj(&calls, "s2", newLiveObj(&calls, "prices"), newLiveObj(&calls, "ma", newLiveObj(&calls, "prices")))
+	store.Register(&s2)
+	sameS2 := newLiveObj(&calls, "s2")
+	store.Register(&sameS2)
+	unused := newLiveObj(&calls, "unused")
+	store.Register(&unused)
+
+	// Before Init the object is just forgotten.
+	store.Unregister(&unused)
+	require.Nil(t, unused)
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("unused"))
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	calls = nil
+
+	// Object is removed only when the last registration is undone.
+	store.Unregister(&sameS2)
+	require.Nil(t, sameS2)
+	require.Empty(t, calls)
+
+	// Providers needed by other objects are kept.
+	store.Unregister(&s2)
+	require.Equal(t, []string{"stop s2", "stop ma", "close s2", "detach s2", "close ma", "detach ma"}, calls)
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("ma"))
+	require.Equal(t, objstore.ObjectStarted, store.StateOf("prices"))
+	require.Equal(t, []string{"s1"}, store.TopLevelDependencies())
+
+	again := newLiveObj(&calls, "s2")
+	require.Panics(t, func() { store.Unregister(&again) })
+
+	calls = nil
+	store.Unregister(&s1)
+	require.Equal(t, []string{"stop s1", "stop prices", "close s1", "detach s1", "close prices", "detach prices"}, calls)
+	require.Empty(t, store.Describe().Objects)
+
+	calls = nil
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+	require.Empty(t, calls)
+}
+
+type ctxKey struct{}
+
+// ctxObj records values of contexts passed into its context-aware lifecycle methods.
+type ctxObj struct {
+	liveObj
+	onInit func()
+}
+
+func (o *ctxObj) InitContext(ctx context.Context, p *InitParams) error {
+	*o.calls = append(*o.calls, fmt.Sprintf("init %v %v", o.id, ctx.Value(ctxKey{})))
+	if o.onInit != nil {
+		o.onInit()
+	}
+	return nil
+}
+
+func (o *ctxObj) StartContext(ctx context.Context, p *InitParams) error {
+	*o.calls = append(*o.calls, fmt.Sprintf("start %v %v", o.id, ctx.Value(ctxKey{})))
+	return nil
+}
+
+func (o *ctxObj) StopContext(ctx context.Context) {
+	*o.calls = append(*o.calls, fmt.Sprintf("stop %v %v", o.id, ctx.Value(ctxKey{})))
+}
+
+func TestSharedStore_LifecycleContext(t *testing.T) {
+	t.Parallel()
+
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil)
+	}
+
+	var calls []string
+	store := newStore()
+	loader := &ctxObj{liveObj: liveObj{id: "loader", calls: &calls}}
+	store.Register(&loader)
+	plain := newLiveObj(&calls, "cache")
+	store.Register(&plain)
+
+	require.NoError(t, store.InitContext(context.WithValue(context.Background(), ctxKey{}, "init"), &InitParams{}))
+	require.NoError(t, store.StartContext(context.WithValue(context.Background(), ctxKey{}, "start")))
+	require.NoError(t, store.StopContext(context.WithValue(context.Background(), ctxKey{}, "stop")))
+	require.NoError(t, store.CloseE())
+	require.Equal(t, []string{"init loader init", "init cache", "start loader start", "start cache",
+		"stop cache", "stop loader stop", "close cache", "close loader"}, calls)
+
+	// Initialization stops, when context is cancelled.
+	calls = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	store = newStore()
+	loader = &ctxObj{liveObj: liveObj{id: "loader", calls: &calls}, onInit: cancel}
+	store.Register(&loader)
+	store.Register(&plain)
+
+	err := store.InitContext(ctx, &InitParams{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualError(t, err, "initialization is cancelled before object cache: context canceled")
+	require.Equal(t, objstore.ObjectInitialized, store.StateOf("loader"))
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("cache"))
+	require.NoError(t, store.CloseE())
+	require.Equal(t, []string{"init loader <nil>", "close loader"}, calls)
+}
+
+// parallelObj checks, that its dependencies are initialized before it, and waits for other objects to run concurrently.
+type parallelObj struct {
+	id   string
+	deps []*parallelObj
+	// Number of objects, which must enter Init before this object returns from it, including it.
+	together    int32
+	entered     *atomic.Int32
+	fail        bool
+	waitCancel  bool
+	called      atomic.Bool
+	initialized atomic.Bool
+}
+
+func (o *parallelObj) ID() string {
+	return o.id
+}
+
+func (o *parallelObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	for i := range o.deps {
+		s.Register(&o.deps[i])
+	}
+}
+
+func (o *parallelObj) Init(p *InitParams) error {
+	return o.InitContext(context.Background(), p)
+}
+
+func (o *parallelObj) InitContext(ctx context.Context, p *InitParams) error {
+	o.called.Store(true)
+	o.entered.Add(1)
+
+	for _, dep := range o.deps {
+		if !dep.initialized.Load() {
+			return fmt.Errorf("dependency %v is not initialized", dep.id)
+		}
+	}
+
+	if o.fail {
+		return fmt.Errorf("no data")
+	}
+	if o.waitCancel {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); o.entered.Load() < o.together; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("objects have not been initialized concurrently")
+		}
+	}
+
+	o.initialized.Store(true)
+	return nil
+}
+
+func (o *parallelObj) Start(p *InitParams) error {
+	return nil
+}
+
+func (o *parallelObj) Stop() {}
+
+func (o *parallelObj) Close() {}
+
+func TestSharedStore_ParallelLifecycle(t *testing.T) {
+	t.Parallel()
+
+	newStore := func(workers int) *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil, objstore.WithParallelLifecycle(workers))
+	}
+
+	// Objects of the same level are initialized concurrently after their dependencies.
+	var entered atomic.Int32
+	base := &parallelObj{id: "base", entered: &entered}
+	var middle []*parallelObj
+	for _, id := range []string{"a", "b", "c"} {
+		middle = append(middle, &parallelObj{id: id, deps: []*parallelObj{base}, together: 3, entered: &entered})
+	}
+	top := &parallelObj{id: "top", deps: middle, entered: &entered}
+
+	store := newStore(3)
+	store.Register(&top)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	for _, obj := range append(middle, base, top) {
+		require.Equal(t, objstore.ObjectStarted, store.StateOf(obj.id))
+	}
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	// The first error cancels the rest.
+	failing := &parallelObj{id: "failing", fail: true, entered: &entered}
+	waiting := &parallelObj{id: "waiting", waitCancel: true, entered: &entered}
+	late := &parallelObj{id: "late", entered: &entered}
+	// Dependencies are initialized in reverse order, so late is called last.
+	top = &parallelObj{id: "top", deps: []*parallelObj{late, waiting, failing}, entered: &entered}
+
+	store = newStore(2)
+	store.Register(&top)
+	require.EqualError(t, store.Init(&InitParams{}), "init failed for failing: no data")
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("failing"))
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("waiting"))
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("late"))
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("top"))
+	require.False(t, late.called.Load())
+	require.False(t, top.called.Load())
+	require.NoError(t, store.CloseE())
+}
+
+func TestSharedStore_Abort(t *testing.T) {
+	t.Parallel()
+
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil)
+	}
+
+	// Objects initialized before failed one are closed.
+	var calls []string
+	provider := newLiveObj(&calls, "provider")
+	indicator := newLiveObj(&calls, "indicator", provider)
+	strategy := newLiveObj(&calls, "strategy", indicator)
+	strategy.failInit = true
+
+	store := newStore()
+	store.Register(&strategy)
+	require.EqualError(t, store.Init(&InitParams{}), "init failed for strategy: no data")
+	require.NoError(t, store.Abort())
+	require.NoError(t, store.Abort())
+	require.Equal(t, []string{"init provider", "init indicator", "init strategy", "close indicator", "close provider"}, calls)
+	require.Equal(t, objstore.ObjectClosed, store.StateOf("provider"))
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("strategy"))
+	require.ErrorIs(t, store.Start(), objstore.ErrAlreadyClosed)
+
+	// Started objects are stopped first.
+	calls = nil
+	strategy.failInit = false
+	store = newStore()
+	store.Register(&strategy)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	calls = nil
+	require.NoError(t, store.Abort())
+	require.Equal(t, []string{"stop strategy", "stop indicator", "stop provider",
+		"close strategy", "close indicator", "close provider"}, calls)
+
+	// Initialized objects are only closed.
+	calls = nil
+	store = newStore()
+	store.Register(&strategy)
+	require.NoError(t, store.Init(&InitParams{}))
+	calls = nil
+	require.NoError(t, store.Abort())
+	require.Equal(t, []string{"close strategy", "close indicator", "close provider"}, calls)
+
+	// Store, which has not been initialized, has nothing to abort.
+	calls = nil
+	store = newStore()
+	store.Register(&strategy)
+	require.NoError(t, store.Abort())
+	require.ErrorIs(t, store.Init(&InitParams{}), objstore.ErrAlreadyClosed)
+	require.Empty(t, calls)
+}
+
+// blockingObj blocks in Start until released, and in InitContext until context is done, if waitInit is set.
+type blockingObj struct {
+	liveObj
+	waitInit bool
+	release  chan struct{}
+}
+
+func (o *blockingObj) InitContext(ctx context.Context, p *InitParams) error {
+	if o.waitInit {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return o.Init(p)
+}
+
+func (o *blockingObj) Start(p *InitParams) error {
+	<-o.release
+	return o.liveObj.Start(p)
+}
+
+func TestSharedStore_PhaseTimeout(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	var timedOut []string
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil,
+			objstore.WithPhaseTimeout(objstore.PhaseInit, 20*time.Millisecond),
+			objstore.WithPhaseTimeout(objstore.PhaseStart, 20*time.Millisecond),
+			objstore.WithTimeoutHandler(func(phase objstore.LifecyclePhase, id string, obj interface{}) {
+				timedOut = append(timedOut, fmt.Sprintf("%v %v", phase, id))
+				if b, ok := obj.(*blockingObj); ok && phase == objstore.PhaseStart {
+					close(b.release)
+				}
+			}))
+	}
+
+	// Start succeeding after timeout fails the store.
+	fast := newLiveObj(&calls, "fast")
+	feed := &blockingObj{liveObj: liveObj{id: "feed", deps: []*liveObj{fast}, calls: &calls}, release: make(chan struct{})}
+	store := newStore()
+	store.Register(&feed)
+	require.NoError(t, store.Init(&InitParams{}))
+	err := store.Start()
+	require.ErrorIs(t, err, objstore.ErrTimeout)
+	require.ErrorContains(t, err, "start failed for feed: start has taken")
+	require.Equal(t, []string{"start feed"}, timedOut)
+	require.Equal(t, objstore.ObjectStarted, store.StateOf("fast"))
+	require.Equal(t, objstore.ObjectFailed, store.StateOf("feed"))
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	// Context of context-aware object has deadline of the timeout.
+	timedOut = nil
+	feed = &blockingObj{liveObj: liveObj{id: "feed", calls: &calls}, waitInit: true}
+	store = newStore()
+	store.Register(&feed)
+	err = store.Init(&InitParams{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, []string{"init feed"}, timedOut)
+	require.NoError(t, store.CloseE())
+}
+
+func TestSharedStore_Reset(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	var strategies []*liveObj
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+	store.RegisterConstructor(func() SharedObject {
+		provider := newLiveObj(&calls, "provider")
+		strategy := newLiveObj(&calls, "strategy", provider)
+		strategies = append(strategies, strategy)
+		return strategy
+	})
+
+	run := func() {
+		require.NoError(t, store.Init(&InitParams{}))
+		require.NoError(t, store.Start())
+		require.NoError(t, store.StopE())
+		require.NoError(t, store.CloseE())
+	}
+
+	run()
+	require.ErrorIs(t, store.Init(&InitParams{}), objstore.ErrAlreadyClosed)
+	require.NoError(t, store.Reset())
+	require.Equal(t, objstore.ObjectRegistered, store.StateOf("strategy"))
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("provider"))
+	run()
+
+	require.Len(t, strategies, 2)
+	require.NotSame(t, strategies[0], strategies[1])
+	require.Same(t, strategies[1], store.Get("strategy"))
+	require.Equal(t, 2, strings.Count(strings.Join(calls, ","), "init provider"))
+
+	// Store must be closed before reset.
+	require.NoError(t, store.Reset())
+	require.NoError(t, store.Init(&InitParams{}))
+	require.ErrorIs(t, store.Reset(), objstore.ErrNotClosed)
+	require.NoError(t, store.Abort())
+
+	// Objects registered without constructor can't be created again.
+	require.NoError(t, store.Reset())
+	other := newLiveObj(&calls, "other")
+	store.Register(&other)
+	require.EqualError(t, store.Reset(), "object other is registered without constructor, so it can't be created again")
+}
+
+// pausableObj records calls of Pause and Resume.
+type pausableObj struct {
+	liveObj
+	pausableDeps []*pausableObj
+}
+
+func (o *pausableObj) RegisterDependencies(s objstore.SharedStore[SharedObject, *InitParams]) {
+	o.liveObj.RegisterDependencies(s)
+	for i := range o.pausableDeps {
+		s.Register(&o.pausableDeps[i])
+	}
+}
+
+func (o *pausableObj) Pause() {
+	*o.calls = append(*o.calls, "pause "+o.id)
+}
+
+func (o *pausableObj) Resume() {
+	*o.calls = append(*o.calls, "resume "+o.id)
+}
+
+func TestSharedStore_PauseResume(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	feed := &pausableObj{liveObj: liveObj{id: "feed", calls: &calls}}
+	indicator := newLiveObj(&calls, "indicator")
+	strategy := &pausableObj{liveObj: liveObj{id: "strategy", deps: []*liveObj{indicator}, calls: &calls}, pausableDeps: []*pausableObj{feed}}
+	store.Register(&strategy)
+
+	require.ErrorIs(t, store.Pause(), objstore.ErrNotStarted)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.ErrorIs(t, store.Pause(), objstore.ErrNotStarted)
+	require.NoError(t, store.Start())
+	require.ErrorIs(t, store.Resume(), objstore.ErrNotPaused)
+
+	calls = nil
+	require.NoError(t, store.Pause())
+	require.True(t, store.Paused())
+	require.ErrorIs(t, store.Pause(), objstore.ErrAlreadyPaused)
+	require.NoError(t, store.Resume())
+	require.False(t, store.Paused())
+	require.Equal(t, []string{"pause strategy", "pause feed", "resume feed", "resume strategy"}, calls)
+
+	// Paused store can be stopped without resuming.
+	calls = nil
+	require.NoError(t, store.Pause())
+	require.NoError(t, store.StopE())
+	require.False(t, store.Paused())
+	require.ErrorIs(t, store.Resume(), objstore.ErrAlreadyStopped)
+	require.NoError(t, store.CloseE())
+	require.Equal(t, []string{"pause strategy", "pause feed", "stop strategy", "stop indicator", "stop feed",
+		"close strategy", "close indicator", "close feed"}, calls)
+}
+
+func TestSharedStore_Graph(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	price := newLiveObj(&calls, `price "BTC"`)
+	strategy := newLiveObj(&calls, "strategy", newLiveObj(&calls, "ma", price), price)
+	store.Register(&strategy)
+
+	require.Equal(t, objstore.ObjectGraph{
+		Nodes: []objstore.GraphNode{{ID: "strategy", Type: "*objstore_test.liveObj", State: objstore.ObjectRegistered, InitOrder: -1}},
+	}, store.Graph())
+
+	require.NoError(t, store.Init(&InitParams{}))
+
+	graph := store.Graph()
+	require.Equal(t, []objstore.GraphNode{
+		{ID: `price "BTC"`, Type: "*objstore_test.liveObj", State: objstore.ObjectInitialized, RegistrationOrder: 2, InitOrder: 0},
+		{ID: "ma", Type: "*objstore_test.liveObj", State: objstore.ObjectInitialized, RegistrationOrder: 1, InitOrder: 1},
+		{ID: "strategy", Type: "*objstore_test.liveObj", State: objstore.ObjectInitialized, RegistrationOrder: 0, InitOrder: 2},
+	}, graph.Nodes)
+	require.ElementsMatch(t, []objstore.GraphEdge{
+		{From: "ma", To: `price "BTC"`},
+		{From: "strategy", To: "ma"},
+		{From: "strategy", To: `price "BTC"`},
+	}, graph.Edges)
+
+	data, err := json.Marshal(graph.Nodes[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"price \"BTC\"","type":"*objstore_test.liveObj","state":"initialized","registrationOrder":2,"initOrder":0}`, string(data))
+
+	require.Equal(t, `flowchart TD
+  n0["price #quot;BTC#quot;<br>initialized"]
+  n1["ma<br>initialized"]
+  n2["strategy<br>initialized"]
+  n1 --> n0
+  n2 --> n1
+  n2 --> n0
+`, graph.Mermaid())
+
+	require.NoError(t, store.Abort())
+}
+
+func TestSharedStore_DependenciesOf(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	price := newLiveObj(&calls, "price")
+	volume := newLiveObj(&calls, "volume")
+	ma := newLiveObj(&calls, "ma", price)
+	strategy := newLiveObj(&calls, "strategy", ma, volume)
+	report := newLiveObj(&calls, "report", price)
+	store.Register(&strategy)
+	store.Register(&report)
+
+	require.Nil(t, store.DependenciesOf("strategy", true))
+	require.NoError(t, store.Init(&InitParams{}))
+
+	require.ElementsMatch(t, []string{"ma", "volume"}, store.DependenciesOf("strategy", false))
+	require.ElementsMatch(t, []string{"ma", "volume", "price"}, store.DependenciesOf("strategy", true))
+	require.Empty(t, store.DependenciesOf("price", true))
+	require.ElementsMatch(t, []string{"ma", "report"}, store.DependentsOf("price", false))
+	require.ElementsMatch(t, []string{"ma", "report", "strategy"}, store.DependentsOf("price", true))
+	require.Empty(t, store.DependentsOf("strategy", true))
+	require.Empty(t, store.DependentsOf("unknown", true))
+
+	// Transitive neighbours are in initialization order.
+	deps := store.DependenciesOf("strategy", true)
+	require.Less(t, slices.Index(deps, "price"), slices.Index(deps, "ma"))
+
+	require.NoError(t, store.Abort())
+}
+
+func TestSharedStore_GetAs(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	provider := newLiveObj(&calls, "provider")
+	strategy := newLiveObj(&calls, "strategy", provider)
+	store.Register(&strategy)
+	require.NoError(t, store.Init(&InitParams{}))
+
+	var shared objstore.SharedStore[SharedObject, *InitParams] = store
+	found, ok := objstore.GetAs[*liveObj](shared, "provider")
+	require.True(t, ok)
+	require.Same(t, provider, found)
+
+	_, ok = objstore.GetAs[*parallelObj](store, "provider")
+	require.False(t, ok)
+	_, ok = objstore.GetAs[*liveObj](store, "unknown")
+	require.False(t, ok)
+
+	// Object constructed with same parameters is resolved into registered one.
+	example := newLiveObj(&calls, "provider")
+	require.True(t, store.GetByExample(&example))
+	require.Same(t, provider, example)
+
+	other := &parallelObj{id: "provider"}
+	require.False(t, store.GetByExample(&other))
+	require.Equal(t, "provider", other.id)
+
+	missing := newLiveObj(&calls, "missing")
+	kept := missing
+	require.False(t, store.GetByExample(&missing))
+	require.Same(t, kept, missing)
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("missing"))
+
+	require.Panics(t, func() { store.GetByExample(provider) })
+	require.NoError(t, store.Abort())
+}
+
+func TestSharedStore_ForEach(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	store := objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+
+	price := newLiveObj(&calls, "price")
+	feed := &pausableObj{liveObj: liveObj{id: "feed", calls: &calls}}
+	strategy := &pausableObj{liveObj: liveObj{id: "strategy", deps: []*liveObj{price}, calls: &calls}, pausableDeps: []*pausableObj{feed}}
+	store.Register(&strategy)
+
+	var ids []string
+	store.ForEach(func(objID string, obj SharedObject) {
+		ids = append(ids, objID)
+	})
+	require.Equal(t, []string{"strategy"}, ids)
+
+	require.NoError(t, store.Init(&InitParams{}))
+
+	ids = nil
+	store.ForEach(func(objID string, obj SharedObject) {
+		require.Same(t, store.Get(objID), obj)
+		ids = append(ids, objID)
+	})
+	require.ElementsMatch(t, []string{"price", "feed", "strategy"}, ids)
+	require.Equal(t, "strategy", ids[2])
+
+	require.ElementsMatch(t, []*pausableObj{feed, strategy}, objstore.AllOfType[*pausableObj](store))
+	require.Equal(t, []*liveObj{price}, objstore.AllOfType[*liveObj](store))
+	require.Empty(t, objstore.AllOfType[*parallelObj](store))
+	require.Len(t, objstore.AllOfType[objstore.Pausable](store), 2)
+
+	require.NoError(t, store.Abort())
+}
+
+func TestSharedStore_Replace(t *testing.T) {
+	t.Parallel()
+
+	newStore := func() *objstore.GenericStore[SharedObject, string, *InitParams] {
+		return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+			return obj.ID()
+		}, nil)
+	}
+
+	// Dependency registered during Init is replaced by stub.
+	var calls []string
+	price := newLiveObj(&calls, "price")
+	strategy := newLiveObj(&calls, "strategy", price)
+	stub := newLiveObj(&calls, "stub")
+
+	store := newStore()
+	require.NoError(t, store.Replace("price", stub, false))
+	store.Register(&strategy)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.Same(t, stub, strategy.deps[0])
+	require.Same(t, stub, store.Get("price"))
+	require.Equal(t, []string{"init stub", "init strategy"}, calls)
+	require.ErrorIs(t, store.Replace("price", price, true), objstore.ErrAlreadyInitialized)
+	require.NoError(t, store.Abort())
+
+	// Registered object is replaced only with force.
+	calls = nil
+	strategy = newLiveObj(&calls, "strategy", price)
+	store = newStore()
+	store.Register(&strategy)
+	strategyStub := newLiveObj(&calls, "strategy-stub")
+	require.EqualError(t, store.Replace("strategy", strategyStub, false), "object strategy is already registered, so it can't be replaced without force")
+	require.NoError(t, store.Replace("strategy", strategyStub, true))
+	require.NoError(t, store.Init(&InitParams{}))
+	require.Equal(t, []string{"init strategy-stub"}, calls)
+	require.NoError(t, store.Abort())
+
+	// Stub must have type of the original object.
+	store = newStore()
+	require.NoError(t, store.Replace("strategy", &parallelObj{id: "strategy"}, false))
+	strategy = newLiveObj(&calls, "strategy")
+	require.Panics(t, func() { store.Register(&strategy) })
+	require.Equal(t, objstore.ObjectUnknown, store.StateOf("strategy"))
+}
@@ -0,0 +1,64 @@
+package objstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SnapshotFormatVersion is a version of snapshot format written by WriteSnapshot.
+const SnapshotFormatVersion = 1
+
+// Snapshot is a serializable description of the store, which can be analyzed offline, e.g. using cmd/shdepdump.
+type Snapshot struct {
+	FormatVersion int       `json:"formatVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	StoreDescription
+}
+
+// WriteSnapshot writes description of the store to w as JSON snapshot.
+// Store is not thread-safe, so it must not be updated concurrently.
+func WriteSnapshot(w io.Writer, store Describer) error {
+	snapshot := Snapshot{
+		FormatVersion:    SnapshotFormatVersion,
+		CreatedAt:        time.Now(),
+		StoreDescription: store.Describe(),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(snapshot); err != nil {
+		return errors.Wrapf(err, "failed to write snapshot")
+	}
+
+	return nil
+}
+
+// ReadSnapshot reads snapshot written by WriteSnapshot.
+// Debug info of objects is read as generic JSON values.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return Snapshot{}, errors.Wrapf(err, "failed to read snapshot")
+	}
+
+	if snapshot.FormatVersion != SnapshotFormatVersion {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot format version: %v", snapshot.FormatVersion)
+	}
+
+	return snapshot, nil
+}
+
+// WriteSnapshot writes description of the store to w as JSON snapshot.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) WriteSnapshot(w io.Writer) error {
+	return WriteSnapshot(w, s)
+}
+
+// WriteSnapshot writes description of parent store and all shards to w as JSON snapshot.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) WriteSnapshot(w io.Writer) error {
+	return WriteSnapshot(w, s)
+}
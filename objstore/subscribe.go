@@ -0,0 +1,103 @@
+package objstore
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterAndSubscribe registers dependency like Register and subscribes subscriber to its updates
+// by calling method SubscribeObj of the dependency, e.g. provided by shdep.SharedObjectBase.
+// If dependency has method NewEventPuller, returns new puller of the dependency, otherwise returns nil.
+// Events must be pulled from returned puller, or it will cause memory leak.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterAndSubscribe(depPtr interface{}, subscriber SharedObject) (puller interface{}) {
+	s.Register(depPtr)
+
+	puller, err := subscribeRegistered(depPtr, subscriber, true)
+	if err != nil {
+		s.reportFailure(err)
+	}
+
+	return puller
+}
+
+// RegisterAndSubscribe is same as Register, but also subscribes subscriber to updates of the dependency.
+// See GenericStore.RegisterAndSubscribe for details.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterAndSubscribe(depPtr interface{}, subscriber SharedObject) (puller interface{}) {
+	return s.shardFor(depPtr).RegisterAndSubscribe(depPtr, subscriber)
+}
+
+// RegisterOpts configures registration made by RegisterWith.
+type RegisterOpts struct {
+	// Subscribe object, which registers the dependency from its RegisterDependencies, to updates of the dependency.
+	// Dependency is subscribed to using its method SubscribeObj, e.g. provided by shdep.SharedObjectBase.
+	// Dependencies without such method are only registered.
+	Subscribe bool
+}
+
+// RegisterWith registers dependency like Register and then does what is requested by opts.
+// Subscription requires registration to be made from RegisterDependencies.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) RegisterWith(depPtr interface{}, opts RegisterOpts) {
+	s.Register(depPtr)
+
+	if !opts.Subscribe {
+		return
+	}
+
+	if !s.gathering {
+		s.fail("RegisterWith with option Subscribe must be called from RegisterDependencies of the subscriber")
+		return
+	}
+
+	if !hasMethod(depPtr, "SubscribeObj") {
+		return
+	}
+
+	if _, err := subscribeRegistered(depPtr, s.gatheringObj, false); err != nil {
+		s.reportFailure(err)
+	}
+}
+
+// RegisterWith is same as GenericStore.RegisterWith.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) RegisterWith(depPtr interface{}, opts RegisterOpts) {
+	s.shardFor(depPtr).RegisterWith(depPtr, opts)
+}
+
+// hasMethod reports whether object, to which objPtr points, has method with the name.
+func hasMethod(objPtr interface{}, name string) bool {
+	objPtrV := reflect.ValueOf(objPtr)
+	if objPtrV.Kind() != reflect.Pointer || objPtrV.IsNil() || objPtrV.Elem().Kind() != reflect.Pointer || objPtrV.Elem().IsNil() {
+		return false
+	}
+
+	return objPtrV.Elem().MethodByName(name).IsValid()
+}
+
+// subscribeRegistered subscribes subscriber to dependency, to which depPtr points after registration.
+// If withPuller is set and dependency supports events, returns new event puller of it.
+func subscribeRegistered(depPtr, subscriber interface{}, withPuller bool) (puller interface{}, err error) {
+	depPtrV := reflect.ValueOf(depPtr)
+	if depPtrV.Kind() != reflect.Pointer || depPtrV.IsNil() || depPtrV.Elem().Kind() != reflect.Pointer || depPtrV.Elem().IsNil() {
+		// Already reported by Register.
+		return nil, nil
+	}
+
+	dep := depPtrV.Elem()
+
+	subscribe := dep.MethodByName("SubscribeObj")
+	if subscriber == nil || !subscribe.IsValid() || subscribe.Type().NumIn() != 1 || subscribe.Type().NumOut() > 1 ||
+		!reflect.TypeOf(subscriber).AssignableTo(subscribe.Type().In(0)) {
+		return nil, fmt.Errorf("Object of type %v does not have method SubscribeObj accepting subscriber of type %T", dep.Type(), subscriber)
+	}
+	subscribe.Call([]reflect.Value{reflect.ValueOf(subscriber)})
+
+	if !withPuller {
+		return nil, nil
+	}
+
+	newPuller := dep.MethodByName("NewEventPuller")
+	if !newPuller.IsValid() || newPuller.Type().NumIn() != 0 || newPuller.Type().NumOut() != 1 {
+		return nil, nil
+	}
+
+	return newPuller.Call(nil)[0].Interface(), nil
+}
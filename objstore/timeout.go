@@ -0,0 +1,71 @@
+package objstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+)
+
+// ErrTimeout is returned (wrapped) by Init and Start of the store, when lifecycle method of the object has taken
+// longer than timeout set by WithPhaseTimeout.
+var ErrTimeout = errors.New("timeout is exceeded")
+
+// phaseContext returns ctx limited by timeout of the phase, if it is set.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) phaseContext(ctx context.Context, phase LifecyclePhase) (context.Context, context.CancelFunc) {
+	if timeout := s.timeouts[phase]; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// watchTimeout reports the object, if it is still running lifecycle method of the phase after timeout of the phase.
+// Returned function must be called, when the method returns. It waits for running timeout handlers, and calls them,
+// if the method has returned at the very timeout, so that exceeded timeout is always reported.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) watchTimeout(phase LifecyclePhase, obj SharedObject, objID ObjID, start time.Time) (stop func()) {
+	timeout := s.timeouts[phase]
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	reported := make(chan struct{})
+	timer := time.AfterFunc(timeout-time.Since(start), func() {
+		defer close(reported)
+		s.reportTimeout(phase, obj, objID, timeout)
+	})
+
+	return func() {
+		if !timer.Stop() {
+			<-reported
+			return
+		}
+		if time.Since(start) >= timeout {
+			s.reportTimeout(phase, obj, objID, timeout)
+		}
+	}
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) reportTimeout(phase LifecyclePhase, obj SharedObject, objID ObjID, timeout time.Duration) {
+	if len(s.timeoutHandlers) == 0 {
+		s.logObj(utils.LevelWarn, fmt.Sprintf("Object has not finished %v within %v", phase, timeout), obj, objID)
+		return
+	}
+
+	for _, h := range s.timeoutHandlers {
+		h(phase, fmt.Sprint(objID), obj)
+	}
+}
+
+// checkTimeout returns ErrTimeout, if lifecycle method of the phase has succeeded, but has taken longer than timeout.
+// Only Init and Start can fail.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) checkTimeout(phase LifecyclePhase, duration time.Duration, err error) error {
+	timeout := s.timeouts[phase]
+	if err != nil || timeout <= 0 || duration < timeout || (phase != PhaseInit && phase != PhaseStart) {
+		return err
+	}
+
+	return errors.Wrapf(ErrTimeout, "%v has taken %v with timeout %v", phase, duration.Round(time.Millisecond), timeout)
+}
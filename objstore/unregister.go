@@ -0,0 +1,147 @@
+package objstore
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// Unregister undoes registration of top-level object made by Register or RegisterLive. Expects pointer to pointer,
+// which is set to nil. Each registration of the same object must be undone separately. When the last registration
+// is undone, the object and its dependencies, which are not needed by other top-level objects anymore, are stopped,
+// closed in reverse initialization order, passed into skip handlers, e.g. to detach them from update tree, and removed
+// from the store. Objects of parent store are not affected. Must not be called while updates are propagated.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) Unregister(obj interface{}) {
+	objV := reflect.ValueOf(obj)
+	if objV.Kind() != reflect.Pointer || objV.IsNil() || objV.Elem().Kind() != reflect.Pointer || objV.Elem().IsNil() {
+		s.fail("Unregister method accepts only non-nil pointers to object pointers, got %T", obj)
+		return
+	}
+
+	sharedObj, ok := objV.Elem().Interface().(SharedObject)
+	if !ok {
+		s.fail("Object of type %v does not implement shared object interface of the store", objV.Elem().Type())
+		return
+	}
+
+	objID := s.getID(sharedObj)
+	if s.topLevelRefs[objID] == 0 {
+		s.fail("Object with id %v of type %T is not registered as top-level object, so it cannot be unregistered", objID, sharedObj)
+		return
+	}
+	if s.phase != storeCreated && s.phase != storeInitialized && s.phase != storeStarted {
+		s.fail("Object with id %v cannot be unregistered from %v store", objID, s.phase)
+		return
+	}
+
+	objV.Elem().Set(reflect.Zero(objV.Elem().Type()))
+
+	s.topLevelRefs[objID]--
+	if s.topLevelRefs[objID] > 0 {
+		return
+	}
+	delete(s.topLevelRefs, objID)
+
+	if s.phase == storeCreated {
+		// Requirements are not gathered yet, so only the object itself is registered.
+		delete(s.dependenciesSet, objID)
+		s.dependencies = slices.DeleteFunc(s.dependencies, func(id ObjID) bool { return id == objID })
+		s.forget(objID)
+		return
+	}
+
+	s.topLevelDependencies = slices.DeleteFunc(slices.Clone(s.topLevelDependencies), func(id ObjID) bool { return id == objID })
+
+	needed := make(map[ObjID]struct{}, len(s.dependenciesGraph))
+	var markNeeded func(id ObjID)
+	markNeeded = func(id ObjID) {
+		if _, ok := needed[id]; ok {
+			return
+		}
+		needed[id] = struct{}{}
+		for _, depID := range s.dependenciesGraph[id] {
+			markNeeded(depID)
+		}
+	}
+	for id := range s.topLevelRefs {
+		markNeeded(id)
+	}
+
+	var removed []ObjID
+	for i := len(s.initializationOrder) - 1; i >= 0; i-- {
+		id := s.initializationOrder[i]
+		if _, ok := needed[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	utils.LogKV(s.l, utils.LevelDebug, "Unregistering shared objects", "objects", removed)
+
+	for _, id := range removed {
+		s.stopRemoved(id)
+	}
+	for _, id := range removed {
+		s.closeRemoved(id)
+	}
+	for _, id := range removed {
+		s.forget(id)
+	}
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) stopRemoved(objID ObjID) {
+	if s.states[objID] != ObjectStarted {
+		return
+	}
+
+	if s.stopObj != nil {
+		object := s.objects[objID]
+		s.logObj(utils.LevelDebug, "Stopping object", object, objID)
+		_ = s.callObj(PhaseStop, object, objID, func() error {
+			s.stopObj(object)
+			return nil
+		})
+	}
+	s.states[objID] = ObjectStopped
+}
+
+func (s *GenericStore[SharedObject, ObjID, InitParams]) closeRemoved(objID ObjID) {
+	object := s.objects[objID]
+
+	if s.isInitialized(objID) && s.closeObj != nil {
+		s.logObj(utils.LevelDebug, "Closing object", object, objID)
+		_ = s.callObj(PhaseClose, object, objID, func() error {
+			s.closeObj(object)
+			return nil
+		})
+	}
+
+	// Skipped objects are already handled.
+	if s.states[objID] != ObjectSkipped {
+		for _, h := range s.skipHandlers {
+			h(object)
+		}
+	}
+}
+
+// forget removes all records of the object from the store.
+func (s *GenericStore[SharedObject, ObjID, InitParams]) forget(objID ObjID) {
+	isObj := func(id ObjID) bool { return id == objID }
+
+	delete(s.objects, objID)
+	delete(s.states, objID)
+	delete(s.failures, objID)
+	delete(s.initDurations, objID)
+	delete(s.skipped, objID)
+	delete(s.disabled, objID)
+	delete(s.registeredAt, objID)
+	delete(s.rolledBack, objID)
+	delete(s.dependenciesGraph, objID)
+	s.forgetObjLogger(objID)
+	s.objectsRegistrationOrder = slices.DeleteFunc(s.objectsRegistrationOrder, isObj)
+	s.initializationOrder = slices.DeleteFunc(s.initializationOrder, isObj)
+}
+
+// Unregister undoes registration of top-level object in the shard it belongs to. See GenericStore.Unregister.
+func (s *ShardedStore[SharedObject, ObjID, InitParams]) Unregister(obj interface{}) {
+	s.shardFor(obj).Unregister(obj)
+}
@@ -0,0 +1,78 @@
+// Package resources provides shared objects wrapping common process-wide resources.
+// Objects are identified by their configuration, so all users with the same configuration share single
+// instance of the resource, e.g. single connections pool. Resources are safe for concurrent use.
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+)
+
+// HTTPClientConfig configures HTTP client. Zero values mean defaults of net/http.
+type HTTPClientConfig struct {
+	Timeout             time.Duration `json:"timeout"`
+	MaxIdleConns        int           `json:"maxIdleConns"`
+	MaxIdleConnsPerHost int           `json:"maxIdleConnsPerHost"`
+	IdleConnTimeout     time.Duration `json:"idleConnTimeout"`
+}
+
+// NewHTTPClient creates shared HTTP client with the given configuration.
+func NewHTTPClient[Ctx, InitParams any](cfg HTTPClientConfig) *HTTPClient[Ctx, InitParams] {
+	return &HTTPClient[Ctx, InitParams]{
+		SharedObjectBase: shdep.NewSharedObjectBase[Ctx, InitParams]("HTTPClient", cfg),
+		cfg:              cfg,
+	}
+}
+
+// HTTPClient is a shared HTTP client. Its connections pool is shared by all users with the same configuration.
+type HTTPClient[Ctx, InitParams any] struct {
+	shdep.SharedObjectBase[Ctx, InitParams]
+	cfg    HTTPClientConfig
+	client *http.Client
+}
+
+var _ shdep.SharedObject[interface{}, interface{}] = &HTTPClient[interface{}, interface{}]{}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (c *HTTPClient[Ctx, InitParams]) Init(params InitParams) error {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected type of default HTTP transport: %T", http.DefaultTransport)
+	}
+
+	transport = transport.Clone()
+	if c.cfg.MaxIdleConns != 0 {
+		transport.MaxIdleConns = c.cfg.MaxIdleConns
+	}
+	if c.cfg.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = c.cfg.MaxIdleConnsPerHost
+	}
+	if c.cfg.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = c.cfg.IdleConnTimeout
+	}
+
+	c.client = &http.Client{
+		Timeout:   c.cfg.Timeout,
+		Transport: transport,
+	}
+
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (c *HTTPClient[Ctx, InitParams]) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// Client returns underlying client. Available after Init.
+func (c *HTTPClient[Ctx, InitParams]) Client() *http.Client {
+	return c.client
+}
+
+// Do sends HTTP request.
+func (c *HTTPClient[Ctx, InitParams]) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
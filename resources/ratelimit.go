@@ -0,0 +1,140 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// RateLimitedClientConfig configures client of an API, which limits rate of requests.
+type RateLimitedClientConfig struct {
+	// Name of the API. Clients of different APIs have separate limits even if other parameters are same.
+	API string `json:"api"`
+
+	// Maximum average number of requests per second.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+
+	// Maximum number of requests, which can be sent at once after a period of inactivity. Minimum is 1.
+	Burst int `json:"burst"`
+
+	HTTP HTTPClientConfig `json:"http"`
+}
+
+// NewRateLimitedClient creates shared API client, which limits rate of requests of all its users together.
+// It uses shared HTTP client with the same HTTP configuration.
+func NewRateLimitedClient[Ctx, InitParams any](cfg RateLimitedClientConfig) *RateLimitedClient[Ctx, InitParams] {
+	return &RateLimitedClient[Ctx, InitParams]{
+		SharedObjectBase: shdep.NewSharedObjectBase[Ctx, InitParams]("RateLimitedClient-"+cfg.API, cfg),
+		cfg:              cfg,
+		http:             NewHTTPClient[Ctx, InitParams](cfg.HTTP),
+	}
+}
+
+// RateLimitedClient is a shared client of an API, which limits rate of requests.
+type RateLimitedClient[Ctx, InitParams any] struct {
+	shdep.SharedObjectBase[Ctx, InitParams]
+	cfg     RateLimitedClientConfig
+	http    *HTTPClient[Ctx, InitParams]
+	limiter *tokenBucket
+}
+
+var _ shdep.SharedObject[interface{}, interface{}] = &RateLimitedClient[interface{}, interface{}]{}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (c *RateLimitedClient[Ctx, InitParams]) RegisterDependencies(store objstore.SharedStore[shdep.SharedObject[Ctx, InitParams], InitParams]) {
+	store.Register(&c.http)
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (c *RateLimitedClient[Ctx, InitParams]) Init(params InitParams) error {
+	if c.cfg.RequestsPerSecond <= 0 {
+		return fmt.Errorf("invalid rate limit of %v API: %v requests per second", c.cfg.API, c.cfg.RequestsPerSecond)
+	}
+
+	c.limiter = newTokenBucket(c.cfg.RequestsPerSecond, max(c.cfg.Burst, 1), time.Now)
+
+	return nil
+}
+
+// Wait blocks until request can be sent without exceeding the limit, or until context is done.
+func (c *RateLimitedClient[Ctx, InitParams]) Wait(ctx context.Context) error {
+	return c.limiter.wait(ctx)
+}
+
+// Do waits until request can be sent without exceeding the limit and sends it.
+// Waiting is interrupted, when context of the request is done.
+func (c *RateLimitedClient[Ctx, InitParams]) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return c.http.Do(req)
+}
+
+// tokenBucket allows rate events per second on average and up to burst events at once.
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now func() time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now(),
+		now:    now,
+	}
+}
+
+// reserve takes a token and returns how long to wait before it becomes available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns token taken by reserve.
+func (b *tokenBucket) cancel() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,222 @@
+package resources
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/pkg/errors"
+)
+
+// ResourceBase is a helper base struct for shared objects wrapping external resource, e.g. connection or file handle.
+// Resource is opened in Init. Users take it by Acquire and return it by releasing the lease, e.g. from their Close.
+// Close of the object releases the resource only when the last user has released it, so the resource is kept
+// alive for users, which are still working with it, e.g. background goroutines, which are not stopped yet.
+type ResourceBase[Ctx, InitParams, R any] struct {
+	shdep.SharedObjectBase[Ctx, InitParams]
+	// Kept by pointer, because base structs are copied by value on construction.
+	state *resourceState[R]
+}
+
+type resourceState[R any] struct {
+	lock sync.Mutex
+	// Serializes Reconnect together with calls of its hooks.
+	reconnectLock sync.Mutex
+	open          func() (R, error)
+	release       func(res R) error
+	current       *generation[R]
+	opened        bool
+	users         int
+	closing       bool
+	onReconnect   []func(res R)
+	releaseErr    error
+}
+
+// generation is one opened instance of the resource. Reconnect and Close retire it,
+// and it is released, when the last lease of it is released.
+type generation[R any] struct {
+	res     R
+	users   int
+	retired bool
+}
+
+// Lease is the resource taken by ResourceBase.Acquire. The resource it was taken from is kept opened until
+// the lease is released, even if it has been replaced by Reconnect meanwhile.
+type Lease[R any] struct {
+	name     string
+	state    *resourceState[R]
+	gen      *generation[R]
+	released bool
+}
+
+// Resource returns the leased resource.
+func (l *Lease[R]) Resource() R {
+	return l.gen.res
+}
+
+// Release returns the resource to the object. If the resource has been replaced by Reconnect or the object
+// has been closed and this was the last lease of it, the resource is released.
+func (l *Lease[R]) Release() {
+	s := l.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if l.released {
+		panic(fmt.Sprintf("lease of resource %v is released twice", l.name))
+	}
+
+	l.released = true
+	s.users--
+	l.gen.users--
+	if l.gen.users == 0 && l.gen.retired {
+		s.releaseGeneration(l.gen)
+	}
+}
+
+// NewResourceBase creates new ResourceBase. Function open opens the resource, and release releases it.
+// Parameters are same as of shdep.NewSharedObjectBase and usually are configuration of the resource.
+func NewResourceBase[Ctx, InitParams, R any](name string, open func() (R, error), release func(res R) error, params ...interface{}) ResourceBase[Ctx, InitParams, R] {
+	return ResourceBase[Ctx, InitParams, R]{
+		SharedObjectBase: shdep.NewSharedObjectBase[Ctx, InitParams](name, params...),
+		state:            &resourceState[R]{open: open, release: release},
+	}
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Opens the resource.
+func (r *ResourceBase[Ctx, InitParams, R]) Init(params InitParams) error {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res, err := s.open()
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %v", r.Name())
+	}
+
+	s.current, s.opened = &generation[R]{res: res}, true
+	return nil
+}
+
+// Acquire takes the current resource for use. Returned lease must be released.
+// Fails, if the resource is not opened or if the object is being closed.
+func (r *ResourceBase[Ctx, InitParams, R]) Acquire() (*Lease[R], error) {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.opened || s.closing {
+		return nil, fmt.Errorf("resource %v is not available", r.Name())
+	}
+
+	s.users++
+	s.current.users++
+	return &Lease[R]{name: r.Name(), state: s, gen: s.current}, nil
+}
+
+// Users returns number of leases of the resource, including leases of resources replaced by Reconnect,
+// which have not been released yet.
+func (r *ResourceBase[Ctx, InitParams, R]) Users() int {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.users
+}
+
+// OnReconnect adds hook, which is called with new resource after successful Reconnect, e.g. to acquire it
+// instead of the old one. Hooks are called in order of addition without the lock of the resource held,
+// so they can call Acquire, but must not call Reconnect.
+func (r *ResourceBase[Ctx, InitParams, R]) OnReconnect(hook func(res R)) {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.onReconnect = append(s.onReconnect, hook)
+}
+
+// Reconnect opens the resource again, e.g. after connection has been lost. Old resource is released,
+// when all leases of it are released, so users, which have acquired it, should acquire new one and release
+// old lease, e.g. from hook added by OnReconnect. If opening fails, the old resource is kept.
+// Returned error includes error of releasing the old resource, if it was released immediately.
+func (r *ResourceBase[Ctx, InitParams, R]) Reconnect() error {
+	s := r.state
+	s.reconnectLock.Lock()
+	defer s.reconnectLock.Unlock()
+
+	s.lock.Lock()
+	if !s.opened || s.closing {
+		s.lock.Unlock()
+		return fmt.Errorf("resource %v is not available", r.Name())
+	}
+
+	res, err := s.open()
+	if err != nil {
+		s.lock.Unlock()
+		return errors.Wrapf(err, "failed to reopen %v", r.Name())
+	}
+
+	old := s.current
+	s.current = &generation[R]{res: res}
+	old.retired = true
+
+	var releaseErr error
+	if old.users == 0 {
+		releaseErr = s.releaseGeneration(old)
+	}
+
+	hooks := append([]func(res R){}, s.onReconnect...)
+	s.lock.Unlock()
+
+	for _, hook := range hooks {
+		hook(res)
+	}
+
+	return errors.Wrapf(releaseErr, "failed to release old resource of %v", r.Name())
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Releases the resource, if nobody uses it,
+// or postpones it until the last lease of it is released.
+func (r *ResourceBase[Ctx, InitParams, R]) Close() {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.opened || s.closing {
+		return
+	}
+
+	s.closing = true
+	s.current.retired = true
+	if s.current.users == 0 {
+		s.releaseGeneration(s.current)
+	}
+}
+
+// ReleaseError returns the first error of releasing the resource, if it has happened.
+func (r *ResourceBase[Ctx, InitParams, R]) ReleaseError() error {
+	s := r.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.releaseErr
+}
+
+// DebugInfo returns same info as SharedObjectBase.DebugInfo and number of users of the resource.
+func (r *ResourceBase[Ctx, InitParams, R]) DebugInfo() map[string]interface{} {
+	info := r.SharedObjectBase.DebugInfo()
+	info["resourceUsers"] = r.Users()
+
+	return info
+}
+
+// releaseGeneration releases resource of the generation and records error of it. Must be called under lock.
+func (s *resourceState[R]) releaseGeneration(g *generation[R]) error {
+	err := s.release(g.res)
+	if err != nil && s.releaseErr == nil {
+		s.releaseErr = err
+	}
+
+	return err
+}
+
+var _ shdep.SharedObject[interface{}, interface{}] = &ResourceBase[interface{}, interface{}, int]{}
@@ -0,0 +1,252 @@
+package resources_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/resources"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+type APIClient = resources.RateLimitedClient[context.Context, *InitParams]
+type DB = resources.SQLDB[context.Context, *InitParams]
+
+// fakeDriver counts opened connections.
+type fakeDriver struct {
+	opened int
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	if name == "unreachable" {
+		return nil, errors.New("connection refused")
+	}
+	d.opened++
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+var testDriver = &fakeDriver{}
+
+func init() {
+	sql.Register("shdep-fake", testDriver)
+}
+
+type service struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	api *APIClient
+	db  *DB
+}
+
+func newService(name string, apiURL string) *service {
+	return &service{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("service", name, apiURL),
+		api: resources.NewRateLimitedClient[context.Context, *InitParams](resources.RateLimitedClientConfig{
+			API:               apiURL,
+			RequestsPerSecond: 50,
+			Burst:             2,
+			HTTP:              resources.HTTPClientConfig{Timeout: time.Second},
+		}),
+		db: resources.NewSQLDB[context.Context, *InitParams](resources.SQLDBConfig{
+			Driver:      "shdep-fake",
+			DSN:         "db",
+			PingTimeout: time.Second,
+		}),
+	}
+}
+
+func (s *service) RegisterDependencies(store SharedStore) {
+	store.Register(&s.api)
+	store.Register(&s.db)
+}
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	openedBefore := testDriver.opened
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+	s1 := newService("s1", srv.URL)
+	s2 := newService("s2", srv.URL)
+	store.Register(&s1)
+	store.Register(&s2)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	require.Same(t, s1.api, s2.api)
+	require.Same(t, s1.db, s2.db)
+	require.Equal(t, openedBefore+1, testDriver.opened)
+	require.NoError(t, s1.db.DB().Ping())
+
+	// Limit is shared by both services: 2 requests are sent at once, others are sent at 50 requests per second.
+	const requestsCount = 6
+	start := time.Now()
+	for i := 0; i < requestsCount; i++ {
+		api := s1.api
+		if i%2 == 1 {
+			api = s2.api
+		}
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := api.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	require.GreaterOrEqual(t, time.Since(start), (requestsCount-2)*20*time.Millisecond-5*time.Millisecond)
+	require.Equal(t, requestsCount, requests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, s1.api.Wait(ctx), context.Canceled)
+
+	store.Stop()
+	store.Close()
+}
+
+func TestSQLDB_PingFailure(t *testing.T) {
+	t.Parallel()
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+	db := resources.NewSQLDB[context.Context, *InitParams](resources.SQLDBConfig{
+		Driver:      "shdep-fake",
+		DSN:         "unreachable",
+		PingTimeout: time.Second,
+	})
+	store.Register(&db)
+
+	err := store.Init(&InitParams{})
+	require.ErrorContains(t, err, "failed to connect to shdep-fake database: connection refused")
+}
+
+// conn is a fake connection, which counts openings and closings of all connections.
+type conn struct {
+	id     int
+	closed bool
+}
+
+type connResource struct {
+	resources.ResourceBase[context.Context, *InitParams, *conn]
+	opened []*conn
+}
+
+func newConnResource(addr string) *connResource {
+	r := &connResource{}
+	r.ResourceBase = resources.NewResourceBase[context.Context, *InitParams](addr, func() (*conn, error) {
+		c := &conn{id: len(r.opened) + 1}
+		r.opened = append(r.opened, c)
+		return c, nil
+	}, func(c *conn) error {
+		c.closed = true
+		return nil
+	}, addr)
+
+	return r
+}
+
+type connUser struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	res      *connResource
+	lease    *resources.Lease[*conn]
+	keepConn bool
+}
+
+func (u *connUser) RegisterDependencies(store SharedStore) {
+	store.Register(&u.res)
+}
+
+func (u *connUser) Init(params *InitParams) error {
+	var err error
+	u.lease, err = u.res.Acquire()
+	u.res.OnReconnect(func(c *conn) {
+		lease, err := u.res.Acquire()
+		if err != nil {
+			return
+		}
+		u.lease.Release()
+		u.lease = lease
+	})
+	return err
+}
+
+func (u *connUser) Close() {
+	if !u.keepConn {
+		u.lease.Release()
+	}
+}
+
+func TestResourceBase(t *testing.T) {
+	t.Parallel()
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+	u1 := &connUser{SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("user", 1), res: newConnResource("db")}
+	u2 := &connUser{SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("user", 2), res: newConnResource("db"), keepConn: true}
+	store.Register(&u1)
+	store.Register(&u2)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	res := u1.res
+	require.Same(t, res, u2.res)
+	require.Equal(t, 2, res.Users())
+	require.Same(t, u1.lease.Resource(), u2.lease.Resource())
+
+	// Users switch to the new connection from hooks, so the old one is released by them.
+	first := u1.lease.Resource()
+	require.NoError(t, res.Reconnect())
+	require.True(t, first.closed)
+	require.Equal(t, 2, u1.lease.Resource().id)
+	require.Same(t, u1.lease.Resource(), u2.lease.Resource())
+	require.Equal(t, 2, res.Users())
+
+	// Old connection is kept, while somebody still holds it.
+	held, err := res.Acquire()
+	require.NoError(t, err)
+	require.NoError(t, res.Reconnect())
+	require.Equal(t, 3, u1.lease.Resource().id)
+	require.False(t, held.Resource().closed)
+	require.Equal(t, 3, res.Users())
+	held.Release()
+	require.True(t, held.Resource().closed)
+	require.False(t, u1.lease.Resource().closed)
+	require.Panics(t, held.Release)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+
+	// Second user still holds connection, so it is released only after the user releases it.
+	last := u2.lease.Resource()
+	require.False(t, last.closed)
+	_, err = res.Acquire()
+	require.Error(t, err)
+	require.Error(t, res.Reconnect())
+	u2.lease.Release()
+	require.True(t, last.closed)
+	require.Equal(t, 0, res.Users())
+	require.NoError(t, res.ReleaseError())
+	require.Panics(t, u2.lease.Release)
+}
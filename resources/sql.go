@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/pkg/errors"
+)
+
+// SQLDBConfig configures pool of database connections. Zero values mean defaults of database/sql.
+// Driver must be registered by importing its package.
+type SQLDBConfig struct {
+	Driver          string        `json:"driver"`
+	DSN             string        `json:"dsn"`
+	MaxOpenConns    int           `json:"maxOpenConns"`
+	MaxIdleConns    int           `json:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `json:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `json:"connMaxIdleTime"`
+
+	// If set, connection to database is checked during Init with this timeout.
+	PingTimeout time.Duration `json:"pingTimeout"`
+}
+
+// NewSQLDB creates shared pool of database connections.
+// DSN is a part of object hash, but not of its name, so credentials do not appear in logs and debug info.
+func NewSQLDB[Ctx, InitParams any](cfg SQLDBConfig) *SQLDB[Ctx, InitParams] {
+	return &SQLDB[Ctx, InitParams]{
+		SharedObjectBase: shdep.NewSharedObjectBase[Ctx, InitParams]("SQLDB-"+cfg.Driver, cfg),
+		cfg:              cfg,
+	}
+}
+
+// SQLDB is a shared pool of database connections.
+type SQLDB[Ctx, InitParams any] struct {
+	shdep.SharedObjectBase[Ctx, InitParams]
+	cfg SQLDBConfig
+	db  *sql.DB
+}
+
+var _ shdep.SharedObject[interface{}, interface{}] = &SQLDB[interface{}, interface{}]{}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (d *SQLDB[Ctx, InitParams]) Init(params InitParams) error {
+	db, err := sql.Open(d.cfg.Driver, d.cfg.DSN)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %v database", d.cfg.Driver)
+	}
+
+	db.SetMaxOpenConns(d.cfg.MaxOpenConns)
+	db.SetMaxIdleConns(d.cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(d.cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(d.cfg.ConnMaxIdleTime)
+
+	if d.cfg.PingTimeout != 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), d.cfg.PingTimeout)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return errors.Wrapf(err, "failed to connect to %v database", d.cfg.Driver)
+		}
+	}
+
+	d.db = db
+
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (d *SQLDB[Ctx, InitParams]) Close() {
+	if d.db != nil {
+		d.db.Close()
+	}
+}
+
+// DB returns underlying pool of connections. Available after Init.
+func (d *SQLDB[Ctx, InitParams]) DB() *sql.DB {
+	return d.db
+}
@@ -0,0 +1,120 @@
+package shdep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// Lifecycle is implemented by stores, e.g. objstore.GenericStore and objstore.ShardedStore.
+type Lifecycle[InitParams any] = objstore.Lifecycle[InitParams]
+
+type runConfig struct {
+	ctx          context.Context
+	signals      []os.Signal
+	stopTimeout  time.Duration
+	closeTimeout time.Duration
+}
+
+type RunOption func(c *runConfig)
+
+// RunWithContext sets context, cancellation of which stops the store. By default context.Background() is used.
+func RunWithContext(ctx context.Context) RunOption {
+	return func(c *runConfig) {
+		c.ctx = ctx
+	}
+}
+
+// RunWithSignals sets signals, which stop the store. By default SIGINT and SIGTERM are used.
+func RunWithSignals(signals ...os.Signal) RunOption {
+	return func(c *runConfig) {
+		c.signals = signals
+	}
+}
+
+// RunWithStopTimeout sets maximum duration of Stop. Zero means no limit, which is the default.
+func RunWithStopTimeout(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.stopTimeout = d
+	}
+}
+
+// RunWithCloseTimeout sets maximum duration of Close. Zero means no limit, which is the default.
+func RunWithCloseTimeout(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.closeTimeout = d
+	}
+}
+
+// RunUntilSignal initializes and starts the store, waits for one of signals or cancellation of context,
+// and then stops and closes the store. If Init fails, initialized objects are closed and error is returned.
+// If Start fails, store is still stopped and closed. Returned error combines errors of all phases.
+//
+// If Stop fails or exceeds its timeout, Close is not executed, because objects may still be in use.
+// Phase, which exceeds its timeout, is left running in background.
+func RunUntilSignal[InitParams any](store Lifecycle[InitParams], params InitParams, opts ...RunOption) error {
+	c := &runConfig{
+		ctx:     context.Background(),
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := store.Init(params); err != nil {
+		errs := []error{fmt.Errorf("failed to initialize store: %w", err)}
+		if err := runWithTimeout(store.CloseE, c.closeTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close store: %w", err))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	var errs []error
+
+	if err := store.Start(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to start store: %w", err))
+	} else {
+		ctx, cancel := signal.NotifyContext(c.ctx, c.signals...)
+		<-ctx.Done()
+		cancel()
+	}
+
+	if err := runWithTimeout(store.StopE, c.stopTimeout); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop store: %w", err))
+		return errors.Join(errs...)
+	}
+
+	if err := runWithTimeout(store.CloseE, c.closeTimeout); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close store: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func runWithTimeout(f func() error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return f()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+}
@@ -0,0 +1,169 @@
+package shdep_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLifecycle records calls of lifecycle methods. Methods return configured errors
+// and block until release is closed, if they are listed in blocking.
+type fakeLifecycle struct {
+	lock     sync.Mutex
+	calls    []string
+	errs     map[string]error
+	blocking map[string]bool
+	release  chan struct{}
+}
+
+var _ shdep.Lifecycle[*InitParams] = &fakeLifecycle{}
+
+func newFakeLifecycle(t *testing.T) *fakeLifecycle {
+	l := &fakeLifecycle{
+		errs:     make(map[string]error),
+		blocking: make(map[string]bool),
+		release:  make(chan struct{}),
+	}
+	t.Cleanup(func() { close(l.release) })
+
+	return l
+}
+
+func (l *fakeLifecycle) call(method string) error {
+	l.lock.Lock()
+	l.calls = append(l.calls, method)
+	err, blocking := l.errs[method], l.blocking[method]
+	l.lock.Unlock()
+
+	if blocking {
+		<-l.release
+	}
+
+	return err
+}
+
+func (l *fakeLifecycle) Calls() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]string(nil), l.calls...)
+}
+
+func (l *fakeLifecycle) Init(p *InitParams) error { return l.call("Init") }
+func (l *fakeLifecycle) Start() error             { return l.call("Start") }
+func (l *fakeLifecycle) StopE() error             { return l.call("Stop") }
+func (l *fakeLifecycle) CloseE() error            { return l.call("Close") }
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeLifecycle(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- shdep.RunUntilSignal[*InitParams](store, &InitParams{}, shdep.RunWithContext(ctx))
+	}()
+
+	require.Eventually(t, func() bool { return len(store.Calls()) == 2 }, time.Second, time.Millisecond)
+	require.Equal(t, []string{"Init", "Start"}, store.Calls())
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Equal(t, []string{"Init", "Start", "Stop", "Close"}, store.Calls())
+}
+
+func TestRunUntilSignal_InitFailure(t *testing.T) {
+	t.Parallel()
+
+	errInit := errors.New("init error")
+	store := newFakeLifecycle(t)
+	store.errs["Init"] = errInit
+
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{}, shdep.RunWithContext(context.Background()))
+	require.ErrorIs(t, err, errInit)
+	require.EqualError(t, err, "failed to initialize store: init error")
+	require.Equal(t, []string{"Init", "Close"}, store.Calls())
+}
+
+func TestRunUntilSignal_StartFailure(t *testing.T) {
+	t.Parallel()
+
+	errStart := errors.New("start error")
+	errClose := errors.New("close error")
+	store := newFakeLifecycle(t)
+	store.errs["Start"] = errStart
+	store.errs["Close"] = errClose
+
+	// Context is not cancelled: failed Start must not wait for it.
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{}, shdep.RunWithContext(context.Background()))
+	require.ErrorIs(t, err, errStart)
+	require.ErrorIs(t, err, errClose)
+	require.Equal(t, []string{"Init", "Start", "Stop", "Close"}, store.Calls())
+}
+
+func TestRunUntilSignal_StopFailureSkipsClose(t *testing.T) {
+	t.Parallel()
+
+	errStop := errors.New("stop error")
+	store := newFakeLifecycle(t)
+	store.errs["Stop"] = errStop
+
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{}, shdep.RunWithContext(cancelledContext()))
+	require.ErrorIs(t, err, errStop)
+	require.EqualError(t, err, "failed to stop store: stop error")
+	require.Equal(t, []string{"Init", "Start", "Stop"}, store.Calls())
+}
+
+func TestRunUntilSignal_StopTimeout(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeLifecycle(t)
+	store.blocking["Stop"] = true
+
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{},
+		shdep.RunWithContext(cancelledContext()),
+		shdep.RunWithStopTimeout(10*time.Millisecond),
+		shdep.RunWithCloseTimeout(time.Second))
+	require.EqualError(t, err, "failed to stop store: timed out after 10ms")
+	require.Equal(t, []string{"Init", "Start", "Stop"}, store.Calls())
+}
+
+func TestRunUntilSignal_CloseTimeout(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeLifecycle(t)
+	store.blocking["Close"] = true
+
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{},
+		shdep.RunWithContext(cancelledContext()),
+		shdep.RunWithStopTimeout(time.Second),
+		shdep.RunWithCloseTimeout(10*time.Millisecond))
+	require.EqualError(t, err, "failed to close store: timed out after 10ms")
+	require.Equal(t, []string{"Init", "Start", "Stop", "Close"}, store.Calls())
+}
+
+func TestRunUntilSignal_InitFailureCloseTimeout(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeLifecycle(t)
+	store.errs["Init"] = errors.New("init error")
+	store.blocking["Close"] = true
+
+	err := shdep.RunUntilSignal[*InitParams](store, &InitParams{},
+		shdep.RunWithContext(context.Background()),
+		shdep.RunWithCloseTimeout(10*time.Millisecond))
+	require.EqualError(t, err, "failed to initialize store: init error\nfailed to close store: timed out after 10ms")
+	require.Equal(t, []string{"Init", "Close"}, store.Calls())
+}
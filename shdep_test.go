@@ -0,0 +1,51 @@
+package shdep_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+type SharedObjectBase = shdep.SharedObjectBase[context.Context, *InitParams]
+
+var NewSharedObjectBase = shdep.NewSharedObjectBase[context.Context, *InitParams]
+var NewSharedStore = shdep.NewSharedStore[context.Context, *InitParams]
+
+type counter struct {
+	SharedObjectBase
+	value int
+}
+
+func newCounter() *counter {
+	return &counter{SharedObjectBase: NewSharedObjectBase("counter", 1)}
+}
+
+type doubler struct {
+	SharedObjectBase
+	counter *counter
+	value   int
+}
+
+func newDoubler() *doubler {
+	d := &doubler{
+		SharedObjectBase: NewSharedObjectBase("doubler", 1),
+		counter:          newCounter(),
+	}
+
+	d.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		d.value = d.counter.value * 2
+	})
+
+	return d
+}
+
+func (d *doubler) RegisterDependencies(store SharedStore) {
+	store.Register(&d.counter)
+	d.counter.SubscribeObj(d)
+}
@@ -0,0 +1,149 @@
+// Package shdepexec runs propagation of external updates in an executor instead of protecting
+// update tree with a lock. Executor is anything, which runs posted tasks sequentially in a single goroutine
+// with its own context, e.g. scheduler of coroutines from github.com/nnikolash/go-coro or an event loop.
+// The package does not depend on any scheduler: it is connected by implementing Executor or using ExecutorFunc.
+package shdepexec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// Executor runs posted tasks sequentially. Post can be called from any goroutine.
+// If updtree.SetGoroutineAffinity is used, executor must run tasks using updtree.RunSerialized.
+type Executor[Ctx any] interface {
+	Post(task func(ctx Ctx))
+}
+
+// ExecutorFunc adapts function, which posts task into a scheduler, to Executor.
+type ExecutorFunc[Ctx any] func(task func(ctx Ctx))
+
+func (f ExecutorFunc[Ctx]) Post(task func(ctx Ctx)) {
+	f(task)
+}
+
+// Notifier is implemented by shared objects based on shdep.SharedObjectBase.
+type Notifier[Ctx any] interface {
+	NotifyUpdated(ctx Ctx, evtTime time.Time)
+}
+
+// EventPublisher is implemented by shared objects based on shdep.SharedObjectBaseWithEvent.
+type EventPublisher[Ctx, Event any] interface {
+	PublishEvent(ctx Ctx, evtTime time.Time, evt Event)
+}
+
+// NotifyUpdated posts notification about update of the object into executor.
+// Use it for updates, which come from outside of update tree, e.g. from a goroutine reading network connection.
+func NotifyUpdated[Ctx any](e Executor[Ctx], obj Notifier[Ctx], evtTime time.Time) {
+	e.Post(func(ctx Ctx) {
+		obj.NotifyUpdated(ctx, evtTime)
+	})
+}
+
+// PublishEvent posts publishing of the event by the object into executor.
+func PublishEvent[Ctx, Event any](e Executor[Ctx], obj EventPublisher[Ctx, Event], evtTime time.Time, evt Event) {
+	e.Post(func(ctx Ctx) {
+		obj.PublishEvent(ctx, evtTime, evt)
+	})
+}
+
+// NewWallClock creates wall clock for updtree.WithClock or updtree.SetClock, which posts firing of timers into the executor.
+// This way propagations started from timers by updtree.Throttle and updtree.Debounce are run by the executor
+// sequentially with other updates instead of racing with them from timer goroutines.
+func NewWallClock[Ctx any](e Executor[Ctx]) updtree.Clock {
+	return executorClock[Ctx]{e: e}
+}
+
+type executorClock[Ctx any] struct {
+	e Executor[Ctx]
+}
+
+func (executorClock[Ctx]) Now() time.Time {
+	return time.Now()
+}
+
+func (c executorClock[Ctx]) AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool) {
+	return time.AfterFunc(d, func() {
+		c.e.Post(func(ctx Ctx) {
+			f(time.Now())
+		})
+	}).Stop
+}
+
+// NewLoop creates executor, which runs tasks in its own goroutine with the given context.
+// It can be used by applications, which do not have a scheduler of their own.
+func NewLoop[Ctx any](ctx Ctx) *Loop[Ctx] {
+	l := &Loop[Ctx]{
+		ctx:  ctx,
+		done: make(chan struct{}),
+	}
+	l.cond = sync.NewCond(&l.lock)
+
+	go l.run()
+
+	return l
+}
+
+// Loop is an executor with unbounded queue of tasks.
+type Loop[Ctx any] struct {
+	ctx    Ctx
+	lock   sync.Mutex
+	cond   *sync.Cond
+	tasks  []func(ctx Ctx)
+	closed bool
+	done   chan struct{}
+}
+
+var _ Executor[int] = &Loop[int]{}
+
+// Post adds task into the queue. Tasks posted after Close are ignored.
+func (l *Loop[Ctx]) Post(task func(ctx Ctx)) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	l.tasks = append(l.tasks, task)
+	l.cond.Signal()
+}
+
+// Close stops the loop after all already posted tasks are executed and waits for it to finish.
+// Must not be called from tasks.
+func (l *Loop[Ctx]) Close() {
+	l.lock.Lock()
+	l.closed = true
+	l.cond.Signal()
+	l.lock.Unlock()
+
+	<-l.done
+}
+
+func (l *Loop[Ctx]) run() {
+	defer close(l.done)
+
+	for {
+		l.lock.Lock()
+		for len(l.tasks) == 0 && !l.closed {
+			l.cond.Wait()
+		}
+
+		tasks := l.tasks
+		l.tasks = nil
+		closed := l.closed
+		l.lock.Unlock()
+
+		updtree.RunSerialized(func() {
+			for _, task := range tasks {
+				task(l.ctx)
+			}
+		})
+
+		if closed && len(tasks) == 0 {
+			return
+		}
+	}
+}
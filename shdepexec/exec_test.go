@@ -0,0 +1,126 @@
+package shdepexec_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/shdepexec"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type ctxKey struct{}
+
+func TestLoop(t *testing.T) {
+	t.Parallel()
+
+	loop := shdepexec.NewLoop(context.WithValue(context.Background(), ctxKey{}, "loop"))
+
+	source := shdep.NewSharedObjectBaseWithEvent[context.Context, *InitParams, int]("source", 1)
+	puller := source.NewEventPuller()
+
+	subscriber := shdep.NewSharedObjectBase[context.Context, *InitParams]("subscriber", 1)
+	var ctxValues []interface{}
+	var events []int
+	subscriber.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		ctxValues = append(ctxValues, ctx.Value(ctxKey{}))
+		for _, evt := range puller.Pull() {
+			events = append(events, *evt.Event)
+		}
+	})
+	source.Subscribe(subscriber.GetUpdateNode())
+
+	// Updates come from many goroutines, but are propagated sequentially by the loop.
+	const goroutines = 10
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shdepexec.PublishEvent[context.Context, int](loop, &source, time.Now(), i)
+		}()
+	}
+	wg.Wait()
+
+	shdepexec.NotifyUpdated[context.Context](loop, &source, time.Now())
+	loop.Close()
+
+	require.Len(t, ctxValues, goroutines+1)
+	for _, v := range ctxValues {
+		require.Equal(t, "loop", v)
+	}
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, events)
+
+	// Posting after close is ignored.
+	shdepexec.NotifyUpdated[context.Context](loop, &source, time.Now())
+	require.Len(t, ctxValues, goroutines+1)
+}
+
+func TestExecutorFunc(t *testing.T) {
+	t.Parallel()
+
+	// Scheduler, which runs tasks inline. Real schedulers, e.g. of coroutines, run them in their own goroutine.
+	var posted int
+	executor := shdepexec.ExecutorFunc[context.Context](func(task func(ctx context.Context)) {
+		posted++
+		task(context.Background())
+	})
+
+	obj := shdep.NewSharedObjectBase[context.Context, *InitParams]("obj", 1)
+	shdepexec.NotifyUpdated[context.Context](executor, &obj, time.Now())
+
+	require.Equal(t, 1, posted)
+}
+
+func TestNewWallClock(t *testing.T) {
+	t.Parallel()
+
+	loop := shdepexec.NewLoop(context.Background())
+	clock := shdepexec.NewWallClock[context.Context](loop)
+
+	source := shdep.NewSharedObjectBase[context.Context, *InitParams]("source", 1)
+	throttled := updtree.Throttle[context.Context](source.GetUpdateNode(), time.Millisecond, updtree.WithClock(clock))
+	debounced := updtree.Debounce[context.Context](source.GetUpdateNode(), 5*time.Millisecond, updtree.WithClock(clock))
+
+	// Counters are not synchronized: race detector reports, if timers propagate updates outside of the loop.
+	var throttledUpdates, debouncedUpdates int
+	debouncedDone := make(chan struct{}, 1)
+	throttled.Subscribe(updtree.NewNode[context.Context]("throttledSub", func(ctx context.Context, evtTime time.Time) {
+		throttledUpdates++
+	}))
+	debounced.Subscribe(updtree.NewNode[context.Context]("debouncedSub", func(ctx context.Context, evtTime time.Time) {
+		debouncedUpdates++
+		select {
+		case debouncedDone <- struct{}{}:
+		default:
+		}
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				shdepexec.NotifyUpdated[context.Context](loop, &source, time.Now())
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-debouncedDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("debounced update was not passed")
+	}
+	loop.Close()
+
+	require.Positive(t, throttledUpdates)
+	require.Positive(t, debouncedUpdates)
+}
@@ -0,0 +1,84 @@
+// Package shdepfx integrates shared objects store with go.uber.org/fx,
+// so that lifecycle of the store is driven by lifecycle of fx application.
+package shdepfx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/pkg/errors"
+	"go.uber.org/fx"
+)
+
+// Store is a part of shared objects store, which is required to run its lifecycle.
+type Store[InitParams any] = objstore.Lifecycle[InitParams]
+
+// BindLifecycle appends hook to fx lifecycle, which initializes and starts the store on start of the application,
+// and stops and closes it on stop of the application.
+func BindLifecycle[InitParams any](lc fx.Lifecycle, store Store[InitParams], initParams InitParams) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := store.Init(initParams); err != nil {
+				// OnStop is not called for failed OnStart, so initialized objects are closed here.
+				_ = store.CloseE()
+				return errors.Wrapf(err, "failed to initialize shared objects store")
+			}
+
+			if err := store.Start(); err != nil {
+				return errors.Wrapf(err, "failed to start shared objects store")
+			}
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := store.StopE(); err != nil {
+				return errors.Wrapf(err, "failed to stop shared objects store")
+			}
+
+			if err := store.CloseE(); err != nil {
+				return errors.Wrapf(err, "failed to close shared objects store")
+			}
+
+			return nil
+		},
+	})
+}
+
+// Lifecycle returns fx option, which binds lifecycle of the store to lifecycle of the application.
+// Both the store and its initialization parameters must be provided to fx.
+// Provided store must also implement Store, e.g. be GenericStore.
+func Lifecycle[CustomSharedObject any, InitParams any]() fx.Option {
+	return fx.Invoke(func(lc fx.Lifecycle, store objstore.SharedStore[CustomSharedObject, InitParams], initParams InitParams) error {
+		lifecycle, ok := store.(Store[InitParams])
+		if !ok {
+			return fmt.Errorf("store of type %T does not implement lifecycle methods returning errors", store)
+		}
+
+		BindLifecycle[InitParams](lc, lifecycle, initParams)
+		return nil
+	})
+}
+
+// Register returns fx option, which registers singleton of type T provided by fx as top-level shared object,
+// so that other shared objects receive the same instance when registering it as dependency.
+// Fails if object with the same ID is already registered, because fx and the store would then use different instances.
+func Register[T comparable, CustomSharedObject any, InitParams any]() fx.Option {
+	return fx.Invoke(func(store objstore.SharedStore[CustomSharedObject, InitParams], obj T) error {
+		objAsSharedType, ok := any(obj).(CustomSharedObject)
+		if !ok {
+			return fmt.Errorf("object of type %T does not implement shared object interface of the store", obj)
+		}
+
+		replica, ok := objstore.RegisterObject(store, objAsSharedType, nil)
+		if !ok {
+			return fmt.Errorf("failed to register object of type %T", obj)
+		}
+
+		if replica, _ := any(replica).(T); replica != obj {
+			return fmt.Errorf("object of type %T is already registered in the store as another instance", obj)
+		}
+
+		return nil
+	})
+}
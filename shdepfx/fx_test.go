@@ -0,0 +1,100 @@
+package shdepfx_test
+
+import (
+	"testing"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepfx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type InitParams struct{}
+
+type SharedObject interface {
+	objstore.SharedObject[SharedObject, *InitParams]
+	ID() string
+}
+
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+
+type lifecycleCounters struct {
+	inits, starts, stops, closes int
+}
+
+func (c *lifecycleCounters) Init(p *InitParams) error  { c.inits++; return nil }
+func (c *lifecycleCounters) Start(p *InitParams) error { c.starts++; return nil }
+func (c *lifecycleCounters) Stop()                     { c.stops++ }
+func (c *lifecycleCounters) Close()                    { c.closes++ }
+
+type Config struct {
+	lifecycleCounters
+}
+
+func (c *Config) ID() string                         { return "config" }
+func (c *Config) RegisterDependencies(s SharedStore) {}
+
+type Service struct {
+	lifecycleCounters
+	config *Config
+}
+
+func (s *Service) ID() string { return "service" }
+
+func (s *Service) RegisterDependencies(store SharedStore) {
+	store.Register(&s.config)
+}
+
+func newStore() SharedStore {
+	return objstore.NewStore[SharedObject, *InitParams](func(obj SharedObject) string {
+		return obj.ID()
+	}, nil)
+}
+
+func TestFx_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	var config *Config
+	service := &Service{config: &Config{}}
+
+	app := fxtest.New(t,
+		fx.Provide(newStore),
+		fx.Supply(&InitParams{}),
+		fx.Provide(func() *Config { return &Config{} }),
+		shdepfx.Register[*Config, SharedObject, *InitParams](),
+		fx.Invoke(func(store SharedStore) {
+			store.Register(&service)
+		}),
+		shdepfx.Lifecycle[SharedObject, *InitParams](),
+		fx.Populate(&config),
+	)
+
+	app.RequireStart()
+
+	require.Same(t, config, service.config)
+	require.Equal(t, lifecycleCounters{inits: 1, starts: 1}, config.lifecycleCounters)
+	require.Equal(t, lifecycleCounters{inits: 1, starts: 1}, service.lifecycleCounters)
+
+	app.RequireStop()
+
+	require.Equal(t, lifecycleCounters{inits: 1, starts: 1, stops: 1, closes: 1}, config.lifecycleCounters)
+	require.Equal(t, lifecycleCounters{inits: 1, starts: 1, stops: 1, closes: 1}, service.lifecycleCounters)
+}
+
+func TestFx_RegisterAnotherInstance(t *testing.T) {
+	t.Parallel()
+
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(newStore),
+		fx.Provide(func() *Config { return &Config{} }),
+		fx.Invoke(func(store SharedStore) {
+			config := &Config{}
+			store.Register(&config)
+		}),
+		shdepfx.Register[*Config, SharedObject, *InitParams](),
+	)
+
+	require.ErrorContains(t, app.Err(), "already registered in the store as another instance")
+}
@@ -0,0 +1,106 @@
+// Package shdepotel provides OpenTelemetry metrics of lifecycle of shared objects and of update propagation.
+package shdepotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/updtree"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/nnikolash/go-shdep/shdepotel"
+
+// Instrumentation records metrics reported by store and update propagation tree.
+// It implements both objstore.Observer and updtree.Observer.
+type Instrumentation struct {
+	lifecycleDuration   metric.Float64Histogram
+	propagationDuration metric.Float64Histogram
+	nodesUpdated        metric.Int64Histogram
+	handlerDuration     metric.Float64Histogram
+}
+
+var _ objstore.Observer = &Instrumentation{}
+var _ updtree.Observer = &Instrumentation{}
+
+func New(mp metric.MeterProvider) (*Instrumentation, error) {
+	meter := mp.Meter(instrumentationName)
+
+	var i Instrumentation
+	var err error
+
+	if i.lifecycleDuration, err = meter.Float64Histogram("shdep.lifecycle.duration",
+		metric.WithDescription("Duration of lifecycle methods of shared objects."),
+		metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("failed to create lifecycle duration histogram: %w", err)
+	}
+
+	if i.propagationDuration, err = meter.Float64Histogram("shdep.propagation.duration",
+		metric.WithDescription("Duration of propagation of update through the tree."),
+		metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("failed to create propagation duration histogram: %w", err)
+	}
+
+	if i.nodesUpdated, err = meter.Int64Histogram("shdep.propagation.nodes",
+		metric.WithDescription("Number of nodes updated during propagation of update."),
+		metric.WithUnit("{node}")); err != nil {
+		return nil, fmt.Errorf("failed to create propagation nodes histogram: %w", err)
+	}
+
+	if i.handlerDuration, err = meter.Float64Histogram("shdep.handler.duration",
+		metric.WithDescription("Duration of update handlers of nodes."),
+		metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("failed to create handler duration histogram: %w", err)
+	}
+
+	return &i, nil
+}
+
+func (i *Instrumentation) LifecyclePhaseFinished(phase objstore.LifecyclePhase, obj interface{}, duration time.Duration, err error) {
+	i.lifecycleDuration.Record(context.Background(), duration.Seconds(), metric.WithAttributes(
+		attribute.String("shdep.phase", string(phase)),
+		attribute.String("shdep.object.type", fmt.Sprintf("%T", obj)),
+		attribute.Bool("error", err != nil),
+	))
+}
+
+func (i *Instrumentation) PropagationFinished(source string, nodesUpdated int, duration time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("shdep.node", source))
+	i.propagationDuration.Record(context.Background(), duration.Seconds(), attrs)
+	i.nodesUpdated.Record(context.Background(), int64(nodesUpdated), attrs)
+}
+
+func (i *Instrumentation) UpdateHandled(node string, duration time.Duration) {
+	i.handlerDuration.Record(context.Background(), duration.Seconds(), metric.WithAttributes(
+		attribute.String("shdep.node", node),
+	))
+}
+
+// WithMeterProvider returns option of the store, which records durations of lifecycle methods of objects.
+// Errors of creation of instruments are reported to otel.Handle, and then the option does nothing.
+func WithMeterProvider(mp metric.MeterProvider) objstore.StoreOption {
+	i, err := New(mp)
+	if err != nil {
+		otel.Handle(err)
+		return objstore.WithObserver(nil)
+	}
+
+	return objstore.WithObserver(i)
+}
+
+// InstrumentPropagation sets observer of update propagation tree, which records durations of propagations and
+// of update handlers. Observer is set for the whole process.
+func InstrumentPropagation(mp metric.MeterProvider) error {
+	i, err := New(mp)
+	if err != nil {
+		return err
+	}
+
+	updtree.SetObserver(i)
+
+	return nil
+}
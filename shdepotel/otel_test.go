@@ -0,0 +1,89 @@
+package shdepotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepotel"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+
+type Provider struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+}
+
+type Consumer struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	provider *Provider
+}
+
+func (c *Consumer) RegisterDependencies(s SharedStore) {
+	s.Register(&c.provider)
+}
+
+func (c *Consumer) Init(p *InitParams) error {
+	c.provider.SubscribeObj(c)
+	c.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {})
+	return nil
+}
+
+// Not parallel, because propagation observer is set for the whole process.
+func TestInstrumentation(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	require.NoError(t, shdepotel.InstrumentPropagation(mp))
+	defer updtree.SetObserver(nil)
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil, shdepotel.WithMeterProvider(mp))
+	consumer := &Consumer{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("Consumer", 1),
+		provider: &Provider{
+			SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("Provider", 1),
+		},
+	}
+	store.Register(&consumer)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	consumer.provider.NotifyUpdated(context.Background(), time.Time{})
+	consumer.provider.NotifyUpdated(context.Background(), time.Time{})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	counts := map[string]uint64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Histogram[float64]:
+			for _, dp := range data.DataPoints {
+				counts[m.Name] += dp.Count
+			}
+		case metricdata.Histogram[int64]:
+			for _, dp := range data.DataPoints {
+				counts[m.Name] += dp.Count
+				require.Equal(t, int64(2), dp.Sum)
+			}
+		}
+	}
+
+	require.Equal(t, map[string]uint64{
+		// Gathering requirements, Init and Start of two objects
+		"shdep.lifecycle.duration":   6,
+		"shdep.propagation.duration": 2,
+		"shdep.propagation.nodes":    2,
+		"shdep.handler.duration":     2,
+	}, counts)
+}
@@ -0,0 +1,130 @@
+// Package shdepprom provides Prometheus collector of shared objects store metrics.
+package shdepprom
+
+import (
+	"sync"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	lock      sync.Locker
+	namespace string
+}
+
+type Option func(c *config)
+
+// WithLock sets lock, which is held while reading the store.
+// See objstore.ReadLocked for which lock it must be.
+func WithLock(l sync.Locker) Option {
+	return func(c *config) {
+		c.lock = l
+	}
+}
+
+// WithNamespace sets namespace of metrics. Default is "shdep".
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// NewCollector returns collector of metrics of objects of the store.
+// Updates and events metrics are collected for objects based on shdep.SharedObjectBase.
+// Handler durations are collected only if enabled by updtree.SetMeasureHandlerDurations.
+func NewCollector(store objstore.Describer, opts ...Option) prometheus.Collector {
+	c := &config{namespace: "shdep"}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	objLabels := []string{"store", "object", "type"}
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(c.namespace, "", name), help, labels, nil)
+	}
+
+	return &collector{
+		store:  store,
+		config: c,
+
+		objects:         desc("objects", "Number of objects in the store by lifecycle state.", []string{"state"}),
+		initDuration:    desc("object_init_duration_seconds", "Duration of initialization of the object.", objLabels),
+		notifications:   desc("node_notifications_total", "Number of update notifications sent by the object.", objLabels),
+		updatesHandled:  desc("node_updates_handled_total", "Number of updates of subscriptions handled by the object.", objLabels),
+		handlerDuration: desc("node_handler_seconds_total", "Total time spent in update handler of the object.", objLabels),
+		eventsBuffer:    desc("events_buffer_size", "Number of events of the object not yet pulled by all pullers.", objLabels),
+		eventsCapacity:  desc("events_buffer_capacity", "Number of events, for which memory is retained by the object.", objLabels),
+		eventsPublished: desc("events_published_total", "Number of events published by the object.", objLabels),
+		eventPullers:    desc("event_pullers", "Number of event pullers of the object.", objLabels),
+	}
+}
+
+type collector struct {
+	store  objstore.Describer
+	config *config
+
+	objects         *prometheus.Desc
+	initDuration    *prometheus.Desc
+	notifications   *prometheus.Desc
+	updatesHandled  *prometheus.Desc
+	handlerDuration *prometheus.Desc
+	eventsBuffer    *prometheus.Desc
+	eventsCapacity  *prometheus.Desc
+	eventsPublished *prometheus.Desc
+	eventPullers    *prometheus.Desc
+}
+
+var _ prometheus.Collector = &collector{}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.objects
+	ch <- c.initDuration
+	ch <- c.notifications
+	ch <- c.updatesHandled
+	ch <- c.handlerDuration
+	ch <- c.eventsBuffer
+	ch <- c.eventsCapacity
+	ch <- c.eventsPublished
+	ch <- c.eventPullers
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	desc := c.describe()
+
+	objectsByState := make(map[objstore.ObjectState]int)
+	for _, obj := range desc.Objects {
+		objectsByState[obj.State]++
+	}
+
+	for state := objstore.ObjectRegistered; state <= objstore.ObjectSkipped; state++ {
+		ch <- prometheus.MustNewConstMetric(c.objects, prometheus.GaugeValue, float64(objectsByState[state]), state.String())
+	}
+
+	for _, obj := range desc.Objects {
+		labels := []string{obj.Store, obj.ID, obj.Type}
+
+		if obj.State != objstore.ObjectRegistered {
+			ch <- prometheus.MustNewConstMetric(c.initDuration, prometheus.GaugeValue, obj.InitDuration.Seconds(), labels...)
+		}
+
+		if updates, ok := obj.Info[shdep.DebugInfoUpdates].(updtree.NodeStats); ok {
+			ch <- prometheus.MustNewConstMetric(c.notifications, prometheus.CounterValue, float64(updates.Notifications), labels...)
+			ch <- prometheus.MustNewConstMetric(c.updatesHandled, prometheus.CounterValue, float64(updates.UpdatesHandled), labels...)
+			ch <- prometheus.MustNewConstMetric(c.handlerDuration, prometheus.CounterValue, updates.HandlerDuration.Seconds(), labels...)
+		}
+
+		if events, ok := obj.Info[shdep.DebugInfoEvents].(updtree.EventsStats); ok {
+			ch <- prometheus.MustNewConstMetric(c.eventsBuffer, prometheus.GaugeValue, float64(events.BufferSize), labels...)
+			ch <- prometheus.MustNewConstMetric(c.eventsCapacity, prometheus.GaugeValue, float64(events.BufferCapacity), labels...)
+			ch <- prometheus.MustNewConstMetric(c.eventsPublished, prometheus.CounterValue, float64(events.EventsPushed), labels...)
+			ch <- prometheus.MustNewConstMetric(c.eventPullers, prometheus.GaugeValue, float64(events.Pullers), labels...)
+		}
+	}
+}
+
+func (c *collector) describe() objstore.StoreDescription {
+	return objstore.DescribeLocked(c.store, c.config.lock)
+}
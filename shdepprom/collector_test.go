@@ -0,0 +1,122 @@
+package shdepprom_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+
+type Provider struct {
+	shdep.SharedObjectBaseWithEvent[context.Context, *InitParams, int]
+}
+
+func NewProvider() *Provider {
+	return &Provider{
+		SharedObjectBaseWithEvent: shdep.NewSharedObjectBaseWithEvent[context.Context, *InitParams, int]("Provider", 1),
+	}
+}
+
+type Consumer struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	provider *Provider
+	events   shdep.EventPuller[int]
+}
+
+func NewConsumer() *Consumer {
+	return &Consumer{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("Consumer", 1),
+		provider:         NewProvider(),
+	}
+}
+
+func (c *Consumer) RegisterDependencies(s SharedStore) {
+	s.Register(&c.provider)
+}
+
+func (c *Consumer) Init(p *InitParams) error {
+	c.events = c.provider.NewEventPuller()
+	c.provider.SubscribeObj(c)
+	c.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {})
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	store := shdep.NewSharedStore[context.Context, *InitParams](nil)
+	consumer := NewConsumer()
+	store.Register(&consumer)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	consumer.provider.PublishEvent(context.Background(), time.Time{}, 1)
+	consumer.provider.PublishEvent(context.Background(), time.Time{}, 2)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(shdepprom.NewCollector(store))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP shdep_objects Number of objects in the store by lifecycle state.
+# TYPE shdep_objects gauge
+shdep_objects{state="closed"} 0
+shdep_objects{state="failed"} 0
+shdep_objects{state="initialized"} 0
+shdep_objects{state="registered"} 0
+shdep_objects{state="skipped"} 0
+shdep_objects{state="started"} 2
+shdep_objects{state="stopped"} 0
+`), "shdep_objects")
+	require.NoError(t, err)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	require.Equal(t, 2.0, metricValue(t, families, "shdep_node_notifications_total", "*shdepprom_test.Provider"))
+	require.Equal(t, 2.0, metricValue(t, families, "shdep_node_updates_handled_total", "*shdepprom_test.Consumer"))
+	require.Equal(t, 2.0, metricValue(t, families, "shdep_events_buffer_size", "*shdepprom_test.Provider"))
+	require.Equal(t, 2.0, metricValue(t, families, "shdep_events_published_total", "*shdepprom_test.Provider"))
+	require.Equal(t, 1.0, metricValue(t, families, "shdep_event_pullers", "*shdepprom_test.Provider"))
+
+	consumer.events.Pull()
+	families, err = reg.Gather()
+	require.NoError(t, err)
+	require.Equal(t, 0.0, metricValue(t, families, "shdep_events_buffer_size", "*shdepprom_test.Provider"))
+}
+
+func metricValue(t *testing.T, families []*dto.MetricFamily, name, objType string) float64 {
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() != "type" || label.GetValue() != objType {
+					continue
+				}
+
+				if m.GetCounter() != nil {
+					return m.GetCounter().GetValue()
+				}
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	require.Failf(t, "metric not found", "%v of %v", name, objType)
+	return 0
+}
@@ -0,0 +1,24 @@
+package shdepremote
+
+import "encoding/json"
+
+// Codec encodes events of remote objects for transport.
+type Codec[Event any] interface {
+	Encode(evt Event) ([]byte, error)
+	Decode(data []byte) (Event, error)
+}
+
+// JSONCodec encodes events as JSON.
+type JSONCodec[Event any] struct{}
+
+var _ Codec[int] = JSONCodec[int]{}
+
+func (JSONCodec[Event]) Encode(evt Event) ([]byte, error) {
+	return json.Marshal(evt)
+}
+
+func (JSONCodec[Event]) Decode(data []byte) (Event, error) {
+	var evt Event
+	err := json.Unmarshal(data, &evt)
+	return evt, err
+}
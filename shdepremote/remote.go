@@ -0,0 +1,181 @@
+// Package shdepremote allows to share objects between processes: an object is hosted by one process,
+// which exports its updates and events over a transport, and other processes use local proxy in place of it.
+// Proxy is a regular shared object, so local subscribers receive remote updates through their update trees.
+package shdepremote
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/pkg/errors"
+)
+
+type config struct {
+	lock           sync.Locker
+	failureHandler utils.FailureHandler
+}
+
+type Option func(c *config)
+
+// WithLock sets lock, which is held while remote update is propagated through local update tree.
+// Update tree is not thread-safe, so the lock must be the one protecting it from concurrent updates.
+func WithLock(l sync.Locker) Option {
+	return func(c *config) {
+		c.lock = l
+	}
+}
+
+// WithFailureHandler sets handler of transport and encoding errors. By default utils.Fail is used.
+func WithFailureHandler(h utils.FailureHandler) Option {
+	return func(c *config) {
+		c.failureHandler = h
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{failureHandler: utils.Fail}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Subscribable is implemented by shared objects based on shdep.SharedObjectBase.
+type Subscribable[Ctx any] interface {
+	Subscribe(subscriber updtree.Node[Ctx]) *updtree.Subscription
+}
+
+// EventSource is implemented by shared objects based on shdep.SharedObjectBaseWithEvent.
+type EventSource[Ctx, Event any] interface {
+	Subscribable[Ctx]
+	NewEventPuller() shdep.EventPuller[Event]
+}
+
+// ExportUpdates publishes notifications about updates of the object into transport under given ID.
+// Must be called after store is started, with the replica of the object used by the store.
+func ExportUpdates[Ctx any](obj Subscribable[Ctx], object string, t Transport, opts ...Option) {
+	c := newConfig(opts)
+
+	node := updtree.NewNode[Ctx]("export-"+object, func(ctx Ctx, evtTime time.Time) {
+		if err := t.Publish(Message{Object: object, Time: evtTime}); err != nil {
+			c.failureHandler(errors.Wrapf(err, "failed to publish update of %v", object))
+		}
+	})
+
+	obj.Subscribe(node)
+}
+
+// Export publishes updates and events of the object into transport under given ID.
+// Each event is published as separate message. Update without new events is published as message without payload.
+// Must be called after store is started, with the replica of the object used by the store.
+func Export[Ctx, Event any](obj EventSource[Ctx, Event], object string, t Transport, codec Codec[Event], opts ...Option) {
+	c := newConfig(opts)
+	puller := obj.NewEventPuller()
+
+	node := updtree.NewNode[Ctx]("export-"+object, func(ctx Ctx, evtTime time.Time) {
+		events := puller.Pull()
+		if len(events) == 0 {
+			if err := t.Publish(Message{Object: object, Time: evtTime}); err != nil {
+				c.failureHandler(errors.Wrapf(err, "failed to publish update of %v", object))
+			}
+			return
+		}
+
+		for _, evt := range events {
+			payload, err := codec.Encode(*evt.Event)
+			if err != nil {
+				c.failureHandler(errors.Wrapf(err, "failed to encode event of %v", object))
+				continue
+			}
+
+			if err := t.Publish(Message{Object: object, Time: evtTime, Payload: payload}); err != nil {
+				c.failureHandler(errors.Wrapf(err, "failed to publish event of %v", object))
+			}
+		}
+	})
+
+	obj.Subscribe(node)
+}
+
+// NewProxy creates shared object, which mirrors updates and events of the remote object exported under given ID.
+// Proxies of the same remote object are shared by the store like any other objects.
+// Function newCtx creates context for propagation of remote updates through local update tree.
+// Codec can be nil, if remote object is exported using ExportUpdates.
+func NewProxy[Ctx, InitParams, Event any](object string, t Transport, codec Codec[Event], newCtx func() Ctx, opts ...Option) *Proxy[Ctx, InitParams, Event] {
+	return &Proxy[Ctx, InitParams, Event]{
+		SharedObjectBaseWithEvent: shdep.NewSharedObjectBaseWithEvent[Ctx, InitParams, Event]("remote", object),
+		object:                    object,
+		transport:                 t,
+		codec:                     codec,
+		newCtx:                    newCtx,
+		config:                    newConfig(opts),
+	}
+}
+
+// Proxy is a local shared object backed by remote object in another process.
+// It subscribes to the remote object on Start and unsubscribes on Stop.
+type Proxy[Ctx, InitParams, Event any] struct {
+	shdep.SharedObjectBaseWithEvent[Ctx, InitParams, Event]
+	object      string
+	transport   Transport
+	codec       Codec[Event]
+	newCtx      func() Ctx
+	config      *config
+	unsubscribe func()
+}
+
+var _ shdep.SharedObject[interface{}, interface{}] = &Proxy[interface{}, interface{}, int]{}
+
+// Object returns ID of remote object.
+func (p *Proxy[Ctx, InitParams, Event]) Object() string {
+	return p.object
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (p *Proxy[Ctx, InitParams, Event]) Start(params InitParams) error {
+	unsubscribe, err := p.transport.Subscribe(p.object, p.handleMessage)
+	if err != nil {
+		return errors.Wrapf(err, "failed to subscribe to remote object %v", p.object)
+	}
+
+	p.unsubscribe = unsubscribe
+
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (p *Proxy[Ctx, InitParams, Event]) Stop() {
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+}
+
+func (p *Proxy[Ctx, InitParams, Event]) handleMessage(msg Message) {
+	if p.config.lock != nil {
+		p.config.lock.Lock()
+		defer p.config.lock.Unlock()
+	}
+
+	if len(msg.Payload) == 0 {
+		p.NotifyUpdated(p.newCtx(), msg.Time)
+		return
+	}
+
+	if p.codec == nil {
+		p.config.failureHandler(errors.Errorf("received event of remote object %v, but proxy has no codec", p.object))
+		return
+	}
+
+	evt, err := p.codec.Decode(msg.Payload)
+	if err != nil {
+		p.config.failureHandler(errors.Wrapf(err, "failed to decode event of remote object %v", p.object))
+		return
+	}
+
+	p.PublishEvent(p.newCtx(), msg.Time, evt)
+}
@@ -0,0 +1,148 @@
+package shdepremote_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepremote"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+type PriceProxy = shdepremote.Proxy[context.Context, *InitParams, float64]
+
+type provider struct {
+	shdep.SharedObjectBaseWithEvent[context.Context, *InitParams, float64]
+}
+
+func newProvider() *provider {
+	return &provider{
+		SharedObjectBaseWithEvent: shdep.NewSharedObjectBaseWithEvent[context.Context, *InitParams, float64]("provider", "prices"),
+	}
+}
+
+type consumer struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	name    string
+	prices  *PriceProxy
+	puller  shdep.EventPuller[float64]
+	updates int
+	events  []float64
+}
+
+func newConsumer(name string, transport shdepremote.Transport, lock sync.Locker) *consumer {
+	c := &consumer{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("consumer", name),
+		name:             name,
+		prices: shdepremote.NewProxy[context.Context, *InitParams](
+			"prices", transport, shdepremote.JSONCodec[float64]{}, context.Background, shdepremote.WithLock(lock)),
+	}
+
+	c.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		c.updates++
+		for _, evt := range c.puller.Pull() {
+			c.events = append(c.events, *evt.Event)
+		}
+	})
+
+	return c
+}
+
+func (c *consumer) RegisterDependencies(store SharedStore) {
+	store.Register(&c.prices)
+	c.prices.SubscribeObj(c)
+	c.puller = c.prices.NewEventPuller()
+}
+
+func newStore() shdep.SharedStore[context.Context, *InitParams] {
+	return shdep.NewSharedStore[context.Context, *InitParams](nil)
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	transport := shdepremote.NewLocalTransport()
+
+	// Host process.
+	hostStore := newStore()
+	prices := newProvider()
+	hostStore.Register(&prices)
+	require.NoError(t, hostStore.Init(&InitParams{}))
+	require.NoError(t, hostStore.Start())
+
+	shdepremote.Export[context.Context, float64](prices, "prices", transport, shdepremote.JSONCodec[float64]{})
+
+	// Client process.
+	var lock sync.Mutex
+	clientStore := newStore()
+	c1 := newConsumer("c1", transport, &lock)
+	c2 := newConsumer("c2", transport, &lock)
+	clientStore.Register(&c1)
+	clientStore.Register(&c2)
+	require.NoError(t, clientStore.Init(&InitParams{}))
+	require.NoError(t, clientStore.Start())
+
+	require.Same(t, c1.prices, c2.prices, "proxy of the same remote object must be shared")
+
+	prices.PublishEvent(context.Background(), time.Now(), 1.5)
+	prices.PublishEvent(context.Background(), time.Now(), 2.5)
+	prices.NotifyUpdated(context.Background(), time.Now())
+
+	for _, c := range []*consumer{c1, c2} {
+		require.Equal(t, 3, c.updates)
+		require.Equal(t, []float64{1.5, 2.5}, c.events)
+	}
+
+	clientStore.Stop()
+	clientStore.Close()
+
+	prices.PublishEvent(context.Background(), time.Now(), 3.5)
+	require.Equal(t, 3, c1.updates, "proxy must unsubscribe on stop")
+}
+
+func TestExportUpdates(t *testing.T) {
+	t.Parallel()
+
+	transport := shdepremote.NewLocalTransport()
+
+	counter := shdep.NewSharedObjectBase[context.Context, *InitParams]("counter", 1)
+	shdepremote.ExportUpdates[context.Context](&counter, "counter", transport)
+
+	var received []shdepremote.Message
+	unsubscribe, err := transport.Subscribe("counter", func(msg shdepremote.Message) {
+		received = append(received, msg)
+	})
+	require.NoError(t, err)
+
+	evtTime := time.Unix(100, 0)
+	counter.NotifyUpdated(context.Background(), evtTime)
+
+	unsubscribe()
+	counter.NotifyUpdated(context.Background(), evtTime)
+
+	require.Equal(t, []shdepremote.Message{{Object: "counter", Time: evtTime}}, received)
+}
+
+func TestProxy_DecodeFailure(t *testing.T) {
+	t.Parallel()
+
+	transport := shdepremote.NewLocalTransport()
+
+	var failures []error
+	proxy := shdepremote.NewProxy[context.Context, *InitParams](
+		"prices", transport, shdepremote.JSONCodec[float64]{}, context.Background,
+		shdepremote.WithFailureHandler(func(err error) { failures = append(failures, err) }))
+	require.NoError(t, proxy.Start(&InitParams{}))
+	defer proxy.Stop()
+
+	require.NoError(t, transport.Publish(shdepremote.Message{Object: "prices", Payload: []byte("not a number")}))
+	require.Len(t, failures, 1)
+	require.Contains(t, failures[0].Error(), "failed to decode event of remote object prices")
+}
@@ -0,0 +1,82 @@
+package shdepremote
+
+import (
+	"sync"
+	"time"
+)
+
+// Message carries single update of remote object.
+type Message struct {
+	// Object is an ID of remote object, under which it is exported.
+	Object string
+
+	// Time is an event time passed to NotifyUpdated.
+	Time time.Time
+
+	// Payload is encoded event. It is empty if object was updated without publishing an event.
+	Payload []byte
+}
+
+// Transport delivers messages between processes, e.g. over gRPC stream or websocket.
+// Implementations must be safe for concurrent use.
+type Transport interface {
+	// Publish sends message to all subscribers of the object.
+	Publish(msg Message) error
+
+	// Subscribe starts delivery of messages of the object to handler. Handler can be called from any goroutine,
+	// but messages of the same object must be delivered sequentially and in order of publishing.
+	Subscribe(object string, handler func(msg Message)) (unsubscribe func(), err error)
+}
+
+// NewLocalTransport creates transport, which delivers messages within the process.
+// It is useful for tests and for sharing objects between independent stores of the same process.
+// Messages are delivered synchronously from Publish.
+func NewLocalTransport() *LocalTransport {
+	return &LocalTransport{subscribers: make(map[string]map[int]func(msg Message))}
+}
+
+type LocalTransport struct {
+	lock        sync.RWMutex
+	subscribers map[string]map[int]func(msg Message)
+	lastID      int
+}
+
+var _ Transport = &LocalTransport{}
+
+func (t *LocalTransport) Publish(msg Message) error {
+	t.lock.RLock()
+	handlers := make([]func(msg Message), 0, len(t.subscribers[msg.Object]))
+	for _, handler := range t.subscribers[msg.Object] {
+		handlers = append(handlers, handler)
+	}
+	t.lock.RUnlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+
+	return nil
+}
+
+func (t *LocalTransport) Subscribe(object string, handler func(msg Message)) (unsubscribe func(), err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.lastID++
+	id := t.lastID
+
+	if t.subscribers[object] == nil {
+		t.subscribers[object] = make(map[int]func(msg Message))
+	}
+	t.subscribers[object][id] = handler
+
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		delete(t.subscribers[object], id)
+		if len(t.subscribers[object]) == 0 {
+			delete(t.subscribers, object)
+		}
+	}, nil
+}
@@ -0,0 +1,150 @@
+package shdeptest
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/shdepexec"
+)
+
+// NewClock creates fake clock, which shows given time until it is advanced.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Clock is a fake clock for deterministic tests of time-dependent objects.
+// Timers fire synchronously from Advance in order of their deadlines.
+type Clock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*clockTimer
+	lastID int
+}
+
+type clockTimer struct {
+	id       int
+	deadline time.Time
+	f        func(now time.Time)
+}
+
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// AfterFunc schedules f to be called, when clock is advanced by d or more.
+// Returned function cancels timer and reports whether it was cancelled before firing.
+func (c *Clock) AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lastID++
+	id := c.lastID
+	c.timers = append(c.timers, &clockTimer{id: id, deadline: c.now.Add(d), f: f})
+
+	return func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		idx := slices.IndexFunc(c.timers, func(t *clockTimer) bool { return t.id == id })
+		if idx < 0 {
+			return false
+		}
+
+		c.timers = slices.Delete(c.timers, idx, idx+1)
+		return true
+	}
+}
+
+// Advance moves clock forward by d and fires timers, which deadlines are reached.
+// Before firing each timer, clock is set to its deadline. Timers scheduled by fired timers also fire,
+// if their deadlines are reached.
+func (c *Clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	target := c.now.Add(d)
+	c.lock.Unlock()
+
+	for {
+		c.lock.Lock()
+
+		next := -1
+		for i, t := range c.timers {
+			if t.deadline.After(target) {
+				continue
+			}
+			if next < 0 || t.deadline.Before(c.timers[next].deadline) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			c.now = target
+			c.lock.Unlock()
+			return
+		}
+
+		t := c.timers[next]
+		c.timers = slices.Delete(c.timers, next, next+1)
+		if t.deadline.After(c.now) {
+			c.now = t.deadline
+		}
+		now := c.now
+
+		c.lock.Unlock()
+
+		t.f(now)
+	}
+}
+
+// NewScheduler creates executor, which runs posted tasks only when RunPending is called.
+func NewScheduler[Ctx any](ctx Ctx) *Scheduler[Ctx] {
+	return &Scheduler[Ctx]{ctx: ctx}
+}
+
+// Scheduler is a fake executor, which allows test to decide when posted tasks are executed.
+type Scheduler[Ctx any] struct {
+	ctx   Ctx
+	lock  sync.Mutex
+	tasks []func(ctx Ctx)
+}
+
+var _ shdepexec.Executor[int] = &Scheduler[int]{}
+
+func (s *Scheduler[Ctx]) Post(task func(ctx Ctx)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tasks = append(s.tasks, task)
+}
+
+// Pending returns number of posted tasks, which are not executed yet.
+func (s *Scheduler[Ctx]) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.tasks)
+}
+
+// RunPending executes posted tasks in order of posting, including tasks posted by executed tasks,
+// until the queue is empty. Returns number of executed tasks.
+func (s *Scheduler[Ctx]) RunPending() int {
+	executed := 0
+
+	for {
+		s.lock.Lock()
+		if len(s.tasks) == 0 {
+			s.lock.Unlock()
+			return executed
+		}
+
+		task := s.tasks[0]
+		s.tasks = s.tasks[1:]
+		s.lock.Unlock()
+
+		task(s.ctx)
+		executed++
+	}
+}
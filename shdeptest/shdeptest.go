@@ -0,0 +1,140 @@
+// Package shdeptest provides helpers for testing shared objects.
+package shdeptest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepexec"
+)
+
+// TB is a subset of testing.TB used by helpers.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Cleanup(f func())
+}
+
+// Lifecycle is implemented by stores.
+type Lifecycle[InitParams any] = objstore.Lifecycle[InitParams]
+
+// Run initializes and starts the store, failing the test on error.
+// Store is stopped and closed when the test finishes.
+func Run[InitParams any](t TB, store Lifecycle[InitParams], params InitParams) {
+	t.Helper()
+
+	if err := store.Init(params); err != nil {
+		_ = store.CloseE()
+		t.Fatalf("failed to initialize store: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := store.StopE(); err != nil {
+			t.Errorf("failed to stop store: %v", err)
+			return
+		}
+
+		if err := store.CloseE(); err != nil {
+			t.Errorf("failed to close store: %v", err)
+		}
+	})
+
+	if err := store.Start(); err != nil {
+		t.Fatalf("failed to start store: %v", err)
+	}
+}
+
+// NewStore creates store, registers objects in it and runs it (see Run).
+// Objects are passed as pointers to pointers, same as into Register, and are replaced with their shared replicas.
+func NewStore[Ctx, InitParams any](t TB, params InitParams, objects ...interface{}) *objstore.GenericStore[shdep.SharedObject[Ctx, InitParams], string, InitParams] {
+	t.Helper()
+
+	store := shdep.NewSharedStore[Ctx, InitParams](nil)
+	for _, obj := range objects {
+		store.Register(obj)
+	}
+
+	Run[InitParams](t, store, params)
+
+	return store
+}
+
+// NotifyAndWait posts notification about update of the object into executor and waits until
+// propagation of the update is finished. Executor must run tasks asynchronously, e.g. shdepexec.Loop.
+// For Scheduler use RunPending instead.
+func NotifyAndWait[Ctx any](e shdepexec.Executor[Ctx], obj shdepexec.Notifier[Ctx], evtTime time.Time) {
+	done := make(chan struct{})
+
+	e.Post(func(ctx Ctx) {
+		defer close(done)
+		obj.NotifyUpdated(ctx, evtTime)
+	})
+
+	<-done
+}
+
+// AssertShared checks that a and b are the same object, e.g. replicas of the same dependency of different objects.
+func AssertShared(t TB, a, b interface{}) bool {
+	t.Helper()
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Pointer || vb.Kind() != reflect.Pointer {
+		t.Errorf("objects must be pointers: %T, %T", a, b)
+		return false
+	}
+
+	if va.Type() != vb.Type() || va.Pointer() != vb.Pointer() {
+		t.Errorf("objects are not shared: %T(%p) and %T(%p)", a, a, b, b)
+		return false
+	}
+
+	return true
+}
+
+// AssertInitOrder checks that objects were initialized in given order. Other objects of the store
+// may be initialized in between. Objects are identified by shdep.ObjectID or passed as IDs of the store.
+func AssertInitOrder(t TB, store objstore.Describer, objects ...interface{}) bool {
+	t.Helper()
+
+	ids := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case string:
+			ids = append(ids, obj)
+		case interface{ Hash() string }:
+			ids = append(ids, shdep.ObjectID(obj))
+		default:
+			ids = append(ids, fmt.Sprint(obj))
+		}
+	}
+
+	initOrder := make([]string, 0)
+	for _, obj := range store.Describe().Objects {
+		if obj.State != objstore.ObjectRegistered {
+			initOrder = append(initOrder, obj.ID)
+		}
+	}
+
+	prevIdx := -1
+	for _, id := range ids {
+		idx := slices.Index(initOrder, id)
+		if idx < 0 {
+			t.Errorf("object %v is not initialized, initialization order: %v", id, initOrder)
+			return false
+		}
+
+		if idx < prevIdx {
+			t.Errorf("wrong initialization order: expected %v, actual %v", ids, initOrder)
+			return false
+		}
+
+		prevIdx = idx
+	}
+
+	return true
+}
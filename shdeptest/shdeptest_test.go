@@ -0,0 +1,183 @@
+package shdeptest_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepexec"
+	"github.com/nnikolash/go-shdep/shdeptest"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type SharedObject = shdep.SharedObject[context.Context, *InitParams]
+type SharedStore = objstore.SharedStore[SharedObject, *InitParams]
+
+type counter struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	value int
+}
+
+func newCounter() *counter {
+	return &counter{SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("counter", 1)}
+}
+
+type doubler struct {
+	shdep.SharedObjectBase[context.Context, *InitParams]
+	counter *counter
+	value   int
+}
+
+func newDoubler(name string) *doubler {
+	d := &doubler{
+		SharedObjectBase: shdep.NewSharedObjectBase[context.Context, *InitParams]("doubler", name),
+		counter:          newCounter(),
+	}
+
+	d.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		d.value = d.counter.value * 2
+	})
+
+	return d
+}
+
+func (d *doubler) RegisterDependencies(store SharedStore) {
+	store.Register(&d.counter)
+	d.counter.SubscribeObj(d)
+}
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestStoreAndAssertions(t *testing.T) {
+	t.Parallel()
+
+	d1 := newDoubler("d1")
+	d2 := newDoubler("d2")
+	store := shdeptest.NewStore[context.Context](t, &InitParams{}, &d1, &d2)
+
+	shdeptest.AssertShared(t, d1.counter, d2.counter)
+	shdeptest.AssertInitOrder(t, store, d1.counter, d1, d2)
+
+	failures := &fakeTB{TB: t}
+	require.False(t, shdeptest.AssertShared(failures, d1, d2))
+	require.False(t, shdeptest.AssertInitOrder(failures, store, d1, d1.counter))
+	require.False(t, shdeptest.AssertInitOrder(failures, store, newDoubler("unknown")))
+	require.Len(t, failures.errors, 3)
+	require.Contains(t, failures.errors[1], "wrong initialization order")
+	require.Contains(t, failures.errors[2], "is not initialized")
+}
+
+func TestNotifyAndWait(t *testing.T) {
+	t.Parallel()
+
+	d := newDoubler("d")
+	shdeptest.NewStore[context.Context](t, &InitParams{}, &d)
+
+	loop := shdepexec.NewLoop(context.Background())
+	defer loop.Close()
+
+	d.counter.value = 21
+	shdeptest.NotifyAndWait[context.Context](loop, d.counter, time.Now())
+	require.Equal(t, 42, d.value)
+}
+
+func TestClockAndScheduler(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1000, 0)
+	clock := shdeptest.NewClock(start)
+	scheduler := shdeptest.NewScheduler(context.Background())
+
+	d := newDoubler("d")
+	shdeptest.NewStore[context.Context](t, &InitParams{}, &d)
+
+	// Timer emulates periodic external update, which is posted into the scheduler.
+	var tick func(now time.Time)
+	tick = func(now time.Time) {
+		d.counter.value++
+		shdepexec.NotifyUpdated[context.Context](scheduler, d.counter, now)
+		clock.AfterFunc(time.Second, tick)
+	}
+	clock.AfterFunc(time.Second, tick)
+
+	cancelled := clock.AfterFunc(time.Minute, func(now time.Time) { t.Fatal("cancelled timer fired") })
+	require.True(t, cancelled())
+	require.False(t, cancelled())
+
+	clock.Advance(500 * time.Millisecond)
+	require.Equal(t, 0, scheduler.Pending())
+
+	clock.Advance(3 * time.Second)
+	require.Equal(t, start.Add(3500*time.Millisecond), clock.Now())
+	require.Equal(t, 3, scheduler.Pending())
+	require.Equal(t, 0, d.value, "updates must not propagate until scheduler runs them")
+
+	require.Equal(t, 3, scheduler.RunPending())
+	require.Equal(t, 6, d.value)
+}
+
+// postingNotifier posts notifications into executor, so it can be notified without locking.
+type postingNotifier struct {
+	executor shdepexec.Executor[context.Context]
+	obj      shdepexec.Notifier[context.Context]
+}
+
+func (n *postingNotifier) NotifyUpdated(ctx context.Context, evtTime time.Time) {
+	shdepexec.NotifyUpdated(n.executor, n.obj, evtTime)
+}
+
+func TestStress(t *testing.T) {
+	t.Parallel()
+
+	const goroutines = 8
+	const iterations = 100
+
+	d1 := newDoubler("d1")
+	d2 := newDoubler("d2")
+	shdeptest.NewStore[context.Context](t, &InitParams{}, &d1, &d2)
+
+	handled := 0
+	d1.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		handled++
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		var lock sync.Mutex
+		shdeptest.Stress[context.Context](context.Background(), shdeptest.StressConfig{
+			Goroutines: goroutines,
+			Iterations: iterations,
+			Lock:       &lock,
+		}, d1.counter)
+
+		require.Equal(t, goroutines*iterations, handled)
+	})
+
+	t.Run("Executor", func(t *testing.T) {
+		handled = 0
+
+		loop := shdepexec.NewLoop(context.Background())
+		shdeptest.Stress[context.Context](context.Background(), shdeptest.StressConfig{
+			Goroutines: goroutines,
+			Iterations: iterations,
+		}, &postingNotifier{executor: loop, obj: d1.counter})
+		loop.Close()
+
+		require.Equal(t, goroutines*iterations, handled)
+	})
+}
@@ -0,0 +1,61 @@
+package shdeptest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/shdepexec"
+)
+
+// StressConfig configures Stress.
+type StressConfig struct {
+	// Number of goroutines notifying about updates concurrently.
+	Goroutines int
+
+	// Number of notifications sent by each goroutine.
+	Iterations int
+
+	// Lock, which is held during each notification, e.g. the one protecting update tree from external updates.
+	// If nil, notifications are sent without locking, which is valid only for sources, which are safe
+	// for concurrent use themselves, e.g. posting updates into an executor.
+	Lock sync.Locker
+}
+
+// Stress notifies sources about updates from multiple goroutines concurrently and waits until all
+// notifications are sent. Goroutines iterate over sources starting from different ones.
+// It is intended to be run under race detector (go test -race) to validate that graph is wired
+// in a thread-safe way: data races in handlers are reported by the race detector as test failures.
+func Stress[Ctx any](ctx Ctx, cfg StressConfig, sources ...shdepexec.Notifier[Ctx]) {
+	if len(sources) == 0 {
+		return
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for g := 0; g < cfg.Goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			for i := 0; i < cfg.Iterations; i++ {
+				source := sources[(g+i)%len(sources)]
+
+				if cfg.Lock != nil {
+					cfg.Lock.Lock()
+				}
+
+				source.NotifyUpdated(ctx, time.Now())
+
+				if cfg.Lock != nil {
+					cfg.Lock.Unlock()
+				}
+			}
+		}()
+	}
+
+	// Goroutines start together to increase chances of concurrent access.
+	close(start)
+	wg.Wait()
+}
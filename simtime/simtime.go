@@ -0,0 +1,241 @@
+// Package simtime provides virtual time for deterministic simulations, e.g. backtesting on historical data.
+// Data feeds and timers enqueue callbacks into Scheduler at virtual timestamps. Advancing the clock executes
+// callbacks in order of their timestamps from the calling goroutine, so that propagations of updates
+// started by them happen in the same order on each run, however long the callbacks take.
+package simtime
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/shdepexec"
+)
+
+// Notifier is implemented by nodes of update propagation tree and by shared objects, e.g. shdep.SharedObjectBase.
+type Notifier[Ctx any] interface {
+	NotifyUpdated(ctx Ctx, evtTime time.Time)
+}
+
+// NewScheduler creates scheduler, which clock shows start until it is advanced.
+// Function newCtx creates context for each of executed callbacks.
+func NewScheduler[Ctx any](start time.Time, newCtx func() Ctx) *Scheduler[Ctx] {
+	return &Scheduler[Ctx]{now: start, newCtx: newCtx}
+}
+
+// Scheduler is a virtual clock with a queue of callbacks keyed by virtual timestamps.
+// Callbacks with equal timestamps are executed in order of scheduling. Before executing each callback
+// clock is set to its timestamp, so callbacks and objects using Now observe time of the event being processed.
+// Callbacks can be scheduled from any goroutine, but are executed only by Step, Advance, AdvanceTo and Run.
+type Scheduler[Ctx any] struct {
+	lock    sync.Mutex
+	now     time.Time
+	newCtx  func() Ctx
+	queue   eventQueue[Ctx]
+	lastSeq uint64
+}
+
+type event[Ctx any] struct {
+	at  time.Time
+	seq uint64
+	f   func(ctx Ctx, now time.Time)
+	// Position in the queue, or -1 if event is not in the queue.
+	index int
+}
+
+// Now returns current virtual time.
+func (s *Scheduler[Ctx]) Now() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.now
+}
+
+// At schedules f to be called at virtual time t. If t is in the past, f is called at current time,
+// after callbacks already scheduled for it. Returned function cancels the callback and reports
+// whether it was cancelled before execution.
+func (s *Scheduler[Ctx]) At(t time.Time, f func(ctx Ctx, now time.Time)) (cancel func() bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if t.Before(s.now) {
+		t = s.now
+	}
+
+	s.lastSeq++
+	evt := &event[Ctx]{at: t, seq: s.lastSeq, f: f}
+	heap.Push(&s.queue, evt)
+
+	return func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if evt.index < 0 {
+			return false
+		}
+
+		heap.Remove(&s.queue, evt.index)
+		return true
+	}
+}
+
+// After schedules f to be called, when clock is advanced by d or more.
+func (s *Scheduler[Ctx]) After(d time.Duration, f func(ctx Ctx, now time.Time)) (cancel func() bool) {
+	return s.At(s.Now().Add(d), f)
+}
+
+// AfterFunc is same as After for callbacks, which do not need context.
+// It allows to use scheduler in place of clocks with the same method, e.g. shdeptest.Clock.
+func (s *Scheduler[Ctx]) AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool) {
+	return s.After(d, func(ctx Ctx, now time.Time) { f(now) })
+}
+
+// Every schedules f to be called each period, starting one period from now, until returned function is called.
+// Queue with periodic callbacks is never drained, so such scheduler must be advanced by Advance or AdvanceTo instead of Run.
+func (s *Scheduler[Ctx]) Every(period time.Duration, f func(ctx Ctx, now time.Time)) (stop func()) {
+	if period <= 0 {
+		panic("period of scheduled callback must be positive")
+	}
+
+	var lock sync.Mutex
+	stopped := false
+	var cancel func() bool
+
+	var tick func(ctx Ctx, now time.Time)
+	tick = func(ctx Ctx, now time.Time) {
+		f(ctx, now)
+
+		lock.Lock()
+		defer lock.Unlock()
+		if !stopped {
+			cancel = s.At(now.Add(period), tick)
+		}
+	}
+
+	lock.Lock()
+	cancel = s.After(period, tick)
+	lock.Unlock()
+
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		stopped = true
+		cancel()
+	}
+}
+
+// NotifyAt schedules notification of subscribers of n at virtual time t, passing t as event time.
+func (s *Scheduler[Ctx]) NotifyAt(t time.Time, n Notifier[Ctx]) (cancel func() bool) {
+	return s.At(t, func(ctx Ctx, now time.Time) { n.NotifyUpdated(ctx, now) })
+}
+
+// Post schedules task to be executed at current virtual time, after callbacks already scheduled for it.
+// It allows to use scheduler as executor of updates coming from outside of update tree, e.g. ticks of shdep.Ticker.
+func (s *Scheduler[Ctx]) Post(task func(ctx Ctx)) {
+	s.At(s.Now(), func(ctx Ctx, now time.Time) { task(ctx) })
+}
+
+var _ shdepexec.Executor[int] = &Scheduler[int]{}
+
+// Pending returns number of scheduled callbacks, which are not executed yet.
+func (s *Scheduler[Ctx]) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.queue)
+}
+
+// Step executes the earliest of scheduled callbacks. Returns false if there are none.
+func (s *Scheduler[Ctx]) Step() bool {
+	return s.step(time.Time{}, false)
+}
+
+// AdvanceTo executes callbacks scheduled up to t inclusively, including callbacks they schedule,
+// and then sets clock to t. Clock is never moved backwards. Returns number of executed callbacks.
+func (s *Scheduler[Ctx]) AdvanceTo(t time.Time) int {
+	executed := 0
+	for s.step(t, true) {
+		executed++
+	}
+
+	s.lock.Lock()
+	if t.After(s.now) {
+		s.now = t
+	}
+	s.lock.Unlock()
+
+	return executed
+}
+
+// Advance is same as AdvanceTo for time d after current time.
+func (s *Scheduler[Ctx]) Advance(d time.Duration) int {
+	return s.AdvanceTo(s.Now().Add(d))
+}
+
+// Run executes scheduled callbacks, including callbacks they schedule, until the queue is empty.
+// Returns number of executed callbacks.
+func (s *Scheduler[Ctx]) Run() int {
+	executed := 0
+	for s.Step() {
+		executed++
+	}
+
+	return executed
+}
+
+// step executes the earliest of callbacks, if there is one scheduled not later than limit, when it is set.
+func (s *Scheduler[Ctx]) step(limit time.Time, limited bool) bool {
+	s.lock.Lock()
+
+	if len(s.queue) == 0 || (limited && s.queue[0].at.After(limit)) {
+		s.lock.Unlock()
+		return false
+	}
+
+	evt := heap.Pop(&s.queue).(*event[Ctx])
+	if evt.at.After(s.now) {
+		s.now = evt.at
+	}
+	now := s.now
+
+	s.lock.Unlock()
+
+	evt.f(s.newCtx(), now)
+	return true
+}
+
+// eventQueue is a min-heap of events ordered by time and then by order of scheduling.
+type eventQueue[Ctx any] []*event[Ctx]
+
+func (q eventQueue[Ctx]) Len() int {
+	return len(q)
+}
+
+func (q eventQueue[Ctx]) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue[Ctx]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *eventQueue[Ctx]) Push(x interface{}) {
+	evt := x.(*event[Ctx])
+	evt.index = len(*q)
+	*q = append(*q, evt)
+}
+
+func (q *eventQueue[Ctx]) Pop() interface{} {
+	old := *q
+	evt := old[len(old)-1]
+	old[len(old)-1] = nil
+	evt.index = -1
+	*q = old[:len(old)-1]
+	return evt
+}
@@ -0,0 +1,72 @@
+package simtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/simtime"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestScheduler_DrivesUpdates(t *testing.T) {
+	t.Parallel()
+
+	s := simtime.NewScheduler(start, context.Background)
+
+	a := updtree.NewNode[context.Context]("a", nil)
+	b := updtree.NewNode[context.Context]("b", nil)
+	var history []string
+	sum := updtree.NewNode[context.Context]("sum", func(ctx context.Context, evtTime time.Time) {
+		history = append(history, evtTime.Format(time.TimeOnly)+" "+s.Now().Format(time.TimeOnly))
+	})
+	a.Subscribe(sum)
+	b.Subscribe(sum)
+
+	// Events are scheduled out of order.
+	s.NotifyAt(start.Add(3*time.Second), a)
+	s.NotifyAt(start.Add(time.Second), b)
+	cancel := s.NotifyAt(start.Add(2*time.Second), a)
+	s.AfterFunc(2*time.Second, func(now time.Time) {
+		s.NotifyAt(now, b)
+	})
+	require.Equal(t, 4, s.Pending())
+
+	require.True(t, cancel())
+	require.False(t, cancel())
+
+	require.Equal(t, 1, s.AdvanceTo(start.Add(time.Second)))
+	require.Equal(t, []string{"10:00:01 10:00:01"}, history)
+
+	require.Equal(t, 3, s.Run())
+	require.Equal(t, []string{"10:00:01 10:00:01", "10:00:02 10:00:02", "10:00:03 10:00:03"}, history)
+	require.False(t, s.Step())
+}
+
+func TestScheduler_Every(t *testing.T) {
+	t.Parallel()
+
+	s := simtime.NewScheduler(start, context.Background)
+
+	var ticks []time.Time
+	stop := s.Every(time.Minute, func(ctx context.Context, now time.Time) {
+		ticks = append(ticks, now)
+	})
+
+	require.Equal(t, 2, s.Advance(150*time.Second))
+	require.Equal(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)}, ticks)
+	require.Equal(t, start.Add(150*time.Second), s.Now())
+
+	// Past events are executed at current time and do not move clock backwards.
+	var pastAt time.Time
+	s.At(start, func(ctx context.Context, now time.Time) { pastAt = now })
+	require.Equal(t, 1, s.Advance(0))
+	require.Equal(t, start.Add(150*time.Second), pastAt)
+
+	stop()
+	require.Equal(t, 0, s.Advance(time.Hour))
+	require.Len(t, ticks, 2)
+}
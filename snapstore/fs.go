@@ -0,0 +1,105 @@
+package snapstore
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const snapshotFileExt = ".snap"
+
+// NewFSStore creates snapshot store, which keeps snapshots in files in directory dir.
+// Snapshots of each object are stored in its own subdirectory named by escaped object ID.
+func NewFSStore(dir string) *FSStore {
+	return &FSStore{dir: dir}
+}
+
+type FSStore struct {
+	dir string
+}
+
+var _ SnapshotStore = &FSStore{}
+
+func (s *FSStore) objectDir(objectID string) string {
+	return filepath.Join(s.dir, url.PathEscape(objectID))
+}
+
+func (s *FSStore) snapshotFile(objectID string, version uint64) string {
+	return filepath.Join(s.objectDir(objectID), strconv.FormatUint(version, 10)+snapshotFileExt)
+}
+
+// Put writes snapshot into temporary file and then renames it, so that partially written snapshot is never read.
+func (s *FSStore) Put(ctx context.Context, objectID string, version uint64, data []byte) error {
+	dir := s.objectDir(objectID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for snapshots of %v", objectID)
+	}
+
+	f, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create snapshot file of %v", objectID)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write snapshot of %v", objectID)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write snapshot of %v", objectID)
+	}
+
+	if err := os.Rename(f.Name(), s.snapshotFile(objectID, version)); err != nil {
+		return errors.Wrapf(err, "failed to save snapshot of %v", objectID)
+	}
+
+	return nil
+}
+
+func (s *FSStore) Get(ctx context.Context, objectID string, version uint64) ([]byte, error) {
+	data, err := os.ReadFile(s.snapshotFile(objectID, version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to read snapshot of %v", objectID)
+	}
+
+	return data, nil
+}
+
+func (s *FSStore) Versions(ctx context.Context, objectID string) ([]uint64, error) {
+	entries, err := os.ReadDir(s.objectDir(objectID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list snapshots of %v", objectID)
+	}
+
+	versions := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotFileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		version, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	slices.Sort(versions)
+
+	return versions, nil
+}
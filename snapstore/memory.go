@@ -0,0 +1,52 @@
+package snapstore
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// NewMemoryStore creates snapshot store, which keeps snapshots in memory.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: make(map[string]map[uint64][]byte)}
+}
+
+type MemoryStore struct {
+	lock      sync.RWMutex
+	snapshots map[string]map[uint64][]byte
+}
+
+var _ SnapshotStore = &MemoryStore{}
+
+func (s *MemoryStore) Put(ctx context.Context, objectID string, version uint64, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.snapshots[objectID] == nil {
+		s.snapshots[objectID] = make(map[uint64][]byte)
+	}
+	s.snapshots[objectID][version] = slices.Clone(data)
+
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, objectID string, version uint64) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	data, ok := s.snapshots[objectID][version]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return slices.Clone(data), nil
+}
+
+func (s *MemoryStore) Versions(ctx context.Context, objectID string) ([]uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return utils.SortedKeys(s.snapshots[objectID]), nil
+}
@@ -0,0 +1,46 @@
+// Package snapstore provides storages for persisted state of shared objects.
+// State is stored as opaque data identified by object ID and version, so backends
+// like S3 or database can be added by implementing SnapshotStore.
+package snapstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when there is no snapshot of the object with requested version.
+var ErrNotFound = errors.New("snapshot not found")
+
+// SnapshotStore stores snapshots of state of shared objects.
+// Implementations must be safe for concurrent use.
+type SnapshotStore interface {
+	// Put stores snapshot of the object. Existing snapshot with the same version is replaced.
+	Put(ctx context.Context, objectID string, version uint64, data []byte) error
+
+	// Get returns snapshot of the object with given version or ErrNotFound.
+	Get(ctx context.Context, objectID string, version uint64) ([]byte, error)
+
+	// Versions returns versions of stored snapshots of the object in ascending order.
+	Versions(ctx context.Context, objectID string) ([]uint64, error)
+}
+
+// Latest returns snapshot of the object with the highest version or ErrNotFound.
+func Latest(ctx context.Context, s SnapshotStore, objectID string) (data []byte, version uint64, err error) {
+	versions, err := s.Versions(ctx, objectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(versions) == 0 {
+		return nil, 0, ErrNotFound
+	}
+
+	version = versions[len(versions)-1]
+
+	data, err = s.Get(ctx, objectID, version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return data, version, nil
+}
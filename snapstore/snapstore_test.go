@@ -0,0 +1,56 @@
+package snapstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/snapstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotStores(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) snapstore.SnapshotStore{
+		"Memory": func(t *testing.T) snapstore.SnapshotStore { return snapstore.NewMemoryStore() },
+		"FS":     func(t *testing.T) snapstore.SnapshotStore { return snapstore.NewFSStore(t.TempDir()) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+			const objID = "*indicators.MA-1a2b/c"
+
+			versions, err := s.Versions(ctx, objID)
+			require.NoError(t, err)
+			require.Empty(t, versions)
+
+			_, err = s.Get(ctx, objID, 1)
+			require.ErrorIs(t, err, snapstore.ErrNotFound)
+
+			_, _, err = snapstore.Latest(ctx, s, objID)
+			require.ErrorIs(t, err, snapstore.ErrNotFound)
+
+			require.NoError(t, s.Put(ctx, objID, 10, []byte("v10")))
+			require.NoError(t, s.Put(ctx, objID, 2, []byte("v2")))
+			require.NoError(t, s.Put(ctx, objID, 2, []byte("v2-replaced")))
+			require.NoError(t, s.Put(ctx, "other", 100, []byte("other")))
+
+			data, err := s.Get(ctx, objID, 2)
+			require.NoError(t, err)
+			require.Equal(t, []byte("v2-replaced"), data)
+
+			versions, err = s.Versions(ctx, objID)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{2, 10}, versions)
+
+			data, version, err := snapstore.Latest(ctx, s, objID)
+			require.NoError(t, err)
+			require.Equal(t, uint64(10), version)
+			require.Equal(t, []byte("v10"), data)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package shdep
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StateTransition is an event published by StateMachine on each change of state.
+type StateTransition[State any] struct {
+	From, To State
+	// Event time of the update, which has caused the transition.
+	Time time.Time
+}
+
+// StateMachine is a helper base struct for shared objects, which have discrete state, e.g. direction of crossover
+// of moving averages. It is same as SharedObjectBaseWithEvent, which publishes StateTransition events,
+// so subscribers are notified only when state changes and can pull transitions, which have happened.
+type StateMachine[State comparable, Ctx, InitParams any] struct {
+	SharedObjectBaseWithEvent[Ctx, InitParams, StateTransition[State]]
+	current      State
+	history      []StateTransition[State]
+	historyLimit int
+}
+
+// NewStateMachine creates new StateMachine in initial state. Parameters are same as of NewSharedObjectBase.
+func NewStateMachine[State comparable, Ctx, InitParams any](name string, initial State, params ...interface{}) StateMachine[State, Ctx, InitParams] {
+	return StateMachine[State, Ctx, InitParams]{
+		SharedObjectBaseWithEvent: NewSharedObjectBaseWithEvent[Ctx, InitParams, StateTransition[State]](name, params...),
+		current:                   initial,
+	}
+}
+
+// Current returns current state.
+func (m *StateMachine[State, Ctx, InitParams]) Current() State {
+	return m.current
+}
+
+// History returns transitions in order they have happened, limited by SetHistoryLimit.
+func (m *StateMachine[State, Ctx, InitParams]) History() []StateTransition[State] {
+	return slices.Clone(m.history)
+}
+
+// SetHistoryLimit sets maximum number of the latest transitions kept in history. Zero means unlimited, which is the default.
+func (m *StateMachine[State, Ctx, InitParams]) SetHistoryLimit(limit int) {
+	m.historyLimit = limit
+	m.trimHistory()
+}
+
+// Transition changes state, publishes transition event and notifies subscribers. Does nothing and returns false,
+// if the state is already current.
+func (m *StateMachine[State, Ctx, InitParams]) Transition(ctx Ctx, evtTime time.Time, to State) bool {
+	if to == m.current {
+		return false
+	}
+
+	transition := StateTransition[State]{From: m.current, To: to, Time: evtTime}
+	m.current = to
+	m.history = append(m.history, transition)
+	m.trimHistory()
+
+	m.PublishEvent(ctx, evtTime, transition)
+	return true
+}
+
+func (m *StateMachine[State, Ctx, InitParams]) trimHistory() {
+	if m.historyLimit > 0 && len(m.history) > m.historyLimit {
+		m.history = slices.Delete(m.history, 0, len(m.history)-m.historyLimit)
+	}
+}
+
+// DebugInfo returns same info as SharedObjectBaseWithEvent.DebugInfo and current state.
+func (m *StateMachine[State, Ctx, InitParams]) DebugInfo() map[string]interface{} {
+	info := m.SharedObjectBaseWithEvent.DebugInfo()
+	info[DebugInfoState] = fmt.Sprint(m.current)
+
+	return info
+}
+
+var _ SharedObject[context.Context, string] = &StateMachine[int, context.Context, string]{}
@@ -0,0 +1,80 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+type sign struct {
+	shdep.StateMachine[int, context.Context, *InitParams]
+	counter *counter
+}
+
+func newSign() *sign {
+	s := &sign{StateMachine: shdep.NewStateMachine[int, context.Context, *InitParams]("sign", 0, 1), counter: newCounter()}
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		switch {
+		case s.counter.value > 0:
+			s.Transition(ctx, evtTime, 1)
+		case s.counter.value < 0:
+			s.Transition(ctx, evtTime, -1)
+		}
+	})
+
+	return s
+}
+
+func (s *sign) RegisterDependencies(store SharedStore) {
+	store.Register(&s.counter)
+	s.counter.SubscribeObj(s)
+}
+
+func TestStateMachine(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	s := newSign()
+	store.Register(&s)
+	transitions := s.NewEventPuller()
+
+	var notified int
+	probe := shdep.NewFuncObject("probe", []interface{}{2}, shdep.FuncObjectSpec[context.Context, *InitParams]{
+		RegisterDeps: func(store SharedStore) { store.Register(&s) },
+		OnUpdate:     func(ctx context.Context, evtTime time.Time) { notified++ },
+	})
+	store.Register(&probe)
+	s.SubscribeObj(probe)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	t1, t2, t3 := time.Unix(1, 0), time.Unix(2, 0), time.Unix(3, 0)
+	for _, upd := range []struct {
+		value int
+		time  time.Time
+	}{{5, t1}, {7, t2}, {-1, t3}} {
+		s.counter.value = upd.value
+		s.counter.NotifyUpdated(context.Background(), upd.time)
+	}
+
+	require.Equal(t, -1, s.Current())
+	// Update, which has not changed the state, is not propagated.
+	require.Equal(t, 2, notified)
+	expected := []shdep.StateTransition[int]{{From: 0, To: 1, Time: t1}, {From: 1, To: -1, Time: t3}}
+	require.Equal(t, expected, s.History())
+	pulled := transitions.Pull()
+	require.Len(t, pulled, 2)
+	require.Equal(t, expected[1], *pulled[1].Event)
+	require.Equal(t, "-1", s.DebugInfo()[shdep.DebugInfoState])
+
+	s.SetHistoryLimit(1)
+	require.Equal(t, expected[1:], s.History())
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
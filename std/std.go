@@ -0,0 +1,102 @@
+// Package std provides aliases and constructors of shdep types bound to context.Context,
+// so that applications using standard context do not need to declare their own aliases.
+package std
+
+import (
+	"context"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+type Object[InitParams any] = shdep.SharedObject[context.Context, InitParams]
+type ObjectBase[InitParams any] = shdep.SharedObjectBase[context.Context, InitParams]
+type ObjectBaseWithEvent[InitParams, Event any] = shdep.SharedObjectBaseWithEvent[context.Context, InitParams, Event]
+
+type FuncObject[InitParams any] = shdep.FuncObject[context.Context, InitParams]
+type FuncObjectSpec[InitParams any] = shdep.FuncObjectSpec[context.Context, InitParams]
+
+type CachedValue[InitParams, T any] = shdep.CachedValue[context.Context, InitParams, T]
+
+type Ticker[InitParams any] = shdep.Ticker[context.Context, InitParams]
+
+type Watchdog[InitParams any] = shdep.Watchdog[context.Context, InitParams]
+
+type StateMachine[State comparable, InitParams any] = shdep.StateMachine[State, context.Context, InitParams]
+
+type Window[InitParams, T any, Src Object[InitParams]] = shdep.Window[context.Context, InitParams, T, Src]
+
+// Store is the type of store passed into RegisterDependencies.
+type Store[InitParams any] = objstore.SharedStore[Object[InitParams], InitParams]
+
+// GenericStore is the type of store created by NewStore.
+type GenericStore[InitParams any] = objstore.GenericStore[Object[InitParams], string, InitParams]
+
+type Node = updtree.Node[context.Context]
+type NodeBase = updtree.NodeBase[context.Context]
+type Tree = updtree.Tree[context.Context]
+
+var _ Object[int] = &ObjectBase[int]{}
+
+func NewObjectBase[InitParams any](name string, params ...interface{}) ObjectBase[InitParams] {
+	return shdep.NewSharedObjectBase[context.Context, InitParams](name, params...)
+}
+
+func NewObjectBaseWithEvent[InitParams, Event any](name string, params ...interface{}) ObjectBaseWithEvent[InitParams, Event] {
+	return shdep.NewSharedObjectBaseWithEvent[context.Context, InitParams, Event](name, params...)
+}
+
+func NewObjectBaseOpts[InitParams any](name string, opts ...shdep.SharedObjectBaseOption) ObjectBase[InitParams] {
+	return shdep.NewSharedObjectBaseOpts[context.Context, InitParams](name, opts...)
+}
+
+func NewObjectBaseWithEventOpts[InitParams, Event any](name string, opts ...shdep.SharedObjectBaseOption) ObjectBaseWithEvent[InitParams, Event] {
+	return shdep.NewSharedObjectBaseWithEventOpts[context.Context, InitParams, Event](name, opts...)
+}
+
+func NewCachedValue[InitParams, T any](name string, params ...interface{}) CachedValue[InitParams, T] {
+	return shdep.NewCachedValue[context.Context, InitParams, T](name, params...)
+}
+
+func NewStateMachine[State comparable, InitParams any](name string, initial State, params ...interface{}) StateMachine[State, InitParams] {
+	return shdep.NewStateMachine[State, context.Context, InitParams](name, initial, params...)
+}
+
+func NewWindow[InitParams, T any, Src Object[InitParams]](name string, source Src, read func(src Src) T, opts shdep.WindowOpts) *Window[InitParams, T, Src] {
+	return shdep.NewWindow[context.Context, InitParams](name, source, read, opts)
+}
+
+func NewTicker[InitParams any](interval time.Duration) *Ticker[InitParams] {
+	return shdep.NewTicker[context.Context, InitParams](interval)
+}
+
+func NewCron[InitParams any](spec string) (*Ticker[InitParams], error) {
+	return shdep.NewCron[context.Context, InitParams](spec)
+}
+
+func NewWatchdog[InitParams any](interval time.Duration, watched ...Object[InitParams]) *Watchdog[InitParams] {
+	return shdep.NewWatchdog[context.Context, InitParams](interval, watched...)
+}
+
+func NewFuncObject[InitParams any](name string, params []interface{}, spec FuncObjectSpec[InitParams]) *FuncObject[InitParams] {
+	return shdep.NewFuncObject[context.Context, InitParams](name, params, spec)
+}
+
+func Deps[InitParams any](store Store[InitParams]) *shdep.DepsBuilder[context.Context, InitParams] {
+	return shdep.Deps[context.Context, InitParams](store)
+}
+
+func NewStore[InitParams any](l utils.Logger, opts ...objstore.StoreOption) *GenericStore[InitParams] {
+	return shdep.NewSharedStore[context.Context, InitParams](l, opts...)
+}
+
+func NewNode(name string, onSubscriptionUpdated func(ctx context.Context, evtTime time.Time)) *NodeBase {
+	return updtree.NewNode[context.Context](name, onSubscriptionUpdated)
+}
+
+func NewTree() *Tree {
+	return updtree.NewTree[context.Context]()
+}
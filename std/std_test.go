@@ -0,0 +1,127 @@
+package std_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/std"
+	"github.com/stretchr/testify/require"
+)
+
+type InitParams struct{}
+
+type counter struct {
+	std.ObjectBase[*InitParams]
+	value int
+}
+
+func newCounter() *counter {
+	return &counter{ObjectBase: std.NewObjectBase[*InitParams]("counter", 1)}
+}
+
+type doubler struct {
+	std.ObjectBase[*InitParams]
+	counter *counter
+	value   int
+}
+
+func newDoubler() *doubler {
+	d := &doubler{
+		ObjectBase: std.NewObjectBase[*InitParams]("doubler", 1),
+		counter:    newCounter(),
+	}
+
+	d.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		d.value = d.counter.value * 2
+	})
+
+	return d
+}
+
+func (d *doubler) RegisterDependencies(store std.Store[*InitParams]) {
+	store.Register(&d.counter)
+	d.counter.SubscribeObj(d)
+}
+
+func TestStd(t *testing.T) {
+	t.Parallel()
+
+	store := std.NewStore[*InitParams](nil)
+
+	d1 := newDoubler()
+	d2 := newDoubler()
+	store.Register(&d1)
+	store.Register(&d2)
+	require.Same(t, d1, d2)
+
+	c := newCounter()
+	store.Register(&c)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	require.Same(t, c, d1.counter)
+
+	c.value = 21
+	c.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 42, d1.value)
+
+	store.Stop()
+	store.Close()
+}
+
+func TestStd_Aliases(t *testing.T) {
+	t.Parallel()
+
+	obj, expectedObj := std.NewObjectBase[*InitParams]("obj", 1, "a"), shdep.NewSharedObjectBase[context.Context, *InitParams]("obj", 1, "a")
+	require.Equal(t, expectedObj.Hash(), obj.Hash())
+
+	opts, expectedOpts := std.NewObjectBaseOpts[*InitParams]("obj", shdep.WithHashParams(1, "a")), shdep.NewSharedObjectBaseOpts[context.Context, *InitParams]("obj", shdep.WithHashParams(1, "a"))
+	require.Equal(t, expectedOpts.Hash(), opts.Hash())
+
+	evt, expectedEvt := std.NewObjectBaseWithEvent[*InitParams, int]("evt", 2), shdep.NewSharedObjectBaseWithEvent[context.Context, *InitParams, int]("evt", 2)
+	require.Equal(t, expectedEvt.Hash(), evt.Hash())
+
+	evtOpts, expectedEvtOpts := std.NewObjectBaseWithEventOpts[*InitParams, int]("evt", shdep.WithExplicitHash("e")), shdep.NewSharedObjectBaseWithEventOpts[context.Context, *InitParams, int]("evt", shdep.WithExplicitHash("e"))
+	require.Equal(t, expectedEvtOpts.Hash(), evtOpts.Hash())
+
+	cached, expectedCached := std.NewCachedValue[*InitParams, int]("cached", 3), shdep.NewCachedValue[context.Context, *InitParams, int]("cached", 3)
+	require.Equal(t, expectedCached.Hash(), cached.Hash())
+
+	sm, expectedSM := std.NewStateMachine[string, *InitParams]("sm", "idle", 4), shdep.NewStateMachine[string, context.Context, *InitParams]("sm", "idle", 4)
+	require.Equal(t, expectedSM.Hash(), sm.Hash())
+
+	require.Equal(t,
+		shdep.NewTicker[context.Context, *InitParams](time.Second).Hash(),
+		std.NewTicker[*InitParams](time.Second).Hash())
+
+	cron, err := std.NewCron[*InitParams]("*/5 * * * *")
+	require.NoError(t, err)
+	expectedCron, err := shdep.NewCron[context.Context, *InitParams]("*/5 * * * *")
+	require.NoError(t, err)
+	require.Equal(t, expectedCron.Hash(), cron.Hash())
+
+	c := newCounter()
+	require.Equal(t,
+		shdep.NewWatchdog[context.Context, *InitParams](time.Minute, c).Hash(),
+		std.NewWatchdog[*InitParams](time.Minute, c).Hash())
+	require.Equal(t,
+		shdep.NewWindow[context.Context, *InitParams]("win", c, func(c *counter) int { return c.value }, shdep.WindowOpts{Size: 10}).Hash(),
+		std.NewWindow[*InitParams]("win", c, func(c *counter) int { return c.value }, shdep.WindowOpts{Size: 10}).Hash())
+
+	spec := std.FuncObjectSpec[*InitParams]{}
+	require.Equal(t,
+		shdep.NewFuncObject("func", []interface{}{5}, spec).Hash(),
+		std.NewFuncObject("func", []interface{}{5}, spec).Hash())
+
+	require.False(t, std.NewNode("node", nil).HasUpdateHandler())
+	require.NotNil(t, std.NewTree())
+
+	store := std.NewStore[*InitParams](nil)
+	d := newDoubler()
+	std.Deps(store).Use(&d)
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NotNil(t, d.counter)
+}
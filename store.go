@@ -1,18 +1,100 @@
 package shdep
 
 import (
+	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/updtree"
 	"github.com/nnikolash/go-shdep/utils"
 )
 
-func NewSharedStore[Ctx, InitParams any](l utils.Logger) SharedStore[Ctx, InitParams] {
-	s := objstore.NewStore(func(obj SharedObject[Ctx, InitParams]) string {
-		return reflect.TypeOf(obj).String() + "-" + obj.Hash()
-	}, l)
+// NewSharedStore creates store of shared objects. Objects skipped using objstore.ErrSkip are detached from update tree.
+// Method OnDependenciesUpdated of objects, which have not set update handler, is used as their update handler.
+// Init of the store fails, if some object has subscribed to local instance of its dependency,
+// which has been replaced by the shared replica upon registration.
+// Returned store implements SharedStore, and also introspection and lifecycle methods returning errors.
+func NewSharedStore[Ctx, InitParams any](l utils.Logger, opts ...objstore.StoreOption) *objstore.GenericStore[SharedObject[Ctx, InitParams], string, InitParams] {
+	v := &replacedObjectsVerifier[Ctx, InitParams]{}
+	opts = append([]objstore.StoreOption{
+		objstore.WithSkipHandler(detachSkipped[Ctx, InitParams]),
+		objstore.WithGatherHandler(setDefaultUpdateHandler[Ctx, InitParams]),
+		objstore.WithReplacementHandler(v.add),
+		objstore.WithInitValidator(v.verify),
+	}, opts...)
 
-	return s
+	return objstore.NewStore(func(obj SharedObject[Ctx, InitParams]) string {
+		return ObjectID(obj)
+	}, l, opts...)
+}
+
+// ObjectID returns ID of the object in the store created by NewSharedStore.
+func ObjectID(obj interface{ Hash() string }) string {
+	return reflect.TypeOf(obj).String() + "-" + obj.Hash()
 }
 
 type SharedStore[Ctx, InitParams any] objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]
+
+func detachSkipped[Ctx, InitParams any](obj interface{}) {
+	if node, ok := obj.(SharedObject[Ctx, InitParams]).GetUpdateNode().(interface{ Detach() }); ok {
+		node.Detach()
+	}
+}
+
+// DependenciesUpdatedHandler is an optional interface of shared objects. If object implements it and has not set
+// update handler explicitly, store created by NewSharedStore sets OnDependenciesUpdated as its update handler.
+type DependenciesUpdatedHandler[Ctx any] interface {
+	OnDependenciesUpdated(ctx Ctx, evtTime time.Time)
+}
+
+func setDefaultUpdateHandler[Ctx, InitParams any](obj interface{}) {
+	handler, ok := obj.(DependenciesUpdatedHandler[Ctx])
+	if !ok {
+		return
+	}
+
+	node, ok := obj.(SharedObject[Ctx, InitParams]).GetUpdateNode().(interface {
+		HasUpdateHandler() bool
+		SetUpdateHandler(func(ctx Ctx, evtTime time.Time))
+	})
+	if ok && !node.HasUpdateHandler() {
+		node.SetUpdateHandler(handler.OnDependenciesUpdated)
+	}
+}
+
+// replacedObjectsVerifier checks that nobody has subscribed to local instances of objects, which have been replaced
+// by shared replicas upon registration, e.g. by calling SubscribeObj before Register. Such subscribers
+// would never receive updates, because updates are published by the replica.
+type replacedObjectsVerifier[Ctx, InitParams any] struct {
+	replaced []SharedObject[Ctx, InitParams]
+	// Objects registered after Init are not tracked, so that they are not retained.
+	initialized bool
+}
+
+func (v *replacedObjectsVerifier[Ctx, InitParams]) add(obj, replica interface{}) {
+	if !v.initialized {
+		v.replaced = append(v.replaced, obj.(SharedObject[Ctx, InitParams]))
+	}
+}
+
+func (v *replacedObjectsVerifier[Ctx, InitParams]) verify() error {
+	replaced := v.replaced
+	v.replaced = nil
+	v.initialized = true
+
+	for _, obj := range replaced {
+		node, ok := obj.GetUpdateNode().(interface{ Subscribers() []updtree.Node[Ctx] })
+		if !ok {
+			continue
+		}
+
+		if subscribers := node.Subscribers(); len(subscribers) > 0 {
+			return fmt.Errorf("%v subscribed to local instance of object %v, which has been replaced by shared replica "+
+				"upon registration, so they will never receive its updates. Subscribe to the object after registering it",
+				subscribers, ObjectID(obj))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package shdep_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+type earlySubscriber struct {
+	SharedObjectBase
+	counter *counter
+}
+
+func (s *earlySubscriber) RegisterDependencies(store SharedStore) {
+	// Subscription to the local instance, which is replaced by the shared replica below.
+	s.counter.SubscribeObj(s)
+	store.Register(&s.counter)
+}
+
+func TestSubscriptionToReplacedObject(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	c := newCounter()
+	store.Register(&c)
+
+	s := &earlySubscriber{SharedObjectBase: NewSharedObjectBase("earlySubscriber", 1), counter: newCounter()}
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {})
+	store.Register(&s)
+
+	require.ErrorContains(t, store.Init(&InitParams{}), "subscribed to local instance of object *shdep_test.counter-")
+	require.NoError(t, store.CloseE())
+}
+
+func TestRegisterAndSubscribe(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	s := &tickerSum{SharedObjectBase: NewSharedObjectBase("tickerSum", 2), counter: newCounter(), ticker: newTicker()}
+	s.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		s.sum++
+	})
+	require.Nil(t, store.RegisterAndSubscribe(&s.counter, s))
+	ticks, ok := store.RegisterAndSubscribe(&s.ticker, s).(shdep.EventPuller[int])
+	require.True(t, ok)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	s.counter.NotifyUpdated(context.Background(), time.Now())
+	s.ticker.PublishEvent(context.Background(), time.Now(), 10)
+	require.Equal(t, 2, s.sum)
+	require.Len(t, ticks.Pull(), 1)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+type optsDoubler struct {
+	SharedObjectBase
+	counter *counter
+	value   int
+}
+
+func (d *optsDoubler) RegisterDependencies(store SharedStore) {
+	store.RegisterWith(&d.counter, objstore.RegisterOpts{Subscribe: true})
+}
+
+func TestRegisterWith(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	d := &optsDoubler{SharedObjectBase: NewSharedObjectBase("optsDoubler", 1), counter: newCounter()}
+	d.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		d.value = d.counter.value * 2
+	})
+	store.Register(&d)
+
+	// Subscription needs the subscriber, which is only known inside RegisterDependencies.
+	c := newCounter()
+	require.Panics(t, func() { store.RegisterWith(&c, objstore.RegisterOpts{Subscribe: true}) })
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	d.counter.value = 4
+	d.counter.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 8, d.value)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+func TestSetEnabled(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	d := newDoubler()
+	store.Register(&d)
+	o := &optsDoubler{SharedObjectBase: NewSharedObjectBase("optsDoubler", 1), counter: newCounter()}
+	o.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		o.value = o.counter.value * 2
+	})
+	store.Register(&o)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+	require.Same(t, d.counter, o.counter)
+	dID := shdep.ObjectID(d)
+
+	store.SetEnabled(dID, false)
+	require.False(t, store.IsEnabled(dID))
+	require.Contains(t, store.Dump(), dID+" [started] top-level disabled")
+
+	// Shared dependency keeps updating other objects.
+	d.counter.value = 3
+	d.counter.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 0, d.value)
+	require.Equal(t, 6, o.value)
+
+	store.SetEnabled(dID, true)
+	require.True(t, store.IsEnabled(dID))
+	d.counter.value = 4
+	d.counter.NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 8, d.value)
+
+	require.Panics(t, func() { store.SetEnabled("unknown", false) })
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+type autoHandler struct {
+	SharedObjectBase
+	counter shdep.Dep[*counter]
+	updates int
+}
+
+func (h *autoHandler) OnDependenciesUpdated(ctx context.Context, evtTime time.Time) {
+	h.updates++
+}
+
+func TestOnDependenciesUpdated(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	h := &autoHandler{SharedObjectBase: NewSharedObjectBase("autoHandler", 1), counter: shdep.Dep[*counter]{Obj: newCounter(), Subscribe: true}}
+	store.Register(&h)
+
+	explicit := &autoHandler{SharedObjectBase: NewSharedObjectBase("autoHandler", 2), counter: shdep.Dep[*counter]{Obj: newCounter(), Subscribe: true}}
+	explicitUpdates := 0
+	explicit.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) { explicitUpdates++ })
+	store.Register(&explicit)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	h.counter.Get().NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, 1, h.updates)
+	require.Equal(t, 0, explicit.updates)
+	require.Equal(t, 1, explicitUpdates)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+func TestObjectErrors(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	failing := shdep.NewFuncObject("failing", []interface{}{"BTC", 5}, shdep.FuncObjectSpec[context.Context, *InitParams]{
+		Start: func(params *InitParams) error { return errors.New("connection refused") },
+	})
+	store.Register(&failing)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	err := store.Start()
+	require.EqualError(t, err, "start failed for failing ("+failing.Hash()[:8]+"): connection refused")
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
+
+type periodCounter struct {
+	SharedObjectBase
+	period int
+}
+
+func TestRegisterGroup(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	var updated [][]int
+	var sub *shdep.FuncObject[context.Context, *InitParams]
+	sub = shdep.NewFuncObject("groupSubscriber", []interface{}{1}, shdep.FuncObjectSpec[context.Context, *InitParams]{})
+	store.Register(&sub)
+
+	ptrs := make([]interface{}, 0, 3)
+	for _, period := range []int{5, 10, 5} {
+		c := &periodCounter{SharedObjectBase: NewSharedObjectBase("periodCounter", period), period: period}
+		ptrs = append(ptrs, &c)
+	}
+	group := store.RegisterGroup("counters", ptrs...)
+	require.Equal(t, "counters", group.Name())
+	require.Equal(t, 3, group.Len())
+	require.Same(t, group.Members()[0], group.Members()[2])
+
+	group.SubscribeObj(sub)
+	sub.SetUpdateHandler(func(ctx context.Context, evtTime time.Time) {
+		var periods []int
+		for _, member := range group.Updated() {
+			periods = append(periods, member.(*periodCounter).period)
+		}
+		updated = append(updated, periods)
+	})
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.NoError(t, store.Start())
+
+	group.Members()[1].(*periodCounter).NotifyUpdated(context.Background(), time.Now())
+	require.Equal(t, [][]int{{10}}, updated)
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}
@@ -0,0 +1,147 @@
+package shdep
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/shdepexec"
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// ExecutorProvider is an optional interface of init params. It provides executor, into which objects producing updates
+// from outside of update tree, e.g. Ticker, post their updates, so that updates are propagated sequentially with all others.
+type ExecutorProvider[Ctx any] interface {
+	Executor() shdepexec.Executor[Ctx]
+}
+
+// ClockProvider is an optional interface of init params. It provides clock, which is used by objects scheduling
+// updates, e.g. Ticker and Watchdog, instead of the clock set by updtree.SetClock, so that stores running in parallel,
+// e.g. in tests or backtests, can use their own clocks.
+type ClockProvider interface {
+	Clock() updtree.Clock
+}
+
+func clockOf(params interface{}) updtree.Clock {
+	if provider, ok := params.(ClockProvider); ok {
+		return provider.Clock()
+	}
+
+	return updtree.GetClock()
+}
+
+// Ticker is a shared object, which publishes tick events with scheduled time of each tick, e.g. for periodic
+// recalculations or reports. Ticks are scheduled using clock of init params (see ClockProvider) or updtree.SetClock,
+// so in simulation they happen in virtual time, and are posted into executor provided by init params, which must
+// implement ExecutorProvider.
+// Ticks are produced between Start and Stop.
+type Ticker[Ctx, InitParams any] struct {
+	SharedObjectBaseWithEvent[Ctx, InitParams, time.Time]
+	// Returns time of the next tick after given time, or zero time if there are no more ticks.
+	next func(after time.Time) time.Time
+
+	lock     sync.Mutex
+	exec     shdepexec.Executor[Ctx]
+	clock    updtree.Clock
+	stopTick func() bool
+	running  bool
+}
+
+// NewTicker creates ticker, which ticks each interval starting one interval after Start.
+// Tickers with same interval are shared.
+func NewTicker[Ctx, InitParams any](interval time.Duration) *Ticker[Ctx, InitParams] {
+	if interval <= 0 {
+		panic(fmt.Sprintf("interval of ticker must be positive, but got %v", interval))
+	}
+
+	return &Ticker[Ctx, InitParams]{
+		SharedObjectBaseWithEvent: NewSharedObjectBaseWithEvent[Ctx, InitParams, time.Time]("Ticker", interval),
+		next: func(after time.Time) time.Time {
+			return after.Add(interval)
+		},
+	}
+}
+
+// NewCron creates ticker, which ticks according to cron specification with five fields: minute, hour, day of month,
+// month and day of week, e.g. "*/15 9-17 * * 1-5". Each field is "*", number, range or list of them, optionally with step.
+// Time is evaluated in location of the clock. Tickers with same specification are shared.
+func NewCron[Ctx, InitParams any](spec string) (*Ticker[Ctx, InitParams], error) {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ticker[Ctx, InitParams]{
+		SharedObjectBaseWithEvent: NewSharedObjectBaseWithEvent[Ctx, InitParams, time.Time]("Cron", spec),
+		next:                      schedule.next,
+	}, nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Starts scheduling ticks.
+func (t *Ticker[Ctx, InitParams]) Start(params InitParams) error {
+	provider, ok := any(params).(ExecutorProvider[Ctx])
+	if !ok {
+		return fmt.Errorf("init params of type %T must implement ExecutorProvider to run %v", params, t.Name())
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.exec = provider.Executor()
+	t.clock = clockOf(params)
+	t.running = true
+	t.schedule(t.clock.Now())
+
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Stops ticks. Ticks already posted into executor are dropped.
+func (t *Ticker[Ctx, InitParams]) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.running = false
+	if t.stopTick != nil {
+		t.stopTick()
+		t.stopTick = nil
+	}
+}
+
+// schedule schedules next tick after given time. Must be called under lock.
+func (t *Ticker[Ctx, InitParams]) schedule(after time.Time) {
+	tickTime := t.next(after)
+	if tickTime.IsZero() {
+		t.stopTick = nil
+		return
+	}
+
+	t.stopTick = t.clock.AfterFunc(tickTime.Sub(t.clock.Now()), func(now time.Time) {
+		t.lock.Lock()
+		if !t.running {
+			t.lock.Unlock()
+			return
+		}
+
+		// Next tick is scheduled from time of this one, so that ticks do not drift, if timers fire late.
+		t.schedule(tickTime)
+		exec := t.exec
+		t.lock.Unlock()
+
+		exec.Post(t.tick(tickTime))
+	})
+}
+
+func (t *Ticker[Ctx, InitParams]) tick(tickTime time.Time) func(ctx Ctx) {
+	return func(ctx Ctx) {
+		t.lock.Lock()
+		running := t.running
+		t.lock.Unlock()
+
+		if running {
+			t.PublishEvent(ctx, tickTime, tickTime)
+		}
+	}
+}
+
+var _ SharedObject[context.Context, string] = &Ticker[context.Context, string]{}
@@ -0,0 +1,88 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/shdepexec"
+	"github.com/nnikolash/go-shdep/simtime"
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+type tickParams struct {
+	sched *simtime.Scheduler[context.Context]
+}
+
+func (p *tickParams) Executor() shdepexec.Executor[context.Context] {
+	return p.sched
+}
+
+func (p *tickParams) Clock() updtree.Clock {
+	return p.sched
+}
+
+func TestTicker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 5, 9, 50, 0, 0, time.UTC)
+	params := &tickParams{sched: simtime.NewScheduler(start, context.Background)}
+
+	store := shdep.NewSharedStore[context.Context, *tickParams](nil)
+
+	ticker := shdep.NewTicker[context.Context, *tickParams](10 * time.Minute)
+	store.Register(&ticker)
+	sameTicker := shdep.NewTicker[context.Context, *tickParams](10 * time.Minute)
+	store.Register(&sameTicker)
+	require.Same(t, ticker, sameTicker)
+
+	// Each 15 minutes from 10 to 11 on weekdays.
+	cron, err := shdep.NewCron[context.Context, *tickParams]("*/15 10 * * 1-5")
+	require.NoError(t, err)
+	store.Register(&cron)
+
+	_, err = shdep.NewCron[context.Context, *tickParams]("* * *")
+	require.ErrorContains(t, err, "must have 5 fields")
+	_, err = shdep.NewCron[context.Context, *tickParams]("60 * * * *")
+	require.ErrorContains(t, err, "minute must be within 0-59")
+
+	ticks := ticker.NewEventPuller()
+	cronTicks := cron.NewEventPuller()
+
+	require.NoError(t, store.Init(params))
+	require.NoError(t, store.Start())
+
+	tickTimes := func(p shdep.EventPuller[time.Time]) []time.Time {
+		var times []time.Time
+		for _, e := range p.Pull() {
+			times = append(times, *e.Event)
+		}
+		return times
+	}
+
+	params.sched.AdvanceTo(start.Add(30 * time.Minute))
+	require.Equal(t, []time.Time{start.Add(10 * time.Minute), start.Add(20 * time.Minute), start.Add(30 * time.Minute)}, tickTimes(ticks))
+	require.Equal(t, []time.Time{start.Add(10 * time.Minute), start.Add(25 * time.Minute)}, tickTimes(cronTicks))
+
+	// Friday is over, so next ticks of cron are on Monday.
+	params.sched.AdvanceTo(start.Add(73 * time.Hour))
+	require.Equal(t, time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC), tickTimes(cronTicks)[2])
+
+	tickTimes(ticks)
+	require.NoError(t, store.StopE())
+	require.Equal(t, 0, params.sched.Pending())
+	params.sched.Advance(time.Hour)
+	require.Empty(t, ticks.Pull())
+	require.NoError(t, store.CloseE())
+
+	// Ticks need executor, which is provided by init params.
+	otherStore := NewSharedStore(nil)
+	otherTicker := shdep.NewTicker[context.Context, *InitParams](time.Second)
+	otherStore.Register(&otherTicker)
+	require.NoError(t, otherStore.Init(&InitParams{}))
+	require.ErrorContains(t, otherStore.Start(), "must implement ExecutorProvider")
+	require.NoError(t, otherStore.StopE())
+	require.NoError(t, otherStore.CloseE())
+}
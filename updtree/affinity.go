@@ -0,0 +1,66 @@
+package updtree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// boundGoroutine is the call site, which has enabled goroutine affinity, or nil.
+var boundGoroutine atomic.Pointer[callSite]
+
+// serializedGoroutines contains IDs of goroutines, which propagate updates serialized by other means:
+// holders of UpdateLock and goroutines running RunSerialized.
+var serializedGoroutines sync.Map
+
+// SetGoroutineAffinity binds propagation of updates to the current goroutine, e.g. to the goroutine, which has
+// started the store, in single-threaded applications. While it is enabled, NotifyUpdated called from any other goroutine
+// is reported according to the error policy with stack traces of the binding and of the call, unless the call is made
+// while holding UpdateLock or from RunSerialized, e.g. by executor. Catches accidental multi-threaded access early
+// in development, but requires determining goroutine of each propagation, so it is intended for debugging.
+// Tasks, which are already running in RunSerialized, are not recognized, so it must be enabled before executors start.
+func SetGoroutineAffinity(enabled bool) {
+	if enabled {
+		boundGoroutine.Store(currentCallSite())
+	} else {
+		boundGoroutine.Store(nil)
+	}
+}
+
+// RunSerialized runs f, allowing it to propagate updates regardless of goroutine affinity.
+// Executors, which run updates in their own goroutine, must run tasks using it, if goroutine affinity is used.
+func RunSerialized(f func()) {
+	// Determining goroutine is not cheap, so it is skipped, when there is nothing to check.
+	if boundGoroutine.Load() == nil {
+		f()
+		return
+	}
+
+	gid := currentGoroutineID()
+	if _, alreadySerialized := serializedGoroutines.LoadOrStore(gid, struct{}{}); alreadySerialized {
+		f()
+		return
+	}
+	defer serializedGoroutines.Delete(gid)
+
+	f()
+}
+
+// checkAffinity reports failure, if propagation of update of the node is started from goroutine other than the bound one.
+func checkAffinity(node fmt.Stringer, bound *callSite) bool {
+	gid := currentGoroutineID()
+	if gid == bound.goroutine {
+		return true
+	}
+
+	if _, ok := serializedGoroutines.Load(gid); ok {
+		return true
+	}
+
+	current := currentCallSite()
+	fail(fmt.Errorf("NotifyUpdated of node %v is called from goroutine %v, but updates are bound to goroutine %v. "+
+		"Updates from other goroutines must be posted into executor or made while holding UpdateLock.\n"+
+		"Bound at:\n%s\nConflicting call:\n%s", node, gid, bound.goroutine, bound.stack, current.stack))
+
+	return false
+}
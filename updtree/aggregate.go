@@ -0,0 +1,74 @@
+package updtree
+
+import (
+	"strings"
+	"time"
+)
+
+// AggregateOpts configures node created by Aggregate.
+type AggregateOpts struct {
+	// Number of inputs, which must have updated since the last emission. Zero means all inputs.
+	Quorum int
+	// If set, updates of inputs older than Window are forgotten, so that quorum must be reached within the window.
+	// Age of updates is measured using Clock.
+	Window time.Duration
+	// Clock measuring age of updates. If not set, the clock set by SetClock is used.
+	Clock Clock
+}
+
+// Aggregate creates node, which is subscribed to all inputs and emits its own update only when quorum of inputs has updated
+// since its last emission, e.g. when both moving averages of a crossover indicator have values.
+// Upon emission fn is called, if set, and then subscribers of the node are notified as part of the same propagation.
+func Aggregate[Ctx any](fn func(ctx Ctx, evtTime time.Time), opts AggregateOpts, inputs ...Node[Ctx]) *NodeBase[Ctx] {
+	quorum := opts.Quorum
+	if quorum <= 0 || quorum > len(inputs) {
+		quorum = len(inputs)
+	}
+
+	names := make([]string, len(inputs))
+	for i, input := range inputs {
+		names[i] = input.getName()
+	}
+
+	n := NewNode[Ctx]("aggregate("+strings.Join(names, ",")+")", nil)
+	c := clockOr(opts.Clock)
+	// Whether each input has updated since the last emission, and time of its last update, if window is set.
+	updated := make([]bool, len(inputs))
+	updatedAt := make([]time.Time, len(inputs))
+
+	n.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		var now time.Time
+		if opts.Window > 0 {
+			now = c.Now()
+		}
+
+		count := 0
+		for i, input := range inputs {
+			if input.HasUpdated() {
+				updated[i], updatedAt[i] = true, now
+			} else if updated[i] && opts.Window > 0 && now.Sub(updatedAt[i]) > opts.Window {
+				updated[i] = false
+			}
+
+			if updated[i] {
+				count++
+			}
+		}
+
+		if count < quorum {
+			return
+		}
+
+		clear(updated)
+		if fn != nil {
+			fn(ctx, evtTime)
+		}
+		n.NotifyUpdated(ctx, evtTime)
+	})
+
+	for _, input := range inputs {
+		input.Subscribe(n)
+	}
+
+	return n
+}
@@ -0,0 +1,219 @@
+package updtree_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// benchNode creates node, which propagates update further when notified.
+func benchNode(name string) *UpdatePropagationNodeBase {
+	n := updtree.NewNode[Ctx](name, nil)
+	n.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		n.NotifyUpdated(ctx, evtTime)
+	})
+	return n
+}
+
+// benchChain creates chain of nodes, where each node is subscribed to the previous one.
+func benchChain(size int) *UpdatePropagationNodeBase {
+	root := benchNode("root")
+	prev := root
+
+	for i := 1; i < size; i++ {
+		n := benchNode(fmt.Sprintf("chain-%v", i))
+		prev.Subscribe(n)
+		prev = n
+	}
+
+	return root
+}
+
+// benchFanOut creates nodes, which all are subscribed to the same root.
+func benchFanOut(size int) *UpdatePropagationNodeBase {
+	root := benchNode("root")
+
+	for i := 1; i < size; i++ {
+		root.Subscribe(benchNode(fmt.Sprintf("fanout-%v", i)))
+	}
+
+	return root
+}
+
+// benchDiamonds creates layers of nodes, where each node is subscribed to two nodes of the previous layer.
+func benchDiamonds(size int) *UpdatePropagationNodeBase {
+	const width = 10
+
+	root := benchNode("root")
+	prevLayer := []*UpdatePropagationNodeBase{root}
+
+	for layer := 0; layer*width < size; layer++ {
+		currLayer := make([]*UpdatePropagationNodeBase, 0, width)
+
+		for i := 0; i < width; i++ {
+			n := benchNode(fmt.Sprintf("diamond-%v-%v", layer, i))
+			prevLayer[i%len(prevLayer)].Subscribe(n)
+			if len(prevLayer) > 1 {
+				prevLayer[(i+1)%len(prevLayer)].Subscribe(n)
+			}
+			currLayer = append(currLayer, n)
+		}
+
+		prevLayer = currLayer
+	}
+
+	return root
+}
+
+var benchTopologies = []struct {
+	name  string
+	build func(size int) *UpdatePropagationNodeBase
+}{
+	{"Chain", benchChain},
+	{"FanOut", benchFanOut},
+	{"Diamonds", benchDiamonds},
+}
+
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+// BenchmarkTree_FirstPropagation measures first propagation, which includes calculation of update order.
+func BenchmarkTree_FirstPropagation(b *testing.B) {
+	for _, topology := range benchTopologies {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("%v/%v", topology.name, size), func(b *testing.B) {
+				b.ReportAllocs()
+
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					root := topology.build(size)
+					b.StartTimer()
+
+					root.NotifyUpdated(context.Background(), time.Time{})
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkTree_Propagation measures propagation with already calculated update order.
+func BenchmarkTree_Propagation(b *testing.B) {
+	for _, topology := range benchTopologies {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("%v/%v", topology.name, size), func(b *testing.B) {
+				root := topology.build(size)
+				root.NotifyUpdated(context.Background(), time.Time{})
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					root.NotifyUpdated(context.Background(), time.Time{})
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkTree_SparsePropagation measures propagation, which affects only small part of a big tree:
+// direct subscribers of the root do not propagate update further to their own subscribers.
+func BenchmarkTree_SparsePropagation(b *testing.B) {
+	const directSubscribers = 10
+
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%v", size), func(b *testing.B) {
+			root := benchNode("root")
+
+			for i := 0; i < directSubscribers; i++ {
+				silent := updtree.NewNode[Ctx](fmt.Sprintf("silent-%v", i), func(ctx Ctx, evtTime time.Time) {})
+				root.Subscribe(silent)
+
+				prev := silent
+				for j := 0; j < size/directSubscribers; j++ {
+					n := benchNode(fmt.Sprintf("chain-%v-%v", i, j))
+					prev.Subscribe(n)
+					prev = n
+				}
+			}
+
+			root.NotifyUpdated(context.Background(), time.Time{})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				root.NotifyUpdated(context.Background(), time.Time{})
+			}
+		})
+	}
+}
+
+// BenchmarkTree_NoSubscribers measures notification of a node, which nobody is subscribed to.
+func BenchmarkTree_NoSubscribers(b *testing.B) {
+	leaf := benchNode("leaf")
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		leaf.NotifyUpdated(context.Background(), time.Time{})
+	}
+}
+
+// BenchmarkTree_ManyRoots measures first propagation from many roots, which share the same subscribers.
+// Without a tree each root calculates its own update order, while nodes of a tree share single one.
+func BenchmarkTree_ManyRoots(b *testing.B) {
+	const rootsCount = 100
+	const chainSize = 1000
+
+	build := func(newNode func(name string) *UpdatePropagationNodeBase) []*UpdatePropagationNodeBase {
+		head := newNode("chain-0")
+		prev := head
+		for i := 1; i < chainSize; i++ {
+			n := newNode(fmt.Sprintf("chain-%v", i))
+			prev.Subscribe(n)
+			prev = n
+		}
+
+		roots := make([]*UpdatePropagationNodeBase, 0, rootsCount)
+		for i := 0; i < rootsCount; i++ {
+			root := newNode(fmt.Sprintf("root-%v", i))
+			root.Subscribe(head)
+			roots = append(roots, root)
+		}
+
+		return roots
+	}
+
+	run := func(b *testing.B, newNode func() func(name string) *UpdatePropagationNodeBase) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			roots := build(newNode())
+			b.StartTimer()
+
+			for _, root := range roots {
+				root.NotifyUpdated(context.Background(), time.Time{})
+			}
+		}
+	}
+
+	b.Run("Own", func(b *testing.B) {
+		run(b, func() func(name string) *UpdatePropagationNodeBase {
+			return benchNode
+		})
+	})
+
+	b.Run("Tree", func(b *testing.B) {
+		run(b, func() func(name string) *UpdatePropagationNodeBase {
+			tree := updtree.NewTree[Ctx]()
+			return func(name string) *UpdatePropagationNodeBase {
+				n := benchNode(name)
+				tree.Attach(n)
+				return n
+			}
+		})
+	})
+}
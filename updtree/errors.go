@@ -0,0 +1,51 @@
+package updtree
+
+import (
+	"sync"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// maxRecordedErrors limits number of errors kept until TakeErrors is called,
+// because same misuse is usually repeated on each propagation.
+const maxRecordedErrors = 100
+
+var recordedErrors struct {
+	sync.Mutex
+	errs    []error
+	dropped int
+}
+
+// fail reports misuse according to the error policy.
+func fail(err error) {
+	cfg := getSettings()
+	if cfg.errorPolicy != utils.PolicyLenient {
+		utils.Fail(err)
+		return
+	}
+
+	if cfg.logger != nil {
+		cfg.logger.Errorf("%v", err)
+	}
+
+	recordedErrors.Lock()
+	defer recordedErrors.Unlock()
+
+	if len(recordedErrors.errs) < maxRecordedErrors {
+		recordedErrors.errs = append(recordedErrors.errs, err)
+	} else {
+		recordedErrors.dropped++
+	}
+}
+
+// TakeErrors returns misuse errors recorded under lenient error policy since previous call and forgets them.
+// Only first 100 errors are kept, dropped is the number of errors, which have been reported after that.
+func TakeErrors() (errs []error, dropped int) {
+	recordedErrors.Lock()
+	defer recordedErrors.Unlock()
+
+	errs, dropped = recordedErrors.errs, recordedErrors.dropped
+	recordedErrors.errs, recordedErrors.dropped = nil, 0
+
+	return errs, dropped
+}
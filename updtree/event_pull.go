@@ -1,6 +1,10 @@
 package updtree
 
-import "fmt"
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
 
 // NOTE: Not thread safe
 
@@ -13,15 +17,33 @@ func NewEventsPullStorage[Event any]() *EventsPullStorage[Event] {
 	return &EventsPullStorage[Event]{}
 }
 
+// Minimal number of erased events, after which storage is compacted.
+const minEventsToCompact = 1024
+
 type EventsPullStorage[Event any] struct {
 	eventsPushed int
 	events       []AccumulatedEvent[Event]
 	pullersCount int
+
+	// Number of events already read by all pullers, which are still retained by the underlying array of events.
+	// Slices returned by Pull point into that array, so instead of clearing those events in place
+	// they are released by moving remaining events into a new array.
+	erased int
+
+	// Pullers created while leak detection is enabled.
+	tracked []*EventPuller[Event]
 }
 
 func (a *EventsPullStorage[Event]) NewPuller() *EventPuller[Event] {
 	a.pullersCount++
-	return &EventPuller[Event]{acc: a}
+	p := &EventPuller[Event]{acc: a}
+
+	if getSettings().pullerLeakTimeout > 0 {
+		p.tracking = &pullerTracking{createdAt: debug.Stack(), lastPull: time.Now()}
+		a.tracked = append(a.tracked, p)
+	}
+
+	return p
 }
 
 func (a *EventsPullStorage[Event]) Publish(evt Event) {
@@ -30,32 +52,50 @@ func (a *EventsPullStorage[Event]) Publish(evt Event) {
 	}
 
 	a.eventsPushed++
+
+	if len(a.events) == cap(a.events) {
+		// Append will move events into a new array, so erased events will be released.
+		a.erased = 0
+	}
+
 	a.events = append(a.events, AccumulatedEvent[Event]{
 		Event:     &evt,
 		readTimes: 0,
 	})
 }
 
-func (a *EventsPullStorage[Event]) getEvents(from int) []AccumulatedEvent[Event] {
+// getEvents returns at most limit events published since from, or all of them if limit is negative.
+func (a *EventsPullStorage[Event]) getEvents(from, limit int) []AccumulatedEvent[Event] {
 	countToPull := a.eventsPushed - from
+	if limit >= 0 && countToPull > limit {
+		countToPull = limit
+	}
 	if countToPull == 0 {
 		return nil
 	}
 
-	firstEvtIdx := len(a.events) - countToPull
+	firstEvtIdx := len(a.events) - (a.eventsPushed - from)
 	if firstEvtIdx < 0 {
 		panic(fmt.Errorf("invalid event index: %v: current len = %v, eventsPushed = %v, from = %v",
 			firstEvtIdx, len(a.events), a.eventsPushed, from))
 	}
 
-	pulledEvents := a.events[firstEvtIdx:len(a.events):len(a.events)]
+	lastEvtIdx := firstEvtIdx + countToPull
+	pulledEvents := a.events[firstEvtIdx:lastEvtIdx:lastEvtIdx]
 	for i := range pulledEvents {
 		pulledEvents[i].readTimes++
 	}
 
+	a.eraseRead()
+
+	return pulledEvents
+}
+
+// eraseRead erases events, which have been read by all pullers.
+func (a *EventsPullStorage[Event]) eraseRead() {
 	countToErase := 0
 	for i := 0; i < len(a.events); i++ {
-		if a.events[i].readTimes != a.pullersCount {
+		if a.events[i].readTimes < a.pullersCount {
 			break
 		}
 		countToErase++
@@ -63,9 +103,42 @@ func (a *EventsPullStorage[Event]) getEvents(from int) []AccumulatedEvent[Event]
 
 	if countToErase > 0 {
 		a.events = a.events[countToErase:]
+		a.erased += countToErase
+
+		if a.erased >= minEventsToCompact && a.erased >= len(a.events) {
+			a.Compact()
+		}
 	}
+}
 
-	return pulledEvents
+// removePuller detaches puller, which has read all events, from the storage.
+func (a *EventsPullStorage[Event]) removePuller(p *EventPuller[Event]) {
+	a.pullersCount--
+	for i := range a.events {
+		a.events[i].readTimes--
+	}
+
+	for i, tracked := range a.tracked {
+		if tracked == p {
+			a.tracked = append(a.tracked[:i], a.tracked[i+1:]...)
+			break
+		}
+	}
+
+	a.eraseRead()
+}
+
+// Compact releases memory of events already read by all pullers.
+// It is called automatically, when erased events take at least a half of the buffer,
+// but can also be called explicitly, e.g. after a burst of events.
+func (a *EventsPullStorage[Event]) Compact() {
+	if len(a.events) == 0 {
+		a.events = nil
+	} else {
+		a.events = append(make([]AccumulatedEvent[Event], 0, len(a.events)), a.events...)
+	}
+
+	a.erased = 0
 }
 
 func (a *EventsPullStorage[Event]) Len() int {
@@ -76,18 +149,122 @@ func (a *EventsPullStorage[Event]) BufferSize() int {
 	return len(a.events)
 }
 
+// BufferCapacity returns number of events, for which memory is retained by the storage,
+// including events already read by all pullers but not yet released.
+func (a *EventsPullStorage[Event]) BufferCapacity() int {
+	return a.erased + cap(a.events)
+}
+
 func (a *EventsPullStorage[Event]) EventsPushed() int {
 	return a.eventsPushed
 }
 
+// PullersCount returns number of pullers created for the storage.
+func (a *EventsPullStorage[Event]) PullersCount() int {
+	return a.pullersCount
+}
+
+// EventsStats contains counters of the storage for monitoring and debugging.
+type EventsStats struct {
+	BufferSize     int `json:"bufferSize"`
+	BufferCapacity int `json:"bufferCapacity"`
+	EventsPushed   int `json:"eventsPushed"`
+	Pullers        int `json:"pullers"`
+	// Number of abandoned pullers. Counted only if leak detection is enabled using SetPullerLeakDetection.
+	AbandonedPullers int `json:"abandonedPullers,omitempty"`
+}
+
+func (a *EventsPullStorage[Event]) Stats() EventsStats {
+	return EventsStats{
+		BufferSize:     a.BufferSize(),
+		BufferCapacity: a.BufferCapacity(),
+		EventsPushed:   a.EventsPushed(),
+		Pullers:        a.PullersCount(),
+
+		AbandonedPullers: len(a.AbandonedPullers()),
+	}
+}
+
+// AbandonedPuller describes puller, which has unread events, but has not pulled them for longer
+// than timeout set by SetPullerLeakDetection.
+type AbandonedPuller struct {
+	// Number of events published since last pull of the puller.
+	Unread      int  `json:"unread"`
+	NeverPulled bool `json:"neverPulled"`
+	// Time of the last pull, or time of creation of the puller, if it has never pulled.
+	LastPull time.Time `json:"lastPull"`
+	// Stack trace of the call, which has created the puller.
+	CreatedAt string `json:"createdAt"`
+}
+
+// AbandonedPullers returns pullers of the storage, which look abandoned. Only pullers created while
+// leak detection is enabled are checked, so it returns nil if leak detection has never been enabled.
+func (a *EventsPullStorage[Event]) AbandonedPullers() []AbandonedPuller {
+	leakTimeout := getSettings().pullerLeakTimeout
+	if len(a.tracked) == 0 || leakTimeout <= 0 {
+		return nil
+	}
+
+	var abandoned []AbandonedPuller
+	now := time.Now()
+
+	for _, p := range a.tracked {
+		unread := a.eventsPushed - p.cursor
+		if unread == 0 || now.Sub(p.tracking.lastPull) < leakTimeout {
+			continue
+		}
+
+		abandoned = append(abandoned, AbandonedPuller{
+			Unread:      unread,
+			NeverPulled: !p.tracking.pulled,
+			LastPull:    p.tracking.lastPull,
+			CreatedAt:   string(p.tracking.createdAt),
+		})
+	}
+
+	return abandoned
+}
+
 type EventPuller[Event any] struct {
 	acc    *EventsPullStorage[Event]
 	cursor int
+	closed bool
+
+	// Set only if leak detection was enabled, when puller was created.
+	tracking *pullerTracking
+}
+
+type pullerTracking struct {
+	createdAt []byte
+	lastPull  time.Time
+	pulled    bool
 }
 
 // Pulls all events from the storage published since last pull.
 func (p *EventPuller[Event]) Pull() []AccumulatedEvent[Event] {
-	events := p.acc.getEvents(p.cursor)
+	return p.pull(-1)
+}
+
+// PullN pulls at most n oldest events published since last pull. Remaining events are returned by next pulls.
+func (p *EventPuller[Event]) PullN(n int) []AccumulatedEvent[Event] {
+	if n < 0 {
+		panic(fmt.Errorf("invalid number of events to pull: %v", n))
+	}
+
+	return p.pull(n)
+}
+
+func (p *EventPuller[Event]) pull(limit int) []AccumulatedEvent[Event] {
+	if p.closed {
+		return nil
+	}
+
+	if p.tracking != nil {
+		p.tracking.lastPull = time.Now()
+		p.tracking.pulled = true
+	}
+
+	events := p.acc.getEvents(p.cursor, limit)
 	if len(events) == 0 {
 		return nil
 	}
@@ -106,3 +283,43 @@ func (p *EventPuller[Event]) Last() (lastPublishedEventIfExists *Event) {
 
 	return events[len(events)-1].Event
 }
+
+// LastOr is same as Last, but returns def if no events were published since last pull.
+func (p *EventPuller[Event]) LastOr(def Event) Event {
+	if last := p.Last(); last != nil {
+		return *last
+	}
+
+	return def
+}
+
+// LastOK is same as Last, but returns false instead of nil if no events were published since last pull.
+func (p *EventPuller[Event]) LastOK() (Event, bool) {
+	if last := p.Last(); last != nil {
+		return *last, true
+	}
+
+	var zero Event
+	return zero, false
+}
+
+// Unread returns number of events published since last pull.
+func (p *EventPuller[Event]) Unread() int {
+	if p.closed {
+		return 0
+	}
+
+	return p.acc.eventsPushed - p.cursor
+}
+
+// Close discards unread events and detaches puller from the storage, so that events are no longer retained for it.
+// Closed puller does not return any events. Repeated calls are ignored.
+func (p *EventPuller[Event]) Close() {
+	if p.closed {
+		return
+	}
+
+	p.Pull()
+	p.closed = true
+	p.acc.removePuller(p)
+}
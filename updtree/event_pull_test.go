@@ -1,7 +1,9 @@
 package updtree_test
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/nnikolash/go-shdep/updtree"
 	"github.com/stretchr/testify/require"
@@ -118,3 +120,160 @@ func TestEventAccum_MultiplePullersMultiplePulls(t *testing.T) {
 	nonExistantEvt := puller1.Last()
 	require.Nil(t, nonExistantEvt)
 }
+
+func TestEventAccum_CompactAfterBurst(t *testing.T) {
+	t.Parallel()
+
+	publisher := updtree.NewEventsPullStorage[int]()
+	puller := publisher.NewPuller()
+
+	for i := 0; i < 10000; i++ {
+		publisher.Publish(i)
+	}
+
+	require.GreaterOrEqual(t, publisher.BufferCapacity(), 10000)
+
+	events := puller.Pull()
+	require.Equal(t, 10000, len(events))
+	require.Equal(t, 0, publisher.Len())
+	require.Equal(t, 0, publisher.BufferCapacity())
+}
+
+func TestEventAccum_SlowPullerBufferIsBounded(t *testing.T) {
+	t.Parallel()
+
+	publisher := updtree.NewEventsPullStorage[int]()
+	fastPuller := publisher.NewPuller()
+	slowPuller := publisher.NewPuller()
+
+	maxCapacity := 0
+	for i := 0; i < 100000; i++ {
+		publisher.Publish(i)
+		fastPuller.Pull()
+
+		if i%100 == 0 {
+			events := slowPuller.Pull()
+			require.Equal(t, i, *events[len(events)-1].Event)
+		}
+
+		maxCapacity = max(maxCapacity, publisher.BufferCapacity())
+	}
+
+	require.LessOrEqual(t, maxCapacity, 4096)
+}
+
+func TestEventAccum_CompactReleasesMemory(t *testing.T) {
+	const eventsCount = 32
+	const eventSize = 1 << 20
+
+	publisher := updtree.NewEventsPullStorage[[]byte]()
+	puller1 := publisher.NewPuller()
+	puller2 := publisher.NewPuller()
+
+	for i := 0; i < eventsCount; i++ {
+		publisher.Publish(make([]byte, eventSize))
+	}
+
+	require.Equal(t, eventsCount, len(puller1.Pull()))
+	require.Equal(t, eventsCount, len(puller2.Pull()))
+	require.Equal(t, 0, publisher.Len())
+
+	heapBefore := heapAlloc()
+	publisher.Compact()
+	heapAfter := heapAlloc()
+
+	require.Less(t, heapAfter, heapBefore-eventsCount*eventSize/2)
+	require.Equal(t, 0, publisher.BufferCapacity())
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return stats.HeapAlloc
+}
+
+// Not parallel, because leak detection is enabled for the whole package.
+func TestEventAccum_AbandonedPullers(t *testing.T) {
+	updtree.SetPullerLeakDetection(time.Millisecond)
+	defer updtree.SetPullerLeakDetection(0)
+
+	publisher := updtree.NewEventsPullStorage[int]()
+	active := publisher.NewPuller()
+	abandoned := publisher.NewPuller()
+
+	publisher.Publish(1)
+	publisher.Publish(2)
+	require.Empty(t, publisher.AbandonedPullers())
+
+	time.Sleep(5 * time.Millisecond)
+	require.Len(t, active.Pull(), 2)
+
+	pullers := publisher.AbandonedPullers()
+	require.Len(t, pullers, 1)
+	require.Equal(t, 2, pullers[0].Unread)
+	require.True(t, pullers[0].NeverPulled)
+	require.Contains(t, pullers[0].CreatedAt, "TestEventAccum_AbandonedPullers")
+	require.Equal(t, 1, publisher.Stats().AbandonedPullers)
+
+	require.Len(t, abandoned.Pull(), 2)
+	require.Empty(t, publisher.AbandonedPullers())
+}
+
+func TestEventAccum_PullNAndClose(t *testing.T) {
+	t.Parallel()
+
+	publisher := updtree.NewEventsPullStorage[int]()
+	puller1 := publisher.NewPuller()
+	puller2 := publisher.NewPuller()
+	for i := 1; i <= 5; i++ {
+		publisher.Publish(i)
+	}
+
+	events := puller1.PullN(2)
+	require.Len(t, events, 2)
+	require.Equal(t, 1, *events[0].Event)
+	require.Equal(t, 2, *events[1].Event)
+	require.Equal(t, 3, puller1.Unread())
+	require.Equal(t, 5, publisher.Len())
+
+	require.Nil(t, puller1.PullN(0))
+	require.Equal(t, 5, *puller1.Last())
+	require.Equal(t, 0, puller1.Unread())
+	require.Equal(t, 5, publisher.Len())
+
+	// Events are retained only for puller2, so closing it releases them.
+	puller2.Close()
+	puller2.Close()
+	require.Equal(t, 0, publisher.Len())
+	require.Equal(t, 1, publisher.PullersCount())
+	require.Equal(t, 0, puller2.Unread())
+
+	publisher.Publish(6)
+	require.Nil(t, puller2.Pull())
+	require.Equal(t, 6, *puller1.Last())
+	require.Equal(t, 0, publisher.Len())
+}
+
+func TestEventAccum_LastOrDefault(t *testing.T) {
+	t.Parallel()
+
+	publisher := updtree.NewEventsPullStorage[int]()
+	puller := publisher.NewPuller()
+
+	require.Equal(t, -1, puller.LastOr(-1))
+	last, ok := puller.LastOK()
+	require.False(t, ok)
+	require.Equal(t, 0, last)
+
+	publisher.Publish(1)
+	publisher.Publish(2)
+	require.Equal(t, 2, puller.LastOr(-1))
+
+	publisher.Publish(3)
+	last, ok = puller.LastOK()
+	require.True(t, ok)
+	require.Equal(t, 3, last)
+}
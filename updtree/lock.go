@@ -0,0 +1,52 @@
+package updtree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// NewUpdateLock wraps lock, which protects update tree from concurrent external updates,
+// so that its re-entrant acquisition panics instead of deadlocking. If l is nil, sync.Mutex is used.
+//
+// Re-entrant acquisition usually happens when update handler synchronously calls external producer,
+// which takes the lock to notify about its own update. The panic message contains stack traces of both
+// acquisitions. Stack trace is captured on each acquisition, so the lock is intended for development and tests.
+func NewUpdateLock(l sync.Locker) *UpdateLock {
+	if l == nil {
+		l = &sync.Mutex{}
+	}
+
+	return &UpdateLock{l: l}
+}
+
+type UpdateLock struct {
+	l sync.Locker
+	// ID of goroutine holding the lock, or zero.
+	holder atomic.Uint64
+	// Acquisition, which holds the lock. Protected by the lock itself.
+	acquisition *callSite
+}
+
+var _ sync.Locker = &UpdateLock{}
+
+func (l *UpdateLock) Lock() {
+	current := currentCallSite()
+	if current.goroutine != 0 && l.holder.Load() == current.goroutine {
+		panic(fmt.Sprintf("deadlock: update lock is acquired by goroutine %v, which already holds it. "+
+			"If this happens in update handler, notify about update directly instead of taking the lock again.\n"+
+			"Lock acquired at:\n%s\nRe-entrant acquisition:\n%s", current.goroutine, l.acquisition.stack, current.stack))
+	}
+
+	l.l.Lock()
+	l.acquisition = current
+	l.holder.Store(current.goroutine)
+	serializedGoroutines.Store(current.goroutine, struct{}{})
+}
+
+func (l *UpdateLock) Unlock() {
+	serializedGoroutines.Delete(l.holder.Load())
+	l.holder.Store(0)
+	l.acquisition = nil
+	l.l.Unlock()
+}
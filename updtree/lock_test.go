@@ -0,0 +1,61 @@
+package updtree_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UpdateLock(t *testing.T) {
+	t.Parallel()
+
+	lock := updtree.NewUpdateLock(nil)
+
+	// External producer notifies its node about updates under the lock.
+	producer := newUpdatePropagationNode("producer", func(self UpdatePropagationNode) {})
+	produce := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		producer.NotifyUpdated(context.Background(), time.Time{})
+	}
+
+	// Handler of the strategy synchronously calls back into the producer.
+	strategy := newUpdatePropagationNode("strategy", func(self UpdatePropagationNode) {
+		produce()
+	})
+	producer.Subscribe(strategy)
+
+	var panicMsg string
+	func() {
+		defer func() {
+			panicMsg = fmt.Sprint(recover())
+		}()
+
+		produce()
+	}()
+
+	require.Contains(t, panicMsg, "deadlock: update lock is acquired by goroutine")
+	require.Contains(t, panicMsg, "Lock acquired at:")
+	require.Contains(t, panicMsg, "Re-entrant acquisition:")
+
+	// Lock has been released by deferred unlock of the outer acquisition, so it is usable again.
+	var wg sync.WaitGroup
+	counter := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock.Lock()
+			counter++
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 10, counter)
+}
@@ -0,0 +1,76 @@
+package updtree
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// MutePolicy defines what happens with updates of subscriptions of a muted node.
+type MutePolicy int32
+
+const (
+	// Updates received while node is muted are dropped.
+	MuteDrop MutePolicy = iota
+	// Updates received while node is muted are collapsed into one, which is handled upon Unmute
+	// with context and event time of the last of them.
+	MuteBuffer
+)
+
+func (p MutePolicy) String() string {
+	switch p {
+	case MuteDrop:
+		return "drop"
+	case MuteBuffer:
+		return "buffer"
+	default:
+		return "unknown"
+	}
+}
+
+// Mute stops invocations of update handler of the node without detaching it from the tree, so that its subscriptions
+// and subscribers are kept. Updates of subscriptions are dropped or buffered according to policy.
+// Since handler is not called, subscribers of the node are not notified either, unless node notifies them itself.
+// Can be called from any goroutine. Repeated call changes the policy.
+func (n *NodeBase[Ctx]) Mute(policy MutePolicy) {
+	atomic.StoreInt32(&n.muted, int32(policy)+1)
+}
+
+// Unmute resumes invocations of update handler of the node. If node was muted with MuteBuffer and has received updates,
+// handler is called immediately with the last of them, so in that case Unmute must be called from the goroutine,
+// which propagates updates, and not during propagation.
+func (n *NodeBase[Ctx]) Unmute() {
+	if atomic.SwapInt32(&n.muted, 0) == 0 {
+		return
+	}
+
+	if !n.mutedPending {
+		return
+	}
+
+	ctx, evtTime := n.mutedCtx, n.mutedEvtTime
+	var zero Ctx
+	n.mutedCtx, n.mutedEvtTime, n.mutedPending = zero, time.Time{}, false
+
+	n.updatesHandled++
+	n.handleSubscriptionsUpdated(ctx, evtTime)
+}
+
+// IsMuted reports whether node is muted by Mute.
+func (n *NodeBase[Ctx]) IsMuted() bool {
+	return atomic.LoadInt32(&n.muted) != 0
+}
+
+// skipMuted records update of subscriptions, if node is muted, and reports whether handler must not be called.
+func (n *NodeBase[Ctx]) skipMuted(ctx Ctx, evtTime time.Time) bool {
+	muted := atomic.LoadInt32(&n.muted)
+	if muted == 0 {
+		return false
+	}
+
+	n.updatesMuted++
+	if MutePolicy(muted-1) == MuteBuffer {
+		n.mutedCtx, n.mutedEvtTime, n.mutedPending = ctx, evtTime, true
+	}
+
+	return true
+}
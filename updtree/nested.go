@@ -0,0 +1,77 @@
+package updtree
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// NestedPropagationMode defines what happens, when update handler calls NotifyUpdated of a node,
+// which is not affected by the running propagation, e.g. node of another independent tree.
+// Update of such node can't be handled by the running propagation, so it is propagated separately.
+type NestedPropagationMode int
+
+const (
+	// NestedPropagationAllow propagates such update right away, nested inside the running propagation.
+	// Subscribers, which are affected by both propagations, can then be updated before the running propagation
+	// has updated their other subscriptions, so the result depends on the order of subscriptions.
+	NestedPropagationAllow NestedPropagationMode = iota
+	// NestedPropagationQueue postpones propagation of such update until the running propagation has finished.
+	NestedPropagationQueue
+	// NestedPropagationFail reports such call according to the error policy with names of both nodes
+	// and does not propagate the update.
+	NestedPropagationFail
+)
+
+// SetNestedPropagation sets what happens, when update handler notifies about update of a node,
+// which is not affected by the running propagation. Default is NestedPropagationAllow.
+// Other modes require determining goroutine of each propagation, which makes propagation noticeably slower.
+func SetNestedPropagation(mode NestedPropagationMode) {
+	updateSettings(func(s *settings) { s.nestedPropagation = mode })
+}
+
+// activePropagation is the propagation, which is running in a goroutine, with the propagations postponed until it finishes.
+type activePropagation struct {
+	source fmt.Stringer
+	queue  []func()
+}
+
+// activePropagations maps ID of goroutine to its activePropagation.
+var activePropagations sync.Map
+
+// guardNestedPropagation runs propagation, unless propagation is already running in the current goroutine.
+// Otherwise propagation is postponed or reported depending on nested propagation mode.
+func guardNestedPropagation(source fmt.Stringer, run func()) {
+	gid := currentGoroutineID()
+
+	if v, ok := activePropagations.Load(gid); ok {
+		active := v.(*activePropagation)
+
+		if getSettings().nestedPropagation == NestedPropagationFail {
+			fail(fmt.Errorf("NotifyUpdated of node %v is called from update handler during propagation of update of node %v, "+
+				"which does not affect it, so the update would be propagated nested into the running propagation", source, active.source))
+			return
+		}
+
+		active.queue = append(active.queue, run)
+		return
+	}
+
+	active := &activePropagation{source: source}
+	activePropagations.Store(gid, active)
+	defer activePropagations.Delete(gid)
+
+	run()
+
+	// Postponed propagations can postpone more propagations.
+	for len(active.queue) > 0 {
+		next := active.queue[0]
+		active.queue = active.queue[1:]
+		next()
+	}
+}
+
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	return goroutineID(buf[:runtime.Stack(buf[:], false)])
+}
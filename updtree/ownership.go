@@ -0,0 +1,96 @@
+package updtree
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// callSite identifies goroutine and stack trace of a call.
+type callSite struct {
+	goroutine uint64
+	stack     []byte
+}
+
+// ownership is a token of the running propagation. It allows to detect calls of NotifyUpdated
+// from other goroutines, which would corrupt state of the nodes.
+type ownership struct {
+	owner atomic.Pointer[callSite]
+}
+
+// acquire takes ownership of the propagation for the current goroutine.
+// Returns false and reports failure, if propagation is already owned by another goroutine.
+func (o *ownership) acquire(source fmt.Stringer) bool {
+	current := currentCallSite()
+	if o.owner.CompareAndSwap(nil, current) {
+		return true
+	}
+
+	return o.check(source, current)
+}
+
+func (o *ownership) release() {
+	o.owner.Store(nil)
+}
+
+// check reports failure, if propagation is owned by goroutine other than the current one.
+func (o *ownership) check(node fmt.Stringer, current *callSite) bool {
+	owner := o.owner.Load()
+	if owner == nil || owner.goroutine == current.goroutine {
+		return true
+	}
+
+	fail(fmt.Errorf("NotifyUpdated of node %v is called from goroutine %v, while update is being propagated by goroutine %v. "+
+		"Updates from outside of update handlers must be serialized, e.g. using lock.\n"+
+		"Propagation:\n%s\nConflicting call:\n%s", node, current.goroutine, owner.goroutine, owner.stack, current.stack))
+
+	return false
+}
+
+// checkOwnership reports failure, if any of propagations, which can include the node, is running in another goroutine.
+func (n *NodeBase[Ctx]) checkOwnership() bool {
+	var current *callSite
+
+	for _, p := range [...]*propagation[Ctx]{n.propagationOfTree(), n.positionOwner, n.propagation} {
+		if p == nil || p.ownership == nil || p.ownership.owner.Load() == nil {
+			continue
+		}
+
+		if current == nil {
+			current = currentCallSite()
+		}
+		if !p.ownership.check(n, current) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *NodeBase[Ctx]) propagationOfTree() *propagation[Ctx] {
+	if n.tree != nil {
+		return &n.tree.propagation
+	}
+
+	return &n.ownPropagation
+}
+
+func currentCallSite() *callSite {
+	stack := make([]byte, 4096)
+	stack = stack[:runtime.Stack(stack, false)]
+
+	return &callSite{goroutine: goroutineID(stack), stack: stack}
+}
+
+// goroutineID parses ID of goroutine from the header of its stack trace: "goroutine 123 [running]:".
+func goroutineID(stack []byte) uint64 {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i >= 0 {
+		stack = stack[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(stack), 10, 64)
+	return id
+}
@@ -0,0 +1,250 @@
+package updtree
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock provides current time and timers to nodes, which control rate of updates.
+// It is implemented by simtime.Scheduler and shdeptest.Clock, which fire timers while time is advanced,
+// and by wall clocks created by NewWallClock and shdepexec.NewWallClock.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc schedules f to be called after d. Returned function cancels timer and reports whether it was cancelled before firing.
+	AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool) {
+	return time.AfterFunc(d, func() { f(time.Now()) }).Stop
+}
+
+// NewWallClock creates wall clock, which fires timers holding l, e.g. UpdateLock protecting update tree
+// from concurrent external updates. This way propagations started from timers by Throttle and Debounce
+// do not race with other updates. If updates are posted into an executor instead, use shdepexec.NewWallClock.
+func NewWallClock(l sync.Locker) Clock {
+	if l == nil {
+		fail(fmt.Errorf("lock of wall clock must not be nil"))
+	}
+
+	return lockedClock{l: l}
+}
+
+type lockedClock struct {
+	l sync.Locker
+}
+
+func (lockedClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c lockedClock) AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool) {
+	return time.AfterFunc(d, func() {
+		c.l.Lock()
+		defer c.l.Unlock()
+
+		f(time.Now())
+	}).Stop
+}
+
+// rateLimiter keeps the last update of the source, which has not been passed to subscribers yet.
+// Timers of the clock can fire from another goroutine, so state is protected by lock.
+type rateLimiter[Ctx any] struct {
+	lock    sync.Mutex
+	node    *NodeBase[Ctx]
+	clock   Clock
+	ctx     Ctx
+	evtTime time.Time
+	pending bool
+	stop    func() bool
+}
+
+// ClockOption configures clock of nodes created by Throttle and Debounce.
+type ClockOption func(c *Clock)
+
+// WithClock makes the node use c instead of the clock set by SetClock, e.g. so that trees of parallel tests
+// or backtests use their own clocks.
+func WithClock(c Clock) ClockOption {
+	return func(clock *Clock) {
+		*clock = c
+	}
+}
+
+// clockOr returns c, if it is set, or the clock set by SetClock otherwise.
+func clockOr(c Clock) Clock {
+	if c != nil {
+		return c
+	}
+
+	return getSettings().clock
+}
+
+func newRateLimiter[Ctx any](kind string, src Node[Ctx], opts []ClockOption) *rateLimiter[Ctx] {
+	var clock Clock
+	for _, opt := range opts {
+		opt(&clock)
+	}
+
+	clock = clockOr(clock)
+	if _, ok := clock.(realClock); ok {
+		fail(fmt.Errorf("%v of node %v requires clock, which fires timers serialized with other updates of the tree: "+
+			"set virtual clock, NewWallClock or shdepexec.NewWallClock using WithClock or SetClock", kind, src.getName()))
+	}
+
+	l := &rateLimiter[Ctx]{
+		node:  NewNode[Ctx](kind+"("+src.getName()+")", nil),
+		clock: clock,
+	}
+	src.Subscribe(l.node)
+
+	return l
+}
+
+// takePending returns pending update and clears it. Must be called under lock.
+func (l *rateLimiter[Ctx]) takePending() (ctx Ctx, evtTime time.Time, ok bool) {
+	ctx, evtTime, ok = l.ctx, l.evtTime, l.pending
+
+	var zero Ctx
+	l.ctx, l.evtTime, l.pending = zero, time.Time{}, false
+	l.stop = nil
+
+	return ctx, evtTime, ok
+}
+
+// Throttle creates node, which passes updates of src to its subscribers at most once per minInterval.
+// First update is passed immediately as part of propagation of src. Updates received during the interval
+// after it are collapsed into one, which is passed with context and event time of the last of them,
+// when the interval ends. This update starts its own propagation from the timer of the clock (see WithClock and SetClock),
+// so default wall clock, which fires timers from other goroutines without synchronization, is refused.
+func Throttle[Ctx any](src Node[Ctx], minInterval time.Duration, opts ...ClockOption) *NodeBase[Ctx] {
+	l := newRateLimiter("throttle", src, opts)
+	var lastPassed time.Time
+	passed := false
+
+	var fire func(now time.Time)
+	fire = func(now time.Time) {
+		l.lock.Lock()
+		ctx, evtTime, ok := l.takePending()
+		if ok {
+			lastPassed = now
+		}
+		l.lock.Unlock()
+
+		if ok {
+			l.node.NotifyUpdated(ctx, evtTime)
+		}
+	}
+
+	l.node.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		now := l.clock.Now()
+
+		l.lock.Lock()
+		if l.stop == nil && (!passed || now.Sub(lastPassed) >= minInterval) {
+			passed = true
+			lastPassed = now
+			l.lock.Unlock()
+
+			l.node.NotifyUpdated(ctx, evtTime)
+			return
+		}
+
+		l.ctx, l.evtTime, l.pending = ctx, evtTime, true
+		if l.stop == nil {
+			l.stop = l.clock.AfterFunc(lastPassed.Add(minInterval).Sub(now), fire)
+		}
+		l.lock.Unlock()
+	})
+
+	return l.node
+}
+
+// Debounce creates node, which passes update of src to its subscribers only after src has not been updated for quiet period.
+// Updates received in the meantime are collapsed into one with context and event time of the last of them.
+// This update is passed from the timer of the clock (see WithClock and SetClock) and starts its own propagation,
+// so as with Throttle, default wall clock is refused.
+func Debounce[Ctx any](src Node[Ctx], quiet time.Duration, opts ...ClockOption) *NodeBase[Ctx] {
+	l := newRateLimiter("debounce", src, opts)
+	// Incremented on each update, so that timers of previous updates do nothing, if they could not be stopped.
+	var generation uint64
+
+	l.node.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+
+		if l.stop != nil {
+			l.stop()
+		}
+
+		generation++
+		scheduled := generation
+		l.ctx, l.evtTime, l.pending = ctx, evtTime, true
+		l.stop = l.clock.AfterFunc(quiet, func(now time.Time) {
+			l.lock.Lock()
+			if scheduled != generation {
+				l.lock.Unlock()
+				return
+			}
+			ctx, evtTime, ok := l.takePending()
+			l.lock.Unlock()
+
+			if ok {
+				l.node.NotifyUpdated(ctx, evtTime)
+			}
+		})
+	})
+
+	return l.node
+}
+
+// RateLimitOpts configures node created by RateLimited.
+type RateLimitOpts[Ctx any] struct {
+	// Maximum average number of updates per second.
+	Rate float64
+	// Maximum number of updates, which can be passed at once after a period of inactivity. Minimum is 1.
+	Burst int
+	// Called with each update, which is dropped because of the limit. Optional.
+	OnOverflow func(ctx Ctx, evtTime time.Time)
+	// Clock measuring time. If not set, the clock set by SetClock is used.
+	Clock Clock
+}
+
+// RateLimited creates node, which passes updates of src to its subscribers as part of propagation of src,
+// while they fit into token bucket limit, e.g. to protect order submission of a strategy from bursts of signals.
+// Updates exceeding the limit are dropped and reported to OnOverflow. Time is measured using opts.Clock.
+func RateLimited[Ctx any](src Node[Ctx], opts RateLimitOpts[Ctx]) *NodeBase[Ctx] {
+	if opts.Rate <= 0 {
+		fail(fmt.Errorf("rate limit of updates of node %v must be positive, but got %v", src.getName(), opts.Rate))
+	}
+
+	n := NewNode[Ctx]("rateLimited("+src.getName()+")", nil)
+	c := clockOr(opts.Clock)
+	burst := float64(max(opts.Burst, 1))
+	tokens := burst
+	last := c.Now()
+
+	n.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		now := c.Now()
+		tokens = min(burst, tokens+now.Sub(last).Seconds()*opts.Rate)
+		last = now
+
+		if tokens < 1 {
+			if opts.OnOverflow != nil {
+				opts.OnOverflow(ctx, evtTime)
+			}
+			return
+		}
+
+		tokens--
+		n.NotifyUpdated(ctx, evtTime)
+	})
+
+	src.Subscribe(n)
+
+	return n
+}
@@ -0,0 +1,160 @@
+package updtree
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// settings of the package, which are changed by SetX functions. Settings are replaced as a whole,
+// so they can be changed at any time, e.g. from another goroutine while updates are propagated.
+// Operations, which are already running, may keep using previous values.
+type settings struct {
+	logger                  utils.Logger
+	profilerLabels          bool
+	propagationContext      bool
+	ownershipChecks         bool
+	errorPolicy             utils.ErrorPolicy
+	pullerLeakTimeout       time.Duration
+	evtTimeCheck            bool
+	evtTimeMaxSkew          time.Duration
+	measureHandlerDurations bool
+	observer                Observer
+	subscriptionSites       bool
+	clock                   Clock
+	nestedPropagation       NestedPropagationMode
+}
+
+var (
+	currentSettings atomic.Pointer[settings]
+	// Serializes changes of settings, so that concurrent changes are not lost.
+	settingsLock    sync.Mutex
+	defaultSettings = settings{clock: realClock{}}
+)
+
+// getSettings returns current settings. Returned value must not be modified.
+func getSettings() *settings {
+	if s := currentSettings.Load(); s != nil {
+		return s
+	}
+
+	return &defaultSettings
+}
+
+func updateSettings(update func(s *settings)) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+
+	next := *getSettings()
+	update(&next)
+	currentSettings.Store(&next)
+}
+
+// SetLogger sets logger, which is used to trace update propagations.
+// By default nothing is logged.
+func SetLogger(l utils.Logger) {
+	updateSettings(func(s *settings) { s.logger = l })
+}
+
+// SetProfilerLabels enables pprof labels "shdep.node" and "shdep.phase" around invocations of update handlers,
+// so that CPU profiles attribute time to specific nodes. If context of propagation is context.Context,
+// handlers receive it with the labels attached.
+// Disabled by default, because labels require allocations on each invocation.
+func SetProfilerLabels(enabled bool) {
+	updateSettings(func(s *settings) { s.profilerLabels = enabled })
+}
+
+// SetPropagationContext enables attaching PropagationInfo to context of propagation before invocations of
+// update handlers, so that it can be retrieved using PropagationInfoFromContext, e.g. by SlogHandler.
+// Has effect only if context of propagation is context.Context.
+// Disabled by default, because attaching the info requires allocations on each invocation.
+func SetPropagationContext(enabled bool) {
+	updateSettings(func(s *settings) { s.propagationContext = enabled })
+}
+
+// SetOwnershipChecks enables detection of NotifyUpdated called from another goroutine while update is being propagated,
+// e.g. by a goroutine started from update handler. Such calls are reported according to the error policy with stack traces of both
+// the propagation and the conflicting call, instead of corrupting state of the nodes.
+// Disabled by default, because it requires capturing stack traces on each propagation and on each call
+// of NotifyUpdated made during propagation.
+func SetOwnershipChecks(enabled bool) {
+	updateSettings(func(s *settings) { s.ownershipChecks = enabled })
+}
+
+// SetErrorPolicy sets how misuse of nodes is reported, e.g. cycles of subscriptions, attachment of node to another tree
+// or NotifyUpdated called from another goroutine. By default utils.PolicyStrict is used, which reports misuse to utils.Fail.
+// With utils.PolicyLenient misuse never panics: failed operation is skipped, the error is written to the logger
+// and recorded to be returned by TakeErrors. UpdateLock is not affected, because it is used only to catch deadlocks.
+func SetErrorPolicy(p utils.ErrorPolicy) {
+	updateSettings(func(s *settings) { s.errorPolicy = p })
+}
+
+// SetPullerLeakDetection enables tracking of event pullers, which have unread events, but have not pulled them
+// for longer than timeout (or have never pulled), so that their storage keeps growing. Such pullers are reported
+// by EventsPullStorage.AbandonedPullers with the call site, which has created them, and counted in EventsStats.
+// Zero timeout disables tracking, which is the default. Affects only pullers created after the call,
+// because capturing stack trace of each created puller is slow.
+func SetPullerLeakDetection(timeout time.Duration) {
+	updateSettings(func(s *settings) { s.pullerLeakTimeout = timeout })
+}
+
+// SetEventTimeCheck enables check of event times passed into NotifyUpdated. Zero time or time, which differs
+// from the wall clock by more than maxSkew, almost always indicates a bug in an adapter of external data feed.
+// Such calls are written to the logger as warnings and counted in NodeStats.
+// If maxSkew is zero, only zero times are reported, e.g. for backtesting, where events have past times.
+// Disabled by default.
+func SetEventTimeCheck(enabled bool, maxSkew time.Duration) {
+	updateSettings(func(s *settings) {
+		s.evtTimeCheck = enabled
+		s.evtTimeMaxSkew = maxSkew
+	})
+}
+
+// SetMeasureHandlerDurations enables measurement of time spent in update handlers, which is reported by NodeBase.Stats.
+// Disabled by default, because reading the clock takes a noticeable part of propagation time.
+func SetMeasureHandlerDurations(enabled bool) {
+	updateSettings(func(s *settings) { s.measureHandlerDurations = enabled })
+}
+
+// Observer receives notifications about propagation of updates, e.g. to collect metrics.
+// It is called synchronously during propagation, so it must be fast.
+type Observer interface {
+	// PropagationFinished is called after update of the source node has been propagated.
+	// NodesUpdated is the number of invoked update handlers.
+	PropagationFinished(source string, nodesUpdated int, duration time.Duration)
+
+	// UpdateHandled is called after update handler of the node has returned.
+	UpdateHandled(node string, duration time.Duration)
+}
+
+// SetObserver sets observer of update propagations. By default there is no observer.
+func SetObserver(o Observer) {
+	updateSettings(func(s *settings) { s.observer = o })
+}
+
+// SetSubscriptionSites enables recording of stack trace of each call of Subscribe, which is then returned by
+// Subscription.CreatedAt, so that it is known where subscriptions have been made.
+// Disabled by default, because capturing stack trace on each subscription is slow.
+func SetSubscriptionSites(enabled bool) {
+	updateSettings(func(s *settings) { s.subscriptionSites = enabled })
+}
+
+// SetClock sets clock, which is used by nodes created by Throttle, Debounce, RateLimited and Aggregate, unless they
+// are given their own clock, e.g. using WithClock. By default wall clock is used, which fires timers from other
+// goroutines without synchronization, so Throttle and Debounce refuse it. For deterministic backtesting and tests
+// use a virtual clock, e.g. simtime.Scheduler or shdeptest.Clock. In production use NewWallClock with the lock
+// protecting the tree, or shdepexec.NewWallClock with the executor running updates. Nil restores default wall clock.
+// Affects only nodes created after the call.
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	updateSettings(func(s *settings) { s.clock = c })
+}
+
+// GetClock returns clock set by SetClock, or wall clock if it has not been set.
+func GetClock() Clock {
+	return getSettings().clock
+}
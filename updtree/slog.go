@@ -0,0 +1,93 @@
+package updtree
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PropagationInfo describes invocation of update handler during propagation of an update.
+type PropagationInfo struct {
+	// Name of the node, which handles the update.
+	Node string
+	// Name of the node, which update is being propagated.
+	Root string
+	// Time of the event, which has caused the update.
+	EvtTime time.Time
+}
+
+type propagationInfoKey struct{}
+
+// PropagationInfoFromContext returns info about the update being handled, if it is attached to the context.
+// The info is attached only after SetPropagationContext(true).
+func PropagationInfoFromContext(ctx context.Context) (PropagationInfo, bool) {
+	if ctx == nil {
+		return PropagationInfo{}, false
+	}
+
+	info, ok := ctx.Value(propagationInfoKey{}).(PropagationInfo)
+	return info, ok
+}
+
+// withPropagationInfo returns ctx with the info attached, if ctx is context.Context.
+// Otherwise ctx is returned as is.
+func withPropagationInfo[Ctx any](ctx Ctx, info PropagationInfo) Ctx {
+	parent, ok := any(ctx).(context.Context)
+	if !ok || parent == nil {
+		return ctx
+	}
+
+	if withInfo, ok := context.WithValue(parent, propagationInfoKey{}, info).(Ctx); ok {
+		return withInfo
+	}
+
+	return ctx
+}
+
+// Keys of attributes, which are added by SlogHandler.
+const (
+	SlogKeyNode    = "shdep.node"
+	SlogKeyRoot    = "shdep.root"
+	SlogKeyEvtTime = "shdep.evtTime"
+)
+
+// NewSlogHandler returns slog handler, which adds to records info about the update being handled
+// (see PropagationInfo) and passes them to the next handler. The info is taken from context of the record,
+// so update handlers must log using context they receive, e.g. logger.InfoContext(ctx, ...),
+// and SetPropagationContext(true) must be called.
+func NewSlogHandler(next slog.Handler) *SlogHandler {
+	return &SlogHandler{next: next}
+}
+
+type SlogHandler struct {
+	next slog.Handler
+}
+
+var _ slog.Handler = &SlogHandler{}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if info, ok := PropagationInfoFromContext(ctx); ok {
+		r = r.Clone()
+		r.AddAttrs(
+			slog.String(SlogKeyNode, info.Node),
+			slog.String(SlogKeyRoot, info.Root),
+			slog.Time(SlogKeyEvtTime, info.EvtTime),
+		)
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SlogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns handler, which puts attributes into the group.
+// Attributes of PropagationInfo are put into the group as well.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,59 @@
+package updtree_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+// Not parallel, because propagation context is enabled for the whole package.
+func Test_SlogHandler(t *testing.T) {
+	updtree.SetPropagationContext(true)
+	defer updtree.SetPropagationContext(false)
+
+	var buf bytes.Buffer
+	logger := slog.New(updtree.NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	indicator := updtree.NewNode[Ctx]("indicator", nil)
+	indicator.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		logger.InfoContext(ctx, "calculated")
+		indicator.NotifyUpdated(ctx, evtTime)
+	})
+	strategy := updtree.NewNode[Ctx]("strategy", func(ctx Ctx, evtTime time.Time) {
+		logger.InfoContext(ctx, "traded")
+	})
+	root.Subscribe(indicator)
+	indicator.Subscribe(strategy)
+
+	evtTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	root.NotifyUpdated(context.Background(), evtTime)
+	logger.InfoContext(context.Background(), "outside")
+
+	type record struct {
+		Msg     string    `json:"msg"`
+		Node    string    `json:"shdep.node"`
+		Root    string    `json:"shdep.root"`
+		EvtTime time.Time `json:"shdep.evtTime"`
+	}
+
+	var records []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r record
+		require.NoError(t, dec.Decode(&r))
+		records = append(records, r)
+	}
+
+	require.Equal(t, []record{
+		{Msg: "calculated", Node: "indicator", Root: "root", EvtTime: evtTime},
+		{Msg: "traded", Node: "strategy", Root: "root", EvtTime: evtTime},
+		{Msg: "outside"},
+	}, records)
+}
@@ -0,0 +1,83 @@
+package updtree
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Subscription is a handle of subscription of one node to updates of another node.
+// It is used for diagnostics: subscriptions can be given names, which are shown by DOT,
+// and if SetSubscriptionSites is enabled, they record where they have been made.
+type Subscription struct {
+	source     string
+	subscriber string
+	name       string
+	createdAt  []byte
+}
+
+func newSubscription(source, subscriber string) *Subscription {
+	s := &Subscription{source: source, subscriber: subscriber}
+	if getSettings().subscriptionSites {
+		s.createdAt = debug.Stack()
+	}
+
+	return s
+}
+
+// Named sets debug name of the subscription and returns the subscription.
+func (s *Subscription) Named(name string) *Subscription {
+	if s != nil {
+		s.name = name
+	}
+
+	return s
+}
+
+// Name returns debug name of the subscription, or empty string if it was not set.
+func (s *Subscription) Name() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.name
+}
+
+// Source returns identifier of the node, to updates of which subscriber has subscribed.
+func (s *Subscription) Source() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.source
+}
+
+// Subscriber returns identifier of the subscribed node.
+func (s *Subscription) Subscriber() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.subscriber
+}
+
+// CreatedAt returns stack trace of the call, which has made the subscription,
+// or empty string if SetSubscriptionSites was not enabled at that moment.
+func (s *Subscription) CreatedAt() string {
+	if s == nil {
+		return ""
+	}
+
+	return string(s.createdAt)
+}
+
+func (s *Subscription) String() string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	if s.name != "" {
+		return fmt.Sprintf("%v (%v -> %v)", s.name, s.source, s.subscriber)
+	}
+
+	return fmt.Sprintf("%v -> %v", s.source, s.subscriber)
+}
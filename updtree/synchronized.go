@@ -0,0 +1,55 @@
+package updtree
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NewSynchronizedNode creates node, NotifyUpdated of which can be called from multiple goroutines, e.g. by handlers
+// of websocket feeds, without external lock: each call holds l while the update is propagated, so propagations started
+// from different goroutines are run one after another. Nodes sharing the same lock are serialized with each other,
+// other nodes and trees are not affected. Lock is required: synchronized nodes, updates of which reach common
+// subscribers, must share it, otherwise their propagations update those subscribers concurrently.
+//
+// The node is an entry point of external updates into the tree, so it has no update handler and cannot subscribe
+// to other nodes. The lock is not re-entrant, so update handlers must not notify synchronized nodes with the same lock.
+// Pass NewUpdateLock as l to detect it in development. State read by handlers must be written under the same lock,
+// so producers should write it and notify about the update using UpdateAndNotify.
+func NewSynchronizedNode[Ctx any](name string, l sync.Locker) *NodeBase[Ctx] {
+	if l == nil {
+		panic(fmt.Sprintf("lock of synchronized node %v must not be nil", name))
+	}
+
+	n := NewNode[Ctx](name, nil)
+	n.lock = l
+
+	return n
+}
+
+// UpdateAndNotify runs update, e.g. writing state of the producer read by update handlers, and notifies subscribers
+// of the node about it. For node created by NewSynchronizedNode both are done holding its lock.
+func (n *NodeBase[Ctx]) UpdateAndNotify(ctx Ctx, evtTime time.Time, update func()) {
+	if n.lock == nil {
+		update()
+		n.notifyUpdated(ctx, evtTime)
+		return
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	update()
+	// Holder of the lock propagates updates serialized, as holder of UpdateLock.
+	RunSerialized(func() { n.notifyUpdated(ctx, evtTime) })
+}
+
+// checkSynchronizedSubscriber reports failure, if the node is synchronized and therefore cannot subscribe to source.
+func (n *NodeBase[Ctx]) checkSynchronizedSubscriber(source *NodeBase[Ctx]) bool {
+	if n.lock == nil {
+		return true
+	}
+
+	fail(fmt.Errorf("synchronized node %v cannot subscribe to node %v: it is an entry point of external updates", n.name, source.name))
+	return false
+}
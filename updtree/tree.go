@@ -0,0 +1,96 @@
+package updtree
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nnikolash/go-shdep/utils"
+)
+
+// Tree holds update order shared by all nodes attached to it.
+//
+// Without a tree each node calculates and keeps its own update order of nodes reachable from it,
+// so nodes having common subscribers sort same nodes again and keep their own copies of the orders.
+// Nodes attached to a tree use single order of all attached nodes, which is recalculated
+// only after the tree has changed.
+//
+// Subscribers of attached nodes are attached to the same tree automatically.
+// Nodes, which do not depend on each other, are updated in the order of their attachment.
+type Tree[Ctx any] struct {
+	nodes       []Node[Ctx]
+	propagation propagation[Ctx]
+	orderValid  bool
+}
+
+func NewTree[Ctx any]() *Tree[Ctx] {
+	return &Tree[Ctx]{}
+}
+
+// NewNode creates node attached to the tree.
+func (t *Tree[Ctx]) NewNode(name string, onSubscriptionUpdated func(ctx Ctx, evtTime time.Time)) *NodeBase[Ctx] {
+	n := NewNode(name, onSubscriptionUpdated)
+	t.Attach(n)
+
+	return n
+}
+
+// Attach attaches node and all nodes reachable from it to the tree.
+// Nodes are referenced by pointers, so nodes must not be copied after attachment.
+func (t *Tree[Ctx]) Attach(node Node[Ctx]) {
+	n := node.self().(*NodeBase[Ctx])
+	if n.tree == t {
+		return
+	}
+
+	if n.tree != nil {
+		fail(fmt.Errorf("node %v is already attached to another tree", n))
+		return
+	}
+
+	n.tree = t
+	n.treeUpdateOrder = nil
+	n.ownPropagation = propagation[Ctx]{}
+	t.nodes = append(t.nodes, n)
+	t.Invalidate()
+
+	for _, subscriber := range n.subscribers {
+		t.Attach(subscriber)
+	}
+}
+
+// Invalidate discards update order of the tree, so that it is recalculated on next propagation.
+// It is called automatically, when nodes are attached or attached nodes get new subscribers.
+func (t *Tree[Ctx]) Invalidate() {
+	t.orderValid = false
+}
+
+// Len returns number of nodes attached to the tree.
+func (t *Tree[Ctx]) Len() int {
+	return len(t.nodes)
+}
+
+// getPropagation returns propagation of the tree, recalculating update order if needed,
+// or nil if update order could not be determined.
+func (t *Tree[Ctx]) getPropagation() *propagation[Ctx] {
+	if t.orderValid || t.propagation.running {
+		// Changes made during propagation take effect on next propagation.
+		return &t.propagation
+	}
+
+	order, err := utils.StableTopologicalSort[Node[Ctx]](&updateOrderGraph[Ctx]{nodes: t.nodes})
+	if err != nil {
+		fail(fmt.Errorf("failed to determine update order of tree: %w", err))
+		return nil
+	}
+
+	t.propagation.setOrder(order)
+	t.orderValid = true
+
+	return &t.propagation
+}
+
+// DOT renders all nodes of the tree in Graphviz DOT format.
+// Edges are directed from the node to its subscribers.
+func (t *Tree[Ctx]) DOT() string {
+	return nodesToDOT(t.nodes)
+}
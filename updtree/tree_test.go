@@ -0,0 +1,135 @@
+package updtree_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/stretchr/testify/require"
+)
+
+func newTreeNode(tree *updtree.Tree[Ctx], name string, handled *[]string) *UpdatePropagationNodeBase {
+	n := tree.NewNode(name, nil)
+	n.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		*handled = append(*handled, name)
+		n.NotifyUpdated(ctx, evtTime)
+	})
+	return n
+}
+
+func Test_Tree_SharedOrder(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+	tree := updtree.NewTree[Ctx]()
+
+	root1 := newTreeNode(tree, "root1", &handled)
+	root2 := newTreeNode(tree, "root2", &handled)
+	a := newTreeNode(tree, "a", &handled)
+	b := newTreeNode(tree, "b", &handled)
+	c := newTreeNode(tree, "c", &handled)
+
+	root1.Subscribe(a)
+	root2.Subscribe(b)
+	a.Subscribe(c)
+	b.Subscribe(c)
+	root1.Subscribe(c)
+
+	root1.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, []string{"a", "c"}, handled)
+
+	handled = nil
+	root2.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, []string{"b", "c"}, handled)
+
+	require.Equal(t, 5, tree.Len())
+	require.False(t, root1.HasUpdated())
+	require.False(t, c.HasUpdated())
+}
+
+func Test_Tree_AutoAttachAndInvalidate(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+	tree := updtree.NewTree[Ctx]()
+
+	root := newTreeNode(tree, "root", &handled)
+	a := newTreeNode(tree, "a", &handled)
+	root.Subscribe(a)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, []string{"a"}, handled)
+
+	// Subscriptions made after first propagation are taken into account.
+	b := updtree.NewNode[Ctx]("b", func(ctx Ctx, evtTime time.Time) {
+		handled = append(handled, "b")
+	})
+	c := updtree.NewNode[Ctx]("c", func(ctx Ctx, evtTime time.Time) {
+		handled = append(handled, "c")
+	})
+	b.Subscribe(c)
+	a.Subscribe(b)
+	require.Equal(t, 4, tree.Len())
+
+	handled = nil
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, []string{"a", "b"}, handled)
+}
+
+func Test_Tree_NotifyOtherRootDuringPropagation(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+	tree := updtree.NewTree[Ctx]()
+
+	root1 := newTreeNode(tree, "root1", &handled)
+	root2 := newTreeNode(tree, "root2", &handled)
+	b := newTreeNode(tree, "b", &handled)
+	root2.Subscribe(b)
+
+	a := tree.NewNode("a", func(ctx Ctx, evtTime time.Time) {
+		handled = append(handled, "a")
+		root2.NotifyUpdated(ctx, evtTime)
+	})
+	root1.Subscribe(a)
+
+	root1.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, []string{"a", "b"}, handled)
+	require.False(t, root2.HasUpdated())
+}
+
+func Test_Tree_AttachToAnotherTree(t *testing.T) {
+	t.Parallel()
+
+	tree1 := updtree.NewTree[Ctx]()
+	tree2 := updtree.NewTree[Ctx]()
+
+	n := tree1.NewNode("n", nil)
+	require.Panics(t, func() {
+		tree2.Attach(n)
+	})
+}
+
+func Test_Tree_NoAllocations(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+	tree := updtree.NewTree[Ctx]()
+
+	root := newTreeNode(tree, "root", &handled)
+	prev := root
+	for _, name := range []string{"a", "b", "c"} {
+		n := newTreeNode(tree, name, &handled)
+		prev.Subscribe(n)
+		prev = n
+	}
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	handled = make([]string, 0, 1000)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		root.NotifyUpdated(context.Background(), time.Time{})
+	})
+	require.Zero(t, allocs)
+}
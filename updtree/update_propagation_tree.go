@@ -2,6 +2,10 @@ package updtree
 
 import (
 	"fmt"
+	"iter"
+	mathbits "math/bits"
+	"slices"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -10,7 +14,10 @@ import (
 
 type UpdateSubscription[Ctx any] interface {
 	// Subscribe to the updates of this node.
-	Subscribe(node Node[Ctx])
+	// Subscribing same node again has no effect, so subscriptions can be made from several places,
+	// e.g. both from RegisterDependencies and Init. Returns handle of the subscription, which is the same for repeated calls.
+	// Returns nil, if subscription has failed and error policy is lenient.
+	Subscribe(node Node[Ctx]) *Subscription
 
 	// Check that this node has been updated. Can be used, when processing
 	// updates and need to know which of subscriptions has been updated.
@@ -29,84 +36,222 @@ type Node[Ctx any] interface {
 	// TODO: this method should be available only for the parent, but not for the users of parent.
 	SetUpdateHandler(onSubscriptionUpdated func(ctx Ctx, evtTime time.Time))
 
+	// Returns unique identifier of the node.
+	ID() string
+
 	// Implementation details
 	self() Node[Ctx]
-	getDependencies(dest *[]dependencyEntry[Ctx])
+	collectNodes(dest *[]Node[Ctx], visited map[Node[Ctx]]struct{})
+	getSubscribers() []Node[Ctx]
+	getName() string
 	addSubscription(subscription Node[Ctx])
-	setSubscriptionUpdated(v bool)
-	hasUpdatedSubscription() bool
-	setHasUpdated(v bool)
 	handleSubscriptionsUpdated(ctx Ctx, evtTime time.Time)
 }
 
 func NewNode[Ctx any](name string, onSubscriptionUpdated func(ctx Ctx, evtTime time.Time)) *NodeBase[Ctx] {
-	if onSubscriptionUpdated == nil {
+	missingHandler := onSubscriptionUpdated == nil
+	if missingHandler {
 		onSubscriptionUpdated = func(ctx Ctx, evtTime time.Time) {
-			panic(fmt.Sprintf("onSubscriptionUpdated was not set for node %s", name))
+			fail(fmt.Errorf("onSubscriptionUpdated was not set for node %s", name))
 		}
 	}
 
 	return &NodeBase[Ctx]{
 		name:                  name,
 		onSubscriptionUpdated: onSubscriptionUpdated,
+		missingHandler:        missingHandler,
 	}
 }
 
 type NodeBase[Ctx any] struct {
 	name string
 
-	subscribers           []Node[Ctx]
-	subscribtions         []Node[Ctx]
+	subscribers   []Node[Ctx]
+	subscribtions []Node[Ctx]
+	// Handles of subscriptions of subscribers.
+	subscriptionHandles   map[Node[Ctx]]*Subscription
 	onSubscriptionUpdated func(ctx Ctx, evtTime time.Time)
+	// Whether onSubscriptionUpdated only reports that handler was not set.
+	missingHandler bool
+
+	// Zero if node is not muted, otherwise MutePolicy plus one. Accessed atomically.
+	muted int32
+	// Last update received while muted with MuteBuffer.
+	mutedCtx     Ctx
+	mutedEvtTime time.Time
+	mutedPending bool
 
 	treeUpdateOrder []Node[Ctx]
+	ownPropagation  propagation[Ctx]
+
+	updated bool
+
+	notifications      uint64
+	updatesHandled     uint64
+	updatesMuted       uint64
+	handlerDuration    time.Duration
+	suspiciousEvtTimes uint64
+
+	// Tree, to which this node is attached. If nil, node uses its own update order.
+	tree *Tree[Ctx]
+
+	// Lock held by NotifyUpdated of node created by NewSynchronizedNode, or nil.
+	lock sync.Locker
 
-	updated             bool
-	subscriptionUpdated bool
+	// Propagation, in which this node has pending update of subscriptions.
+	propagation *propagation[Ctx]
+
+	// Position of this node in the update order of the last propagation, which has included it.
+	positionOwner *propagation[Ctx]
+	position      int
 }
 
 var _ Node[interface{}] = &NodeBase[interface{}]{}
 
-func (n *NodeBase[Ctx]) Subscribe(subscriber Node[Ctx]) {
+func (n *NodeBase[Ctx]) Subscribe(subscriber Node[Ctx]) *Subscription {
+	if subscriber.self() == Node[Ctx](n) {
+		// Otherwise it would be reported only on next propagation as a cycle without a hint where it came from.
+		fail(fmt.Errorf("node %v cannot subscribe to itself", n.name))
+		return nil
+	}
+
+	if !subscriber.self().(*NodeBase[Ctx]).checkSynchronizedSubscriber(n) {
+		return nil
+	}
+
+	// Subscriptions of a node are usually fewer than subscribers of its source, so they are checked for duplicates.
+	if slices.Contains(subscriber.self().(*NodeBase[Ctx]).subscribtions, Node[Ctx](n)) {
+		return n.subscriptionHandles[subscriber.self()]
+	}
+
 	n.subscribers = append(n.subscribers, subscriber.self())
 	subscriber.addSubscription(n)
+	n.invalidateUpdateOrders()
+
+	handle := newSubscription(n.ID(), subscriber.self().(*NodeBase[Ctx]).ID())
+	if n.subscriptionHandles == nil {
+		n.subscriptionHandles = make(map[Node[Ctx]]*Subscription)
+	}
+	n.subscriptionHandles[subscriber.self()] = handle
+
+	if n.tree != nil {
+		n.tree.Attach(subscriber)
+		n.tree.Invalidate()
+	}
+
+	return handle
+}
+
+// Subscriptions returns handles of subscriptions to this node in the same order as Subscribers.
+func (n *NodeBase[Ctx]) Subscriptions() []*Subscription {
+	handles := make([]*Subscription, 0, len(n.subscribers))
+	for _, subscriber := range n.subscribers {
+		handles = append(handles, n.subscriptionHandles[subscriber])
+	}
+
+	return handles
+}
+
+// Detach removes all subscriptions of the node and all subscriptions to it, so that the node is excluded
+// from update propagation, e.g. when object owning it is disabled. Must not be called while updates are propagated.
+func (n *NodeBase[Ctx]) Detach() {
+	// Nodes, from which this node is reachable, are determined before subscriptions are removed.
+	n.invalidateUpdateOrders()
+
+	for _, subscription := range n.subscribtions {
+		base := subscription.self().(*NodeBase[Ctx])
+		base.subscribers = slices.DeleteFunc(base.subscribers, func(node Node[Ctx]) bool { return node == Node[Ctx](n) })
+		delete(base.subscriptionHandles, n)
+	}
+
+	for _, subscriber := range n.subscribers {
+		base := subscriber.self().(*NodeBase[Ctx])
+		base.subscribtions = slices.DeleteFunc(base.subscribtions, func(node Node[Ctx]) bool { return node == Node[Ctx](n) })
+	}
+
+	n.subscribtions = nil
+	n.subscribers = nil
+	n.subscriptionHandles = nil
+
+	if n.tree != nil {
+		n.tree.Invalidate()
+	}
 }
 
 func (n *NodeBase[Ctx]) SetUpdateHandler(onSubscriptionUpdated func(ctx Ctx, evtTime time.Time)) {
 	n.onSubscriptionUpdated = onSubscriptionUpdated
+	n.missingHandler = false
+}
+
+// HasUpdateHandler reports whether update handler of the node is set.
+func (n *NodeBase[Ctx]) HasUpdateHandler() bool {
+	return n.onSubscriptionUpdated != nil && !n.missingHandler
 }
 
 func (n *NodeBase[Ctx]) addSubscription(subscription Node[Ctx]) {
 	n.subscribtions = append(n.subscribtions, subscription)
 }
 
-type dependencyEntry[Ctx any] struct {
-	Node       Node[Ctx]
-	Dependants []Node[Ctx]
-}
+// collectNodes appends to dest all nodes reachable from this node, which are not in visited yet.
+// Visited nodes are kept by the caller instead of being marked in nodes, because same nodes
+// can be collected concurrently by different trees.
+func (n *NodeBase[Ctx]) collectNodes(dest *[]Node[Ctx], visited map[Node[Ctx]]struct{}) {
+	if _, ok := visited[n]; ok {
+		return
+	}
 
-func (n *NodeBase[Ctx]) getDependencies(dest *[]dependencyEntry[Ctx]) {
-	*dest = append(*dest, dependencyEntry[Ctx]{Node: n, Dependants: n.subscribers})
+	visited[n] = struct{}{}
+	*dest = append(*dest, n)
 
 	for _, subscriber := range n.subscribers {
-		subscriber.getDependencies(dest)
+		subscriber.collectNodes(dest, visited)
 	}
 }
 
-func (n *NodeBase[Ctx]) self() Node[Ctx] {
-	return n
+// invalidateUpdateOrders drops update orders determined by this node and by all nodes, from which it is reachable,
+// because subscriptions of this node are part of their update orders. Update orders of other nodes stay valid.
+func (n *NodeBase[Ctx]) invalidateUpdateOrders() {
+	visited := map[*NodeBase[Ctx]]struct{}{n: {}}
+	pending := []*NodeBase[Ctx]{n}
+
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		node.treeUpdateOrder = nil
+
+		for _, subscription := range node.subscribtions {
+			base := subscription.self().(*NodeBase[Ctx])
+			if _, ok := visited[base]; !ok {
+				visited[base] = struct{}{}
+				pending = append(pending, base)
+			}
+		}
+	}
 }
 
-func (n *NodeBase[Ctx]) setSubscriptionUpdated(v bool) {
-	n.subscriptionUpdated = v
+// collectReachableNodes returns this node and all nodes reachable from it in the order of discovery.
+func (n *NodeBase[Ctx]) collectReachableNodes() []Node[Ctx] {
+	nodes := make([]Node[Ctx], 0, len(n.subscribers)+1)
+	n.collectNodes(&nodes, make(map[Node[Ctx]]struct{}, len(n.subscribers)+1))
+
+	return nodes
 }
 
-func (n *NodeBase[Ctx]) hasUpdatedSubscription() bool {
-	return n.subscriptionUpdated
+// Subscribers returns nodes subscribed to this node.
+func (n *NodeBase[Ctx]) Subscribers() []Node[Ctx] {
+	return slices.Clone(n.subscribers)
 }
 
-func (n *NodeBase[Ctx]) setHasUpdated(v bool) {
-	n.updated = v
+func (n *NodeBase[Ctx]) getSubscribers() []Node[Ctx] {
+	return n.subscribers
+}
+
+func (n *NodeBase[Ctx]) getName() string {
+	return n.name
+}
+
+func (n *NodeBase[Ctx]) self() Node[Ctx] {
+	return n
 }
 
 func (n *NodeBase[Ctx]) HasUpdated() bool {
@@ -114,26 +259,23 @@ func (n *NodeBase[Ctx]) HasUpdated() bool {
 }
 
 func (n *NodeBase[Ctx]) getUpdateOrder() ([]Node[Ctx], error) {
-	dependencies := make([]dependencyEntry[Ctx], 0, len(n.subscribers))
-	n.getDependencies(&dependencies)
+	nodes := n.collectReachableNodes()
 
-	stability := make([]Node[Ctx], 0, len(dependencies))
-
-	for _, entry := range dependencies {
-		stability = append(stability, entry.Node)
-	}
-
-	stability = utils.Uniq(stability)
-
-	dependenciesMap := make(map[Node[Ctx]][]Node[Ctx], len(dependencies))
+	return utils.StableTopologicalSort[Node[Ctx]](&updateOrderGraph[Ctx]{nodes: nodes})
+}
 
-	for _, entry := range dependencies {
-		dependenciesMap[entry.Node] = entry.Dependants
-	}
+// updateOrderGraph is a view of the nodes reachable from some node.
+// Nodes are kept in the order of their discovery, which is used for stability of sorting.
+type updateOrderGraph[Ctx any] struct {
+	nodes []Node[Ctx]
+}
 
-	r, err := utils.StableTopologicalSortWithSortedKeys(dependenciesMap, stability)
+func (g *updateOrderGraph[Ctx]) Nodes() iter.Seq[Node[Ctx]] {
+	return slices.Values(g.nodes)
+}
 
-	return r, err
+func (g *updateOrderGraph[Ctx]) Edges(node Node[Ctx]) iter.Seq[Node[Ctx]] {
+	return slices.Values(node.getSubscribers())
 }
 
 func (n *NodeBase[Ctx]) handleSubscriptionsUpdated(ctx Ctx, evtTime time.Time) {
@@ -143,43 +285,362 @@ func (n *NodeBase[Ctx]) handleSubscriptionsUpdated(ctx Ctx, evtTime time.Time) {
 }
 
 func (n *NodeBase[Ctx]) NotifyUpdated(ctx Ctx, evtTime time.Time) {
-	n.updated = true
+	if n.lock != nil {
+		n.UpdateAndNotify(ctx, evtTime, func() {})
+		return
+	}
+
+	n.notifyUpdated(ctx, evtTime)
+}
+
+func (n *NodeBase[Ctx]) notifyUpdated(ctx Ctx, evtTime time.Time) {
+	cfg := getSettings()
+
+	if cfg.ownershipChecks && !n.checkOwnership() {
+		return
+	}
+
+	n.notifications++
 
-	n.notifySubscribers()
+	if cfg.evtTimeCheck {
+		n.checkEvtTime(cfg, evtTime)
+	}
 
-	if n.subscriptionUpdated {
+	if len(n.subscribers) == 0 {
+		// Nobody to notify, so no need to mark node as updated or to calculate update order.
 		return
 	}
 
-	n.processUpdate(ctx, evtTime)
+	if n.propagation != nil {
+		// This node is being updated as part of propagation, so the update will be propagated further by it.
+		n.updated = true
+		n.propagation.notifySubscribers(n)
+		return
+	}
+
+	p := n.rootPropagation()
+	if p == nil {
+		return
+	}
+
+	if bound := boundGoroutine.Load(); bound != nil && !p.running && !checkAffinity(n, bound) {
+		return
+	}
+
+	if !p.running && cfg.nestedPropagation != NestedPropagationAllow {
+		guardNestedPropagation(n, func() {
+			// Update order could change, while propagation has been postponed.
+			if p := n.rootPropagation(); p != nil {
+				n.propagateUpdate(p, ctx, evtTime)
+			}
+		})
+		return
+	}
+
+	n.propagateUpdate(p, ctx, evtTime)
 }
 
-func (n NodeBase[Ctx]) notifySubscribers() {
-	for _, node := range n.subscribers {
-		node.setSubscriptionUpdated(true)
+func (n *NodeBase[Ctx]) propagateUpdate(p *propagation[Ctx], ctx Ctx, evtTime time.Time) {
+	n.updated = true
+
+	if p.running {
+		// This node is notified by one of nodes being updated during propagation,
+		// e.g. by one of its own subscribers, so its update is propagated as part of the same propagation.
+		p.processed = append(p.processed, n)
+		p.notifySubscribers(n)
+		return
 	}
+
+	p.run(n, ctx, evtTime)
 }
 
-func (n *NodeBase[Ctx]) processUpdate(ctx Ctx, evtTime time.Time) {
+// rootPropagation returns propagation, using which update of this node is propagated,
+// or nil if update order could not be determined.
+func (n *NodeBase[Ctx]) rootPropagation() *propagation[Ctx] {
+	if n.tree != nil {
+		return n.tree.getPropagation()
+	}
+
+	if n.ownPropagation.running {
+		// Subscriptions made during propagation take effect on next propagation.
+		return &n.ownPropagation
+	}
+
 	if n.treeUpdateOrder == nil {
 		var err error
 		if n.treeUpdateOrder, err = n.getUpdateOrder(); err != nil {
-			panic(fmt.Sprintf("%+v", err))
+			n.treeUpdateOrder = nil
+			fail(fmt.Errorf("failed to determine update order of node %v: %w", n, err))
+			return nil
 		}
+
+		n.ownPropagation.setOrder(n.treeUpdateOrder)
 	}
 
-	for _, node := range n.treeUpdateOrder {
-		if node.hasUpdatedSubscription() {
-			node.handleSubscriptionsUpdated(ctx, evtTime)
+	return &n.ownPropagation
+}
+
+// propagation tracks nodes affected by propagation of an update, so that
+// only those nodes are visited instead of the whole tree.
+// Nodes are kept as *NodeBase to avoid interface calls on the hot path:
+// every Node embeds NodeBase, so subscribers always are *NodeBase underneath.
+type propagation[Ctx any] struct {
+	order     []*NodeBase[Ctx]
+	positions map[*NodeBase[Ctx]]int
+
+	// Bitset of positions in update order of nodes, which have pending update of subscriptions.
+	pending      []uint64
+	pendingCount int
+	// There are no pending nodes before this position.
+	firstPending int
+
+	// Nodes, which were processed during propagation.
+	processed []*NodeBase[Ctx]
+	running   bool
+
+	// Used only if ownership checks are enabled. Kept by pointer, because nodes are copied by value on construction.
+	ownership *ownership
+}
+
+func (p *propagation[Ctx]) run(source *NodeBase[Ctx], ctx Ctx, evtTime time.Time) {
+	cfg := getSettings()
+	logger, observer := cfg.logger, cfg.observer
+
+	if cfg.ownershipChecks && p.ownership != nil {
+		if !p.ownership.acquire(source) {
+			return
 		}
-		node.setSubscriptionUpdated(false)
+		defer p.ownership.release()
+	}
+
+	if logger != nil {
+		utils.LogKV(logger, utils.LevelTrace, "Propagating update", "source", source, "evtTime", evtTime)
 	}
 
-	for _, node := range n.treeUpdateOrder {
-		node.setHasUpdated(false)
+	var propagationStart time.Time
+	if observer != nil {
+		propagationStart = time.Now()
+	}
+
+	p.running = true
+	p.notifySubscribers(source)
+
+	nodesUpdated := 0
+	for p.pendingCount > 0 {
+		node := p.popNext()
+		p.processed = append(p.processed, node)
+
+		if node.skipMuted(ctx, evtTime) {
+			node.propagation = nil
+			continue
+		}
+
+		if logger != nil {
+			utils.LogKV(logger, utils.LevelTrace, "Handling subscriptions update", "node", node, "source", source)
+		}
+		node.updatesHandled++
+		nodesUpdated++
+
+		measureHandler := cfg.measureHandlerDurations || observer != nil
+		var handlerStart time.Time
+		if measureHandler {
+			handlerStart = time.Now()
+		}
+
+		handlerCtx := ctx
+		if cfg.propagationContext {
+			handlerCtx = withPropagationInfo(ctx, PropagationInfo{Node: node.name, Root: source.name, EvtTime: evtTime})
+		}
+
+		if cfg.profilerLabels {
+			utils.DoWithProfilerLabels(handlerCtx, func(ctx Ctx) {
+				node.handleSubscriptionsUpdated(ctx, evtTime)
+			}, "shdep.node", node.name, "shdep.phase", "update")
+		} else {
+			node.handleSubscriptionsUpdated(handlerCtx, evtTime)
+		}
+
+		if measureHandler {
+			handlerDuration := time.Since(handlerStart)
+			if cfg.measureHandlerDurations {
+				node.handlerDuration += handlerDuration
+			}
+			if observer != nil {
+				observer.UpdateHandled(node.name, handlerDuration)
+			}
+		}
+		node.propagation = nil
+	}
+
+	source.updated = false
+	// Processed nodes are part of the update order anyway, so there is no need to clear references to them.
+	for _, node := range p.processed {
+		node.updated = false
+	}
+
+	p.processed = p.processed[:0]
+	p.running = false
+
+	if observer != nil {
+		observer.PropagationFinished(source.name, nodesUpdated, time.Since(propagationStart))
 	}
 }
 
-func (n *NodeBase[Ctx]) String() string {
+func (p *propagation[Ctx]) setOrder(order []Node[Ctx]) {
+	p.order = make([]*NodeBase[Ctx], len(order))
+	p.positions = make(map[*NodeBase[Ctx]]int, len(order))
+	p.pending = make([]uint64, (len(order)+63)/64)
+	if p.ownership == nil {
+		p.ownership = &ownership{}
+	}
+
+	for i, node := range order {
+		base := node.(*NodeBase[Ctx])
+		p.order[i] = base
+		p.positions[base] = i
+		base.positionOwner = p
+		base.position = i
+	}
+}
+
+func (p *propagation[Ctx]) notifySubscribers(n *NodeBase[Ctx]) {
+	for _, s := range n.subscribers {
+		subscriber := s.(*NodeBase[Ctx])
+		if subscriber.propagation != nil {
+			// Already has pending update
+			continue
+		}
+
+		pos, ok := p.positionOf(subscriber)
+		if !ok {
+			// Subscriber is not in the update order, which happens if it has subscribed during this propagation.
+			continue
+		}
+
+		subscriber.propagation = p
+		p.push(pos)
+	}
+}
+
+func (p *propagation[Ctx]) positionOf(node *NodeBase[Ctx]) (int, bool) {
+	// Most of the time node is part of only one tree, so its position is cached in the node itself.
+	if node.positionOwner == p {
+		return node.position, true
+	}
+
+	pos, ok := p.positions[node]
+	return pos, ok
+}
+
+func (p *propagation[Ctx]) push(pos int) {
+	p.pending[pos/64] |= 1 << (pos % 64)
+
+	if p.pendingCount == 0 || pos < p.firstPending {
+		p.firstPending = pos
+	}
+
+	p.pendingCount++
+}
+
+// popNext removes from pending and returns node, which is the first in update order.
+func (p *propagation[Ctx]) popNext() *NodeBase[Ctx] {
+	for word := p.firstPending / 64; ; word++ {
+		bits := p.pending[word]
+		if word == p.firstPending/64 {
+			bits &^= (1 << (p.firstPending % 64)) - 1
+		}
+		if bits == 0 {
+			continue
+		}
+
+		pos := word*64 + mathbits.TrailingZeros64(bits)
+		p.pending[word] &^= 1 << (pos % 64)
+		p.pendingCount--
+		p.firstPending = pos + 1
+
+		return p.order[pos]
+	}
+}
+
+// NodeStats contains counters of the node for monitoring and debugging.
+type NodeStats struct {
+	// Number of calls of NotifyUpdated.
+	Notifications uint64 `json:"notifications"`
+	// Number of invocations of update handler.
+	UpdatesHandled uint64 `json:"updatesHandled"`
+	// Number of updates of subscriptions received while node was muted.
+	UpdatesMuted uint64 `json:"updatesMuted,omitempty"`
+	// Total time spent in update handler. Measured only if enabled by SetMeasureHandlerDurations.
+	HandlerDuration time.Duration `json:"handlerDuration"`
+	// Number of calls of NotifyUpdated with zero or skewed event time. Counted only if enabled by SetEventTimeCheck.
+	SuspiciousEvtTimes uint64 `json:"suspiciousEvtTimes,omitempty"`
+}
+
+func (n *NodeBase[Ctx]) Stats() NodeStats {
+	return NodeStats{
+		Notifications:      n.notifications,
+		UpdatesHandled:     n.updatesHandled,
+		UpdatesMuted:       n.updatesMuted,
+		HandlerDuration:    n.handlerDuration,
+		SuspiciousEvtTimes: n.suspiciousEvtTimes,
+	}
+}
+
+// checkEvtTime warns about event time, which is zero or differs from the wall clock by more than allowed skew.
+func (n *NodeBase[Ctx]) checkEvtTime(cfg *settings, evtTime time.Time) {
+	var skew time.Duration
+
+	if !evtTime.IsZero() {
+		if cfg.evtTimeMaxSkew <= 0 {
+			return
+		}
+
+		skew = time.Since(evtTime)
+		if skew <= cfg.evtTimeMaxSkew && skew >= -cfg.evtTimeMaxSkew {
+			return
+		}
+	}
+
+	n.suspiciousEvtTimes++
+
+	if logger := cfg.logger; logger != nil {
+		if evtTime.IsZero() {
+			utils.LogKV(logger, utils.LevelWarn, "NotifyUpdated is called with zero event time", "node", n)
+		} else {
+			utils.LogKV(logger, utils.LevelWarn, "NotifyUpdated is called with event time too far from current time",
+				"node", n, "evtTime", evtTime, "skew", skew)
+		}
+	}
+}
+
+// ID returns unique identifier of the node, which does not change over time.
+func (n *NodeBase[Ctx]) ID() string {
 	return n.name + fmt.Sprintf("-%x", uintptr(unsafe.Pointer(n)))
 }
+
+// String returns name of the node with short hash of its identity and number of handled updates, e.g. "ma#1f2e(updates: 10)".
+// Use ID to identify the node.
+func (n *NodeBase[Ctx]) String() string {
+	shortHash := uint16((uint64(uintptr(unsafe.Pointer(n))) * 0x9E3779B97F4A7C15) >> 48)
+	return fmt.Sprintf("%v#%04x(updates: %v)", n.name, shortHash, n.updatesHandled)
+}
+
+// DOT renders the tree of nodes reachable from this node in Graphviz DOT format.
+// Edges are directed from the node to its subscribers.
+func (n *NodeBase[Ctx]) DOT() string {
+	return nodesToDOT(n.collectReachableNodes())
+}
+
+// nodesToDOT renders nodes and subscriptions between them. Edges of named subscriptions are labeled with their names.
+func nodesToDOT[Ctx any](nodes []Node[Ctx]) string {
+	graph := make(utils.Graph[Node[Ctx]], len(nodes))
+	for _, node := range nodes {
+		graph[node] = node.getSubscribers()
+	}
+
+	return utils.GraphToLabeledDOT(graph, func(node Node[Ctx]) string {
+		return node.getName()
+	}, func(from, to Node[Ctx]) string {
+		return from.self().(*NodeBase[Ctx]).subscriptionHandles[to].Name()
+	})
+}
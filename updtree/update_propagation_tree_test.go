@@ -2,10 +2,14 @@ package updtree_test
 
 import (
 	"context"
+	"runtime/pprof"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/nnikolash/go-shdep/shdeptest"
 	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/nnikolash/go-shdep/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -145,3 +149,848 @@ func newUpdatePropagationNode(name string, handler func(self UpdatePropagationNo
 	n.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) { handler(n) })
 	return n
 }
+
+func Test_UpdatePropagationTree_DOT(t *testing.T) {
+	t.Parallel()
+
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {})
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) {})
+
+	a.Subscribe(c)
+	a.Subscribe(b)
+	b.Subscribe(c)
+
+	require.Equal(t, `digraph {
+  n0 [label="a"];
+  n1 [label="b"];
+  n2 [label="c"];
+  n0 -> n1;
+  n0 -> n2;
+  n1 -> n2;
+}
+`, a.DOT())
+}
+
+func Test_UpdatePropagationTree_ConcurrentCollection(t *testing.T) {
+	t.Parallel()
+
+	// Both roots reach shared nodes, e.g. when they belong to different trees.
+	shared := newUpdatePropagationNode("shared", func(self UpdatePropagationNode) {})
+	leaf := newUpdatePropagationNode("leaf", func(self UpdatePropagationNode) {})
+	shared.Subscribe(leaf)
+
+	roots := []*UpdatePropagationNodeBase{
+		newUpdatePropagationNode("root1", func(self UpdatePropagationNode) {}),
+		newUpdatePropagationNode("root2", func(self UpdatePropagationNode) {}),
+	}
+
+	expected := make([]string, len(roots))
+	for i, root := range roots {
+		root.Subscribe(shared)
+		root.Subscribe(leaf)
+		expected[i] = root.DOT()
+	}
+
+	var wg sync.WaitGroup
+	for i, root := range roots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if dot := root.DOT(); dot != expected[i] {
+					t.Errorf("unexpected DOT of %v:\n%v", root, dot)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_UpdatePropagationTree_NoAllocations(t *testing.T) {
+	t.Parallel()
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	prev := root
+	for _, name := range []string{"a", "b", "c"} {
+		n := newUpdatePropagationNode(name, func(self UpdatePropagationNode) {
+			self.NotifyUpdated(context.Background(), time.Time{})
+		})
+		prev.Subscribe(n)
+		root.Subscribe(n)
+		prev = n
+	}
+
+	// First propagation calculates update order.
+	root.NotifyUpdated(context.Background(), time.Time{})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		root.NotifyUpdated(context.Background(), time.Time{})
+	})
+	require.Zero(t, allocs)
+}
+
+func Test_UpdatePropagationTree_NoSubscribers(t *testing.T) {
+	t.Parallel()
+
+	leaf := newUpdatePropagationNode("leaf", func(self UpdatePropagationNode) {})
+
+	// Even first notification does not calculate update order.
+	allocs := testing.AllocsPerRun(100, func() {
+		leaf.NotifyUpdated(context.Background(), time.Time{})
+	})
+	require.Zero(t, allocs)
+	require.False(t, leaf.HasUpdated())
+
+	notified := false
+	subscriber := newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) {
+		notified = true
+	})
+	leaf.Subscribe(subscriber)
+
+	leaf.NotifyUpdated(context.Background(), time.Time{})
+	require.True(t, notified)
+}
+
+func Test_UpdatePropagationTree_LateSubscription(t *testing.T) {
+	t.Parallel()
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {
+		self.NotifyUpdated(context.Background(), time.Time{})
+	})
+	root.Subscribe(a)
+	root.NotifyUpdated(context.Background(), time.Time{})
+
+	// Update order of root is already determined, but it includes new subscribers of reachable nodes.
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	a.Subscribe(b)
+	root.NotifyUpdated(context.Background(), time.Time{})
+
+	require.Equal(t, uint64(2), a.Stats().UpdatesHandled)
+	require.Equal(t, uint64(1), b.Stats().UpdatesHandled)
+
+	// Subscriptions of indirectly reachable nodes are included as well,
+	// while update orders of unrelated nodes are not affected.
+	other := newUpdatePropagationNode("other", func(self UpdatePropagationNode) {})
+	otherSubscriber := newUpdatePropagationNode("otherSubscriber", func(self UpdatePropagationNode) {})
+	other.Subscribe(otherSubscriber)
+	other.NotifyUpdated(context.Background(), time.Time{})
+
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) {
+		self.NotifyUpdated(context.Background(), time.Time{})
+	})
+	a.Subscribe(c)
+	root.NotifyUpdated(context.Background(), time.Time{})
+
+	d := newUpdatePropagationNode("d", func(self UpdatePropagationNode) {})
+	c.Subscribe(d)
+	root.NotifyUpdated(context.Background(), time.Time{})
+	other.NotifyUpdated(context.Background(), time.Time{})
+
+	require.Equal(t, uint64(2), c.Stats().UpdatesHandled)
+	require.Equal(t, uint64(1), d.Stats().UpdatesHandled)
+	require.Equal(t, uint64(2), otherSubscriber.Stats().UpdatesHandled)
+}
+
+func Test_UpdatePropagationTree_DuplicateSubscription(t *testing.T) {
+	t.Parallel()
+
+	handled := 0
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	subscriber := newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) { handled++ })
+
+	root.Subscribe(subscriber)
+	root.Subscribe(subscriber)
+	require.Len(t, root.Subscribers(), 1)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, 1, handled)
+
+	root.Detach()
+	require.Empty(t, root.Subscribers())
+
+	root.Subscribe(subscriber)
+	require.Len(t, root.Subscribers(), 1)
+}
+
+// Not parallel, because failure handler is set for the whole package.
+func Test_UpdatePropagationTree_SelfSubscription(t *testing.T) {
+	var failure error
+	prev := utils.SetFailureHandler(func(err error) { failure = err })
+	defer utils.SetFailureHandler(prev)
+
+	handled := 0
+	n := newUpdatePropagationNode("feed", func(self UpdatePropagationNode) { handled++ })
+	n.Subscribe(n)
+	require.EqualError(t, failure, "node feed cannot subscribe to itself")
+	require.Empty(t, n.Subscribers())
+
+	failure = nil
+	n.NotifyUpdated(context.Background(), time.Time{})
+	require.NoError(t, failure)
+	require.Zero(t, handled)
+}
+
+func Test_UpdatePropagationTree_Detach(t *testing.T) {
+	t.Parallel()
+
+	for _, useTree := range []bool{false, true} {
+		var handled []string
+		newNode := func(name string) *UpdatePropagationNodeBase {
+			return newUpdatePropagationNode(name, func(self UpdatePropagationNode) {
+				handled = append(handled, name)
+				self.NotifyUpdated(context.Background(), time.Time{})
+			})
+		}
+
+		root := newNode("root")
+		provider := newNode("provider")
+		strategy := newNode("strategy")
+		if useTree {
+			updtree.NewTree[Ctx]().Attach(root)
+		}
+
+		root.Subscribe(provider)
+		root.Subscribe(strategy)
+		provider.Subscribe(strategy)
+		root.NotifyUpdated(context.Background(), time.Time{})
+		require.Equal(t, []string{"provider", "strategy"}, handled)
+
+		provider.Detach()
+		require.Empty(t, provider.Subscribers())
+		require.Len(t, root.Subscribers(), 1)
+
+		handled = nil
+		root.NotifyUpdated(context.Background(), time.Time{})
+		require.Equal(t, []string{"strategy"}, handled)
+
+		handled = nil
+		provider.NotifyUpdated(context.Background(), time.Time{})
+		require.Empty(t, handled)
+	}
+}
+
+// Not parallel, because ownership checks and failure handler are set for the whole package.
+func Test_UpdatePropagationTree_OwnershipChecks(t *testing.T) {
+	updtree.SetOwnershipChecks(true)
+	defer updtree.SetOwnershipChecks(false)
+
+	var failure error
+	prev := utils.SetFailureHandler(func(err error) { failure = err })
+	defer utils.SetFailureHandler(prev)
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {
+		// Handing work to another goroutine, which notifies about update immediately.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			root.NotifyUpdated(context.Background(), time.Time{})
+		}()
+		<-done
+
+		// Notifications from the goroutine running propagation are allowed.
+		self.NotifyUpdated(context.Background(), time.Time{})
+	})
+	root.Subscribe(a)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.ErrorContains(t, failure, "NotifyUpdated of node root")
+	require.ErrorContains(t, failure, "while update is being propagated by goroutine")
+	require.ErrorContains(t, failure, "Test_UpdatePropagationTree_OwnershipChecks")
+	require.Equal(t, uint64(1), root.Stats().Notifications)
+	require.Equal(t, uint64(1), a.Stats().Notifications)
+
+	failure = nil
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.Error(t, failure)
+	require.Equal(t, uint64(2), a.Stats().UpdatesHandled)
+}
+
+// Not parallel, because profiler labels are enabled for the whole package.
+func Test_UpdatePropagationTree_ProfilerLabels(t *testing.T) {
+	updtree.SetProfilerLabels(true)
+	defer updtree.SetProfilerLabels(false)
+
+	var nodeLabel, phaseLabel string
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	subscriber := updtree.NewNode[Ctx]("subscriber", func(ctx Ctx, evtTime time.Time) {
+		nodeLabel, _ = pprof.Label(ctx, "shdep.node")
+		phaseLabel, _ = pprof.Label(ctx, "shdep.phase")
+	})
+	root.Subscribe(subscriber)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, "subscriber", nodeLabel)
+	require.Equal(t, "update", phaseLabel)
+}
+
+func Test_UpdatePropagationTree_Stats(t *testing.T) {
+	t.Parallel()
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {
+		self.NotifyUpdated(context.Background(), time.Time{})
+	})
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	root.Subscribe(a)
+	a.Subscribe(b)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	root.NotifyUpdated(context.Background(), time.Time{})
+
+	require.Equal(t, updtree.NodeStats{Notifications: 2, UpdatesHandled: 0}, root.Stats())
+	require.Equal(t, updtree.NodeStats{Notifications: 2, UpdatesHandled: 2}, a.Stats())
+	require.Equal(t, updtree.NodeStats{Notifications: 0, UpdatesHandled: 2}, b.Stats())
+}
+
+// Not parallel, because measurement of handler durations is enabled for the whole package.
+func Test_UpdatePropagationTree_HandlerDurations(t *testing.T) {
+	updtree.SetMeasureHandlerDurations(true)
+	defer updtree.SetMeasureHandlerDurations(false)
+
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	subscriber := newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) {
+		time.Sleep(time.Millisecond)
+	})
+	root.Subscribe(subscriber)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.GreaterOrEqual(t, subscriber.Stats().HandlerDuration, time.Millisecond)
+}
+
+// Not parallel, because error policy is set for the whole package.
+func Test_UpdatePropagationTree_LenientErrorPolicy(t *testing.T) {
+	updtree.SetErrorPolicy(utils.PolicyLenient)
+	defer updtree.SetErrorPolicy(utils.PolicyStrict)
+
+	prev := utils.SetFailureHandler(func(err error) { t.Fatalf("failure handler must not be called: %v", err) })
+	defer utils.SetFailureHandler(prev)
+
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {})
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	a.Subscribe(b)
+	b.Subscribe(a)
+
+	require.NotPanics(t, func() {
+		a.NotifyUpdated(context.Background(), time.Time{})
+		a.NotifyUpdated(context.Background(), time.Time{})
+	})
+	require.Equal(t, uint64(0), b.Stats().UpdatesHandled)
+
+	errs, dropped := updtree.TakeErrors()
+	require.Len(t, errs, 2)
+	require.Zero(t, dropped)
+	require.ErrorContains(t, errs[0], "failed to determine update order of node a")
+
+	errs, _ = updtree.TakeErrors()
+	require.Empty(t, errs)
+
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) {})
+	c.Subscribe(updtree.NewNode[Ctx]("withoutHandler", nil))
+	require.NotPanics(t, func() {
+		c.NotifyUpdated(context.Background(), time.Time{})
+	})
+
+	errs, _ = updtree.TakeErrors()
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "onSubscriptionUpdated was not set for node withoutHandler")
+}
+
+// Not parallel, because event time check is enabled for the whole package.
+func Test_UpdatePropagationTree_EventTimeCheck(t *testing.T) {
+	updtree.SetEventTimeCheck(true, time.Hour)
+	defer updtree.SetEventTimeCheck(false, 0)
+
+	n := newUpdatePropagationNode("feed", func(self UpdatePropagationNode) {})
+	n.NotifyUpdated(context.Background(), time.Now())
+	require.Zero(t, n.Stats().SuspiciousEvtTimes)
+
+	n.NotifyUpdated(context.Background(), time.Time{})
+	n.NotifyUpdated(context.Background(), time.Now().Add(-2*time.Hour))
+	n.NotifyUpdated(context.Background(), time.Now().Add(2*time.Hour))
+	require.Equal(t, uint64(3), n.Stats().SuspiciousEvtTimes)
+
+	// Without skew only zero times are reported.
+	updtree.SetEventTimeCheck(true, 0)
+	n.NotifyUpdated(context.Background(), time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+	n.NotifyUpdated(context.Background(), time.Time{})
+	require.Equal(t, uint64(4), n.Stats().SuspiciousEvtTimes)
+}
+
+// Not parallel, because nested propagation mode and failure handler are set for the whole package.
+func Test_UpdatePropagationTree_NestedPropagation(t *testing.T) {
+	defer updtree.SetNestedPropagation(updtree.NestedPropagationAllow)
+
+	var failure error
+	prev := utils.SetFailureHandler(func(err error) { failure = err })
+	defer utils.SetFailureHandler(prev)
+
+	run := func(mode updtree.NestedPropagationMode) []string {
+		updtree.SetNestedPropagation(mode)
+
+		var handled []string
+		prices := newUpdatePropagationNode("prices", func(self UpdatePropagationNode) {})
+		news := newUpdatePropagationNode("news", func(self UpdatePropagationNode) {})
+
+		strategy := newUpdatePropagationNode("strategy", func(self UpdatePropagationNode) {
+			handled = append(handled, "strategy")
+			news.NotifyUpdated(context.Background(), time.Time{})
+			handled = append(handled, "strategy done")
+		})
+		reporter := newUpdatePropagationNode("reporter", func(self UpdatePropagationNode) {
+			handled = append(handled, "reporter")
+		})
+		prices.Subscribe(strategy)
+		prices.Subscribe(reporter)
+		news.Subscribe(reporter)
+
+		prices.NotifyUpdated(context.Background(), time.Time{})
+		return handled
+	}
+
+	// Reporter is already pending in the running propagation, so nested propagation is merged into it.
+	require.Equal(t, []string{"strategy", "strategy done", "reporter"}, run(updtree.NestedPropagationAllow))
+	require.NoError(t, failure)
+
+	require.Equal(t, []string{"strategy", "strategy done", "reporter", "reporter"}, run(updtree.NestedPropagationQueue))
+	require.NoError(t, failure)
+
+	require.Equal(t, []string{"strategy", "strategy done", "reporter"}, run(updtree.NestedPropagationFail))
+	require.ErrorContains(t, failure, "NotifyUpdated of node news")
+	require.ErrorContains(t, failure, "during propagation of update of node prices")
+}
+
+// Not parallel, because goroutine affinity and failure handler are set for the whole package.
+func Test_UpdatePropagationTree_GoroutineAffinity(t *testing.T) {
+	var failure error
+	prev := utils.SetFailureHandler(func(err error) { failure = err })
+	defer utils.SetFailureHandler(prev)
+
+	handled := 0
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	root.Subscribe(newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) { handled++ }))
+
+	updtree.SetGoroutineAffinity(true)
+	defer updtree.SetGoroutineAffinity(false)
+
+	root.NotifyUpdated(context.Background(), time.Time{})
+	require.NoError(t, failure)
+
+	inGoroutine := func(f func()) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			f()
+		}()
+		<-done
+	}
+
+	inGoroutine(func() { root.NotifyUpdated(context.Background(), time.Time{}) })
+	require.ErrorContains(t, failure, "NotifyUpdated of node root")
+	require.ErrorContains(t, failure, "Test_UpdatePropagationTree_GoroutineAffinity")
+	require.Equal(t, 1, handled)
+
+	failure = nil
+	lock := updtree.NewUpdateLock(nil)
+	inGoroutine(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		root.NotifyUpdated(context.Background(), time.Time{})
+	})
+	inGoroutine(func() {
+		updtree.RunSerialized(func() { root.NotifyUpdated(context.Background(), time.Time{}) })
+	})
+	require.NoError(t, failure)
+	require.Equal(t, 3, handled)
+}
+
+// Not parallel, because recording of subscription sites is enabled for the whole package.
+func Test_UpdatePropagationTree_SubscriptionHandles(t *testing.T) {
+	updtree.SetSubscriptionSites(true)
+	defer updtree.SetSubscriptionSites(false)
+
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {})
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) {})
+
+	ab := a.Subscribe(b).Named("prices")
+	require.Same(t, ab, a.Subscribe(b))
+	require.Equal(t, "prices", ab.Name())
+	require.Equal(t, a.ID(), ab.Source())
+	require.Equal(t, b.ID(), ab.Subscriber())
+	require.Contains(t, ab.CreatedAt(), "Test_UpdatePropagationTree_SubscriptionHandles")
+
+	ac := a.Subscribe(c)
+	require.Equal(t, []*updtree.Subscription{ab, ac}, a.Subscriptions())
+	require.Equal(t, a.ID()+" -> "+c.ID(), ac.String())
+
+	require.Equal(t, `digraph {
+  n0 [label="a"];
+  n1 [label="b"];
+  n2 [label="c"];
+  n0 -> n1 [label="prices"];
+  n0 -> n2;
+}
+`, a.DOT())
+
+	b.Detach()
+	require.Equal(t, []*updtree.Subscription{ac}, a.Subscriptions())
+
+	a.NotifyUpdated(context.Background(), time.Time{})
+	require.Regexp(t, `^c#[0-9a-f]{4}\(updates: 1\)$`, c.String())
+	require.Regexp(t, `^c-[0-9a-f]+$`, c.ID())
+}
+
+func Test_UpdatePropagationTree_Mute(t *testing.T) {
+	t.Parallel()
+
+	var bUpdates, cUpdates int
+	var lastEvtTime time.Time
+
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {})
+	b := updtree.NewNode[Ctx]("b", nil)
+	b.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		bUpdates++
+		lastEvtTime = evtTime
+		b.NotifyUpdated(ctx, evtTime)
+	})
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) { cUpdates++ })
+
+	a.Subscribe(b)
+	b.Subscribe(c)
+	a.Subscribe(c)
+
+	b.Mute(updtree.MuteDrop)
+	require.True(t, b.IsMuted())
+	a.NotifyUpdated(context.Background(), time.Unix(1, 0))
+	require.Equal(t, 0, bUpdates)
+	require.Equal(t, 1, cUpdates)
+
+	b.Unmute()
+	require.False(t, b.IsMuted())
+	require.Equal(t, 0, bUpdates)
+
+	b.Mute(updtree.MuteBuffer)
+	a.NotifyUpdated(context.Background(), time.Unix(2, 0))
+	a.NotifyUpdated(context.Background(), time.Unix(3, 0))
+	require.Equal(t, 0, bUpdates)
+	require.Equal(t, 3, cUpdates)
+
+	// Buffered updates are collapsed into the last one.
+	b.Unmute()
+	require.Equal(t, 1, bUpdates)
+	require.Equal(t, time.Unix(3, 0), lastEvtTime)
+	require.Equal(t, 4, cUpdates)
+	require.Equal(t, uint64(3), b.Stats().UpdatesMuted)
+
+	b.Unmute()
+	require.Equal(t, 1, bUpdates)
+}
+
+func Test_UpdatePropagationTree_ThrottleAndDebounce(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := shdeptest.NewClock(start)
+
+	src := newUpdatePropagationNode("src", func(self UpdatePropagationNode) {})
+	throttled := updtree.Throttle[Ctx](src, time.Second, updtree.WithClock(clock))
+	debounced := updtree.Debounce[Ctx](src, time.Second, updtree.WithClock(clock))
+
+	var throttledTimes, debouncedTimes []time.Time
+	throttled.Subscribe(newUpdatePropagationNode("throttledSub", func(self UpdatePropagationNode) {
+		throttledTimes = append(throttledTimes, clock.Now())
+	}))
+	debounced.Subscribe(newUpdatePropagationNode("debouncedSub", func(self UpdatePropagationNode) {
+		debouncedTimes = append(debouncedTimes, clock.Now())
+	}))
+
+	notify := func() { src.NotifyUpdated(context.Background(), clock.Now()) }
+
+	notify()
+	require.Equal(t, []time.Time{start}, throttledTimes)
+	clock.Advance(300 * time.Millisecond)
+	notify()
+	clock.Advance(300 * time.Millisecond)
+	notify()
+	require.Len(t, throttledTimes, 1)
+	require.Empty(t, debouncedTimes)
+
+	// Updates received during the interval are passed as one, when it ends.
+	clock.Advance(400 * time.Millisecond)
+	require.Equal(t, []time.Time{start, start.Add(time.Second)}, throttledTimes)
+	require.Empty(t, debouncedTimes)
+
+	clock.Advance(time.Second)
+	require.Equal(t, []time.Time{start.Add(1600 * time.Millisecond)}, debouncedTimes)
+	require.Len(t, throttledTimes, 2)
+
+	clock.Advance(time.Second)
+	notify()
+	require.Equal(t, start.Add(3*time.Second), throttledTimes[2])
+}
+
+func Test_UpdatePropagationTree_ThrottleAndDebounceWallClock(t *testing.T) {
+	t.Parallel()
+
+	src := newUpdatePropagationNode("src", func(self UpdatePropagationNode) {})
+
+	// Default wall clock fires timers from other goroutines without synchronization.
+	require.Panics(t, func() { updtree.Throttle[Ctx](src, time.Millisecond) })
+	require.Panics(t, func() { updtree.Debounce[Ctx](src, time.Millisecond) })
+
+	lock := updtree.NewUpdateLock(nil)
+	clock := updtree.NewWallClock(lock)
+
+	throttled := updtree.Throttle[Ctx](src, time.Millisecond, updtree.WithClock(clock))
+	debounced := updtree.Debounce[Ctx](src, 5*time.Millisecond, updtree.WithClock(clock))
+
+	// Counters are not synchronized: race detector reports, if timers propagate updates concurrently with producers.
+	var updates, throttledUpdates, debouncedUpdates int
+	debouncedDone := make(chan struct{}, 1)
+	src.Subscribe(newUpdatePropagationNode("srcSub", func(self UpdatePropagationNode) { updates++ }))
+	throttled.Subscribe(newUpdatePropagationNode("throttledSub", func(self UpdatePropagationNode) { throttledUpdates++ }))
+	debounced.Subscribe(newUpdatePropagationNode("debouncedSub", func(self UpdatePropagationNode) {
+		debouncedUpdates++
+		select {
+		case debouncedDone <- struct{}{}:
+		default:
+		}
+	}))
+
+	const goroutines = 5
+	const updatesPerGoroutine = 20
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updatesPerGoroutine; j++ {
+				lock.Lock()
+				src.NotifyUpdated(context.Background(), time.Now())
+				lock.Unlock()
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-debouncedDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("debounced update was not passed")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, goroutines*updatesPerGoroutine, updates)
+	require.Positive(t, throttledUpdates)
+	require.LessOrEqual(t, throttledUpdates, updates)
+	require.Positive(t, debouncedUpdates)
+}
+
+func Test_UpdatePropagationTree_Aggregate(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := shdeptest.NewClock(start)
+
+	a := newUpdatePropagationNode("a", func(self UpdatePropagationNode) {})
+	b := newUpdatePropagationNode("b", func(self UpdatePropagationNode) {})
+	c := newUpdatePropagationNode("c", func(self UpdatePropagationNode) {})
+
+	var all, quorum, windowed int
+	allNode := updtree.Aggregate(func(ctx Ctx, evtTime time.Time) { all++ }, updtree.AggregateOpts{}, a, b, c)
+	updtree.Aggregate(func(ctx Ctx, evtTime time.Time) { quorum++ }, updtree.AggregateOpts{Quorum: 2}, a, b, c)
+	updtree.Aggregate(func(ctx Ctx, evtTime time.Time) { windowed++ }, updtree.AggregateOpts{Window: time.Second, Clock: clock}, a, b)
+
+	var allSubUpdates int
+	allNode.Subscribe(newUpdatePropagationNode("allSub", func(self UpdatePropagationNode) { allSubUpdates++ }))
+
+	notify := func(n UpdatePropagationNode) { n.NotifyUpdated(context.Background(), clock.Now()) }
+
+	notify(a)
+	notify(a)
+	require.Equal(t, 0, quorum)
+	notify(b)
+	require.Equal(t, 1, quorum)
+	require.Equal(t, 1, windowed)
+	require.Equal(t, 0, all)
+
+	notify(c)
+	require.Equal(t, 1, all)
+	require.Equal(t, 1, allSubUpdates)
+	require.Equal(t, 1, quorum)
+
+	// Update of a is forgotten, because b updates outside of the window.
+	notify(a)
+	clock.Advance(2 * time.Second)
+	notify(b)
+	require.Equal(t, 1, windowed)
+	notify(a)
+	require.Equal(t, 2, windowed)
+}
+
+func Test_UpdatePropagationTree_RateLimited(t *testing.T) {
+	t.Parallel()
+
+	clock := shdeptest.NewClock(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	src := newUpdatePropagationNode("src", func(self UpdatePropagationNode) {})
+	var overflows int
+	limited := updtree.RateLimited(src, updtree.RateLimitOpts[Ctx]{
+		Rate:       2,
+		Burst:      2,
+		OnOverflow: func(ctx Ctx, evtTime time.Time) { overflows++ },
+		Clock:      clock,
+	})
+
+	var passed int
+	limited.Subscribe(newUpdatePropagationNode("orders", func(self UpdatePropagationNode) { passed++ }))
+
+	notify := func() { src.NotifyUpdated(context.Background(), clock.Now()) }
+
+	for i := 0; i < 5; i++ {
+		notify()
+	}
+	require.Equal(t, 2, passed)
+	require.Equal(t, 3, overflows)
+
+	// One token is restored each half of a second.
+	clock.Advance(500 * time.Millisecond)
+	notify()
+	notify()
+	require.Equal(t, 3, passed)
+	require.Equal(t, 4, overflows)
+
+	clock.Advance(10 * time.Second)
+	for i := 0; i < 3; i++ {
+		notify()
+	}
+	require.Equal(t, 5, passed)
+}
+
+func Test_UpdatePropagationTree_Synchronized(t *testing.T) {
+	t.Parallel()
+
+	// Separate graph updated from handler of the first one.
+	audited := 0
+	audit := newUpdatePropagationNode("audit", func(self UpdatePropagationNode) {})
+	auditLog := newUpdatePropagationNode("auditLog", func(self UpdatePropagationNode) { audited++ })
+	audit.Subscribe(auditLog)
+
+	// Feeds share the lock, so their propagations are serialized with each other.
+	var lock sync.Mutex
+	feeds := []*UpdatePropagationNodeBase{
+		updtree.NewSynchronizedNode[Ctx]("feed1", &lock),
+		updtree.NewSynchronizedNode[Ctx]("feed2", &lock),
+	}
+	price, sum := 0, 0
+	total := newUpdatePropagationNode("total", func(self UpdatePropagationNode) {
+		sum += price
+		audit.NotifyUpdated(context.Background(), time.Time{})
+	})
+	for _, feed := range feeds {
+		feed.Subscribe(total)
+	}
+
+	const goroutines, updates = 8, 200
+	done := make(chan struct{})
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			feed := feeds[i%len(feeds)]
+			for j := 0; j < updates; j++ {
+				if j%2 == 0 {
+					feed.UpdateAndNotify(context.Background(), time.Time{}, func() { price = 1 })
+				} else {
+					feed.NotifyUpdated(context.Background(), time.Time{})
+				}
+			}
+		}()
+	}
+	for i := 0; i < goroutines; i++ {
+		<-done
+	}
+
+	require.Equal(t, goroutines*updates, sum)
+	require.Equal(t, goroutines*updates, audited)
+
+	// Synchronized node is an entry point of external updates.
+	require.Panics(t, func() { total.Subscribe(feeds[0]) })
+}
+
+func Test_UpdatePropagationTree_SynchronizedProducers(t *testing.T) {
+	t.Parallel()
+
+	require.Panics(t, func() { updtree.NewSynchronizedNode[Ctx]("feed", nil) })
+
+	// Two producers feed one subscriber, so they must share the lock. Counter is not synchronized
+	// otherwise: race detector reports, if propagations of the producers run concurrently.
+	lock := updtree.NewUpdateLock(nil)
+	producers := []*UpdatePropagationNodeBase{
+		updtree.NewSynchronizedNode[Ctx]("producer1", lock),
+		updtree.NewSynchronizedNode[Ctx]("producer2", lock),
+	}
+	handled := 0
+	subscriber := newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) { handled++ })
+	for _, p := range producers {
+		p.Subscribe(subscriber)
+	}
+
+	const updates = 500
+	var wg sync.WaitGroup
+	for _, p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				p.NotifyUpdated(context.Background(), time.Time{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, len(producers)*updates, handled)
+}
+
+// Not parallel, because settings are changed for the whole package.
+func Test_UpdatePropagationTree_SettingsChangedConcurrently(t *testing.T) {
+	handled := 0
+	root := newUpdatePropagationNode("root", func(self UpdatePropagationNode) {})
+	subscriber := newUpdatePropagationNode("subscriber", func(self UpdatePropagationNode) { handled++ })
+	root.Subscribe(subscriber)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for enabled := true; ; enabled = !enabled {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			updtree.SetMeasureHandlerDurations(enabled)
+			updtree.SetEventTimeCheck(enabled, time.Hour)
+			updtree.SetNestedPropagation(updtree.NestedPropagationAllow)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		root.NotifyUpdated(context.Background(), time.Now())
+	}
+
+	close(done)
+	wg.Wait()
+	updtree.SetMeasureHandlerDurations(false)
+	updtree.SetEventTimeCheck(false, 0)
+
+	require.Equal(t, 1000, handled)
+}
@@ -0,0 +1,159 @@
+// Package updtreetest provides property-based testing of update propagation:
+// Checker verifies invariants of propagations in any graph of nodes, and CheckRandom
+// generates random graphs and random updates to be used in fuzz tests.
+package updtreetest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+type statsProvider interface {
+	Stats() updtree.NodeStats
+}
+
+type subscribersProvider[Ctx any] interface {
+	Subscribers() []updtree.Node[Ctx]
+}
+
+// NewChecker creates checker of propagation invariants.
+func NewChecker[Ctx any]() *Checker[Ctx] {
+	return &Checker[Ctx]{
+		names: make(map[updtree.Node[Ctx]]string),
+	}
+}
+
+// Checker tracks invocations of update handlers of nodes and verifies after each propagation, that:
+//   - each handler has been called at most once,
+//   - handler has been called only if some of its subscriptions has been updated,
+//   - handler has been called after handlers of all updated subscriptions,
+//   - each node, which has been updated, has caused handlers of all its tracked subscribers to be called,
+//   - all nodes report HasUpdated() == false after propagation.
+//
+// Nodes must be based on updtree.NodeBase, e.g. nodes of shared objects returned by GetUpdateNode.
+// All nodes of the graph must be tracked. Subscriptions of nodes are collected on the first propagation
+// after a node is tracked, so the graph must not change without tracking new nodes.
+type Checker[Ctx any] struct {
+	nodes         []updtree.Node[Ctx]
+	names         map[updtree.Node[Ctx]]string
+	subscriptions map[updtree.Node[Ctx]][]updtree.Node[Ctx]
+
+	handled []updtree.Node[Ctx]
+}
+
+// Track sets update handler of the node, which records invocation and calls handler.
+// Handler can be nil, if node does not need to do anything.
+func (c *Checker[Ctx]) Track(node updtree.Node[Ctx], name string, handler func(ctx Ctx, evtTime time.Time)) {
+	if _, ok := node.(statsProvider); !ok {
+		panic(fmt.Sprintf("node %v does not provide stats", name))
+	}
+
+	if _, ok := node.(subscribersProvider[Ctx]); !ok {
+		panic(fmt.Sprintf("node %v does not provide subscribers", name))
+	}
+
+	c.nodes = append(c.nodes, node)
+	c.names[node] = name
+
+	node.SetUpdateHandler(func(ctx Ctx, evtTime time.Time) {
+		c.handled = append(c.handled, node)
+		if handler != nil {
+			handler(ctx, evtTime)
+		}
+	})
+}
+
+func (c *Checker[Ctx]) collectSubscriptions() {
+	c.subscriptions = make(map[updtree.Node[Ctx]][]updtree.Node[Ctx], len(c.nodes))
+	for _, n := range c.nodes {
+		for _, subscriber := range n.(subscribersProvider[Ctx]).Subscribers() {
+			c.subscriptions[subscriber] = append(c.subscriptions[subscriber], n)
+		}
+	}
+}
+
+func (c *Checker[Ctx]) name(node updtree.Node[Ctx]) string {
+	if name, ok := c.names[node]; ok {
+		return name
+	}
+	return fmt.Sprint(node)
+}
+
+// Notify notifies subscribers of the source node about update and checks invariants of the propagation.
+// Returns description of the first violated invariant.
+func (c *Checker[Ctx]) Notify(source updtree.Node[Ctx], ctx Ctx, evtTime time.Time) error {
+	notificationsBefore := make(map[updtree.Node[Ctx]]uint64, len(c.nodes))
+	for _, node := range c.nodes {
+		notificationsBefore[node] = node.(statsProvider).Stats().Notifications
+	}
+
+	c.handled = c.handled[:0]
+	source.NotifyUpdated(ctx, evtTime)
+
+	// Handlers can subscribe nodes during propagation, so subscriptions are collected after it.
+	c.collectSubscriptions()
+
+	updated := make(map[updtree.Node[Ctx]]bool, len(c.nodes))
+	for _, node := range c.nodes {
+		if node.(statsProvider).Stats().Notifications != notificationsBefore[node] {
+			updated[node] = true
+		}
+	}
+	updated[source] = true
+
+	handledAt := make(map[updtree.Node[Ctx]]int, len(c.handled))
+	for i, node := range c.handled {
+		if prev, ok := handledAt[node]; ok {
+			return fmt.Errorf("handler of %v called twice: at %v and %v", c.name(node), prev, i)
+		}
+		handledAt[node] = i
+	}
+
+	for i, node := range c.handled {
+		hasUpdatedSubscription := false
+
+		for _, subscription := range c.subscriptions[node] {
+			if !updated[subscription] {
+				continue
+			}
+			hasUpdatedSubscription = true
+
+			if subscription == source {
+				continue
+			}
+
+			pos, ok := handledAt[subscription]
+			if !ok {
+				return fmt.Errorf("subscription %v of %v was updated without its handler being called", c.name(subscription), c.name(node))
+			}
+			if pos > i {
+				return fmt.Errorf("handler of %v called before handler of its subscription %v", c.name(node), c.name(subscription))
+			}
+		}
+
+		if !hasUpdatedSubscription {
+			return fmt.Errorf("handler of %v called, but none of its subscriptions was updated", c.name(node))
+		}
+	}
+
+	for node := range updated {
+		for _, subscriber := range node.(subscribersProvider[Ctx]).Subscribers() {
+			if _, tracked := c.names[subscriber]; !tracked {
+				continue
+			}
+			if _, ok := handledAt[subscriber]; !ok {
+				return fmt.Errorf("%v was updated, but handler of its subscriber %v was not called", c.name(node), c.name(subscriber))
+			}
+		}
+	}
+
+	for _, node := range c.nodes {
+		if node.HasUpdated() {
+			return fmt.Errorf("%v reports update after propagation has finished", c.name(node))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package updtreetest
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// RandomConfig configures random graphs and updates generated by CheckRandom.
+type RandomConfig struct {
+	// Number of nodes of the graph.
+	Nodes int
+
+	// Probability of each node to subscribe to each of previously created nodes.
+	SubscriptionProbability float64
+
+	// Probability of a node to propagate update further, when its handler is called.
+	PropagationProbability float64
+
+	// Number of checked propagations. Each of them starts from a random node.
+	Propagations int
+
+	// If set, nodes are attached to updtree.Tree instead of using own update orders.
+	UseTree bool
+}
+
+// CheckRandom generates random graph and random propagations of updates using the seed, and checks invariants
+// of each propagation using Checker. Returns description of the first violated invariant.
+// Results are reproducible for the same seed and config.
+func CheckRandom(seed int64, cfg RandomConfig) error {
+	rng := rand.New(rand.NewSource(seed))
+
+	var tree *updtree.Tree[struct{}]
+	if cfg.UseTree {
+		tree = updtree.NewTree[struct{}]()
+	}
+
+	nodes := make([]*updtree.NodeBase[struct{}], cfg.Nodes)
+	for i := range nodes {
+		nodes[i] = updtree.NewNode[struct{}](fmt.Sprintf("node-%v", i), nil)
+		if tree != nil {
+			tree.Attach(nodes[i])
+		}
+
+		for j := 0; j < i; j++ {
+			if rng.Float64() < cfg.SubscriptionProbability {
+				nodes[j].Subscribe(nodes[i])
+			}
+		}
+	}
+
+	checker := NewChecker[struct{}]()
+	for i, node := range nodes {
+		checker.Track(node, fmt.Sprintf("node-%v", i), func(ctx struct{}, evtTime time.Time) {
+			if rng.Float64() < cfg.PropagationProbability {
+				node.NotifyUpdated(ctx, evtTime)
+			}
+		})
+	}
+
+	for i := 0; i < cfg.Propagations && len(nodes) > 0; i++ {
+		source := nodes[rng.Intn(len(nodes))]
+		if err := checker.Notify(source, struct{}{}, time.Time{}); err != nil {
+			return fmt.Errorf("propagation %v from %v: %w", i, source, err)
+		}
+	}
+
+	return nil
+}
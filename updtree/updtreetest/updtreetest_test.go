@@ -0,0 +1,93 @@
+package updtreetest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep/updtree"
+	"github.com/nnikolash/go-shdep/updtree/updtreetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Diamond(t *testing.T) {
+	t.Parallel()
+
+	//    /-> left -\
+	// root          -> bottom
+	//    \-> right-/
+	root := updtree.NewNode[context.Context]("root", nil)
+	left := updtree.NewNode[context.Context]("left", nil)
+	right := updtree.NewNode[context.Context]("right", nil)
+	bottom := updtree.NewNode[context.Context]("bottom", nil)
+	root.Subscribe(left)
+	root.Subscribe(right)
+	left.Subscribe(bottom)
+	right.Subscribe(bottom)
+
+	var bottomUpdates int
+	checker := updtreetest.NewChecker[context.Context]()
+	checker.Track(root, "root", nil)
+	checker.Track(left, "left", left.NotifyUpdated)
+	checker.Track(right, "right", right.NotifyUpdated)
+	checker.Track(bottom, "bottom", func(ctx context.Context, evtTime time.Time) { bottomUpdates++ })
+
+	require.NoError(t, checker.Notify(root, context.Background(), time.Now()))
+	require.NoError(t, checker.Notify(left, context.Background(), time.Now()))
+	require.Equal(t, 2, bottomUpdates)
+}
+
+func TestChecker_DetectsMissedSubscriber(t *testing.T) {
+	t.Parallel()
+
+	root := updtree.NewNode[context.Context]("root", nil)
+	late := updtree.NewNode[context.Context]("late", nil)
+	other := updtree.NewNode[context.Context]("other", nil)
+	root.Subscribe(other)
+
+	checker := updtreetest.NewChecker[context.Context]()
+	checker.Track(root, "root", nil)
+	checker.Track(late, "late", nil)
+	checker.Track(other, "other", func(ctx context.Context, evtTime time.Time) {
+		if len(root.Subscribers()) == 1 {
+			root.Subscribe(late)
+		}
+	})
+
+	// Subscription made during propagation takes effect only on next propagation.
+	require.ErrorContains(t, checker.Notify(root, context.Background(), time.Now()), "handler of its subscriber late was not called")
+	require.NoError(t, checker.Notify(root, context.Background(), time.Now()))
+}
+
+func TestCheckRandom(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(0); seed < 50; seed++ {
+		for _, useTree := range []bool{false, true} {
+			err := updtreetest.CheckRandom(seed, updtreetest.RandomConfig{
+				Nodes:                   30,
+				SubscriptionProbability: 0.15,
+				PropagationProbability:  0.7,
+				Propagations:            20,
+				UseTree:                 useTree,
+			})
+			require.NoError(t, err, "seed %v, tree %v", seed, useTree)
+		}
+	}
+}
+
+func FuzzPropagation(f *testing.F) {
+	f.Add(int64(1), uint8(10), uint8(30), uint8(80), false)
+	f.Add(int64(2), uint8(50), uint8(10), uint8(50), true)
+
+	f.Fuzz(func(t *testing.T, seed int64, nodes, subscriptionPercent, propagationPercent uint8, useTree bool) {
+		err := updtreetest.CheckRandom(seed, updtreetest.RandomConfig{
+			Nodes:                   int(nodes),
+			SubscriptionProbability: float64(subscriptionPercent%101) / 100,
+			PropagationProbability:  float64(propagationPercent%101) / 100,
+			Propagations:            10,
+			UseTree:                 useTree,
+		})
+		require.NoError(t, err)
+	})
+}
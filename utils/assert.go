@@ -1,9 +1,74 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"sync/atomic"
+)
 
-func Assert(condition bool, format string, args ...interface{}) {
+// FailureHandler handles assertion failures and misuse of the library.
+// By default it panics. If handler returns, failed operation is aborted
+// and execution continues, so it can be used to log errors in production.
+type FailureHandler func(err error)
+
+var failureHandler atomic.Pointer[FailureHandler]
+
+// PanicOnFailure is default failure handler.
+func PanicOnFailure(err error) {
+	panic(err)
+}
+
+// SetFailureHandler sets package-level failure handler and returns previous one.
+// Passing nil restores default handler.
+func SetFailureHandler(h FailureHandler) (prev FailureHandler) {
+	if h == nil {
+		h = PanicOnFailure
+	}
+
+	if prevPtr := failureHandler.Swap(&h); prevPtr != nil {
+		return *prevPtr
+	}
+
+	return PanicOnFailure
+}
+
+// Fail reports failure to the package-level failure handler.
+func Fail(err error) {
+	if h := failureHandler.Load(); h != nil {
+		(*h)(err)
+		return
+	}
+
+	PanicOnFailure(err)
+}
+
+// Assert reports failure if condition is false. Returns condition.
+func Assert(condition bool, format string, args ...interface{}) bool {
 	if !condition {
-		panic(fmt.Errorf(format, args...))
+		Fail(fmt.Errorf(format, args...))
+	}
+
+	return condition
+}
+
+// ErrorPolicy defines how misuse of the library is reported.
+type ErrorPolicy int
+
+const (
+	// PolicyStrict reports misuse to failure handler, which panics by default.
+	PolicyStrict ErrorPolicy = iota
+	// PolicyLenient never panics on misuse. Failure handler is not called: failed operation is aborted,
+	// and the error is recorded to be returned later, e.g. by Init of the store.
+	// It allows libraries embedding this one to guarantee that they never panic the host process.
+	PolicyLenient
+)
+
+func (p ErrorPolicy) String() string {
+	switch p {
+	case PolicyStrict:
+		return "strict"
+	case PolicyLenient:
+		return "lenient"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
 	}
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GraphToDOT renders graph in Graphviz DOT format. Edges are directed from the node to the nodes it points to.
+// Nodes and edges are ordered by their labels, so the output is same across runs as long as labels are unique.
+func GraphToDOT[Key comparable](graph Graph[Key], label func(Key) string) string {
+	return GraphToLabeledDOT(graph, label, nil)
+}
+
+// GraphToLabeledDOT is same as GraphToDOT, but also labels edges using edgeLabel. Edges with empty label are not labeled.
+// If edgeLabel is nil, edges are not labeled.
+func GraphToLabeledDOT[Key comparable](graph Graph[Key], label func(Key) string, edgeLabel func(from, to Key) string) string {
+	if label == nil {
+		label = func(k Key) string { return fmt.Sprint(k) }
+	}
+
+	labels := make(map[Key]string, len(graph))
+	for node, edges := range graph {
+		labels[node] = label(node)
+		for _, to := range edges {
+			if _, ok := labels[to]; !ok {
+				labels[to] = label(to)
+			}
+		}
+	}
+
+	compare := func(a, b Key) int {
+		if c := strings.Compare(labels[a], labels[b]); c != 0 {
+			return c
+		}
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+
+	nodes := SortedKeysFunc(labels, compare)
+
+	ids := make(map[Key]int, len(nodes))
+	for i, node := range nodes {
+		ids[node] = i
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "  n%d [label=%s];\n", ids[node], strconv.Quote(labels[node]))
+	}
+
+	for _, node := range nodes {
+		edges := slices.Clone(graph[node])
+		slices.SortFunc(edges, compare)
+
+		for _, to := range edges {
+			if edgeLabel != nil {
+				if l := edgeLabel(node, to); l != "" {
+					fmt.Fprintf(&b, "  n%d -> n%d [label=%s];\n", ids[node], ids[to], strconv.Quote(l))
+					continue
+				}
+			}
+			fmt.Fprintf(&b, "  n%d -> n%d;\n", ids[node], ids[to])
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
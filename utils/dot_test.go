@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphToDOT(t *testing.T) {
+	t.Parallel()
+
+	graph := utils.Graph[int]{
+		3: {1, 2},
+		2: {1},
+		1: nil,
+		4: {5},
+	}
+	label := func(k int) string { return fmt.Sprintf("node %d", k) }
+
+	const expected = `digraph {
+  n0 [label="node 1"];
+  n1 [label="node 2"];
+  n2 [label="node 3"];
+  n3 [label="node 4"];
+  n4 [label="node 5"];
+  n1 -> n0;
+  n2 -> n0;
+  n2 -> n1;
+  n3 -> n4;
+}
+`
+
+	// Output must not depend on map iteration order.
+	for i := 0; i < 10; i++ {
+		require.Equal(t, expected, utils.GraphToDOT(graph, label))
+	}
+}
+
+func TestGraphToLabeledDOT(t *testing.T) {
+	t.Parallel()
+
+	graph := utils.Graph[string]{
+		"b": {"a", "c"},
+		"a": {"c"},
+	}
+	edgeLabel := func(from, to string) string {
+		if to == "c" {
+			return from + "->c"
+		}
+		return ""
+	}
+
+	require.Equal(t, `digraph {
+  n0 [label="a"];
+  n1 [label="b"];
+  n2 [label="c"];
+  n0 -> n2 [label="a->c"];
+  n1 -> n0;
+  n1 -> n2 [label="b->c"];
+}
+`, utils.GraphToLabeledDOT(graph, nil, edgeLabel))
+}
@@ -13,11 +13,26 @@ var hashJSONConfig = sonic.Config{SortMapKeys: true}.Froze()
 func Hash(values ...interface{}) (string, error) {
 	//return hashstructure.Hash(values, &hashstructure.HashOptions{})
 
+	valuesStr, err := CanonicalJSON(values...)
+	if err != nil {
+		return "", err
+	}
+
+	return HashJSON(valuesStr)
+}
+
+// CanonicalJSON serializes values into JSON with sorted keys of maps, from which Hash is calculated.
+func CanonicalJSON(values ...interface{}) ([]byte, error) {
 	valuesStr, err := hashJSONConfig.MarshalIndent(values, "", "  ")
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to marshal hashed values into json")
+		return nil, errors.Wrapf(err, "failed to marshal hashed values into json")
 	}
 
+	return valuesStr, nil
+}
+
+// HashJSON calculates hash of values serialized by CanonicalJSON. Hash(values...) is same as HashJSON(CanonicalJSON(values...)).
+func HashJSON(valuesStr []byte) (string, error) {
 	md5Hash := md5.New()
 	if _, err := md5Hash.Write(valuesStr); err != nil {
 		return "", errors.Wrapf(err, "failed to write hashed values into md5")
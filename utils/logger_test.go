@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// Removes timestamps written by log.Logger.
+var timestampRE = regexp.MustCompile(`(?m)^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{6} `)
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := utils.NewStdLoggerTo(&buf, utils.LevelDebug)
+
+	l.Tracef("discarded %v", 1)
+	l.Debugf("debug %v", 2)
+	l.Infof("info %v", 3)
+	l.Warnf("warn %v", 4)
+	l.Errorf("error %v", 5)
+	l.LogKV(utils.LevelTrace, "discarded kv", "k", 6)
+	l.LogKV(utils.LevelInfo, "kv", "a", 7, "b", "x", "odd")
+	require.PanicsWithError(t, "panic 8", func() { l.Panicf("panic %v", 8) })
+
+	require.False(t, l.Enabled(utils.LevelTrace))
+	require.True(t, l.Enabled(utils.LevelDebug))
+
+	require.Equal(t, `DEBUG debug 2
+INFO  info 3
+WARN  warn 4
+ERROR error 5
+INFO  kv a=7 b=x odd
+PANIC panic 8
+`, timestampRE.ReplaceAllString(buf.String(), ""))
+}
+
+func TestStdLogger_MinLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := utils.NewStdLoggerTo(&buf, utils.LevelWarn)
+
+	l.Debugf("discarded")
+	l.Infof("discarded")
+	l.LogKV(utils.LevelInfo, "discarded", "k", 1)
+	l.Warnf("warn")
+	l.LogKV(utils.LevelError, "kv", "k", 2)
+
+	for level := utils.LevelTrace; level <= utils.LevelPanic; level++ {
+		require.Equal(t, level >= utils.LevelWarn, l.Enabled(level), level)
+	}
+	require.Equal(t, "WARN  warn\nERROR kv k=2\n", timestampRE.ReplaceAllString(buf.String(), ""))
+
+	// Fresh logger writes to stderr.
+	require.True(t, utils.NewStdLogger(utils.LevelInfo).Enabled(utils.LevelInfo))
+}
+
+func TestWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := utils.WithPrefix(utils.NewStdLoggerTo(&buf, utils.LevelInfo), "obj1: ")
+
+	l.Debugf("discarded")
+	l.Infof("info %v%%", 100)
+	l.Errorf("error %q", "x")
+	utils.LogKV(l, utils.LevelWarn, "kv", "k", 1)
+	utils.LogKV(utils.WithPrefix(l, "nested: "), utils.LevelInfo, "nested kv", "k", 2)
+	require.PanicsWithError(t, "obj1: panic", func() { l.Panicf("panic") })
+
+	require.False(t, utils.LogEnabled(l, utils.LevelDebug))
+	require.True(t, utils.LogEnabled(l, utils.LevelInfo))
+
+	require.Equal(t, `INFO  obj1: info 100%
+ERROR obj1: error "x"
+WARN  obj1: kv k=1
+INFO  obj1: nested: nested kv k=2
+PANIC obj1: panic
+`, timestampRE.ReplaceAllString(buf.String(), ""))
+}
+
+func TestWithPrefix_NilLogger(t *testing.T) {
+	t.Parallel()
+
+	l := utils.WithPrefix(nil, "p: ")
+	require.NotPanics(t, func() {
+		l.Infof("discarded")
+		utils.LogKV(l, utils.LevelInfo, "discarded", "k", 1)
+	})
+	require.False(t, utils.LogEnabled(l, utils.LevelPanic))
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Logger interface {
@@ -18,6 +19,8 @@ type Logger interface {
 type NoopLogger struct{}
 
 var _ Logger = &NoopLogger{}
+var _ KVLogger = &NoopLogger{}
+var _ LevelEnabler = &NoopLogger{}
 
 func (l *NoopLogger) Tracef(format string, args ...interface{}) {}
 func (l *NoopLogger) Debugf(format string, args ...interface{}) {}
@@ -32,3 +35,116 @@ func (l *NoopLogger) Fatalf(format string, args ...interface{}) {
 func (l *NoopLogger) Panicf(format string, args ...interface{}) {
 	panic(fmt.Errorf(format, args...))
 }
+
+func (l *NoopLogger) LogKV(level LogLevel, msg string, kv ...interface{}) {}
+
+func (l *NoopLogger) Enabled(level LogLevel) bool {
+	return false
+}
+
+type LogLevel int
+
+const (
+	LevelTrace LogLevel = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+	LevelPanic
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	case LevelPanic:
+		return "PANIC"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// KVLogger is an optional interface of Logger for writing structured messages.
+// Store and update tree prefer it over formatted messages when logger implements it.
+// LogKV only writes messages: levels LevelFatal and LevelPanic don't exit or panic.
+type KVLogger interface {
+	LogKV(level LogLevel, msg string, kv ...interface{})
+}
+
+// callerKVLogger is implemented by KVLoggers, which record source of the message.
+// skip is the number of stack frames to skip to reach the caller, counting from
+// runtime.Callers called inside of logKV.
+type callerKVLogger interface {
+	logKV(skip int, level LogLevel, msg string, kv ...interface{})
+}
+
+// LogKV writes structured message into l. If l does not implement KVLogger,
+// key-value pairs are appended to the message as "key=value".
+func LogKV(l Logger, level LogLevel, msg string, kv ...interface{}) {
+	if cl, ok := l.(callerKVLogger); ok {
+		// Skipping runtime.Callers, logKV and this function.
+		cl.logKV(3, level, msg, kv...)
+		return
+	}
+
+	if kvl, ok := l.(KVLogger); ok {
+		kvl.LogKV(level, msg, kv...)
+		return
+	}
+
+	msg = msg + formatKV(kv)
+
+	switch level {
+	case LevelTrace:
+		l.Tracef("%s", msg)
+	case LevelDebug:
+		l.Debugf("%s", msg)
+	case LevelInfo:
+		l.Infof("%s", msg)
+	case LevelWarn:
+		l.Warnf("%s", msg)
+	default:
+		l.Errorf("%s", msg)
+	}
+}
+
+func formatKV(kv []interface{}) string {
+	var b strings.Builder
+
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", kv[i])
+		}
+	}
+
+	return b.String()
+}
+
+// LevelEnabler is an optional interface of Logger for reporting, whether messages of
+// the level are written. It allows to skip preparation of messages, which are discarded anyway.
+type LevelEnabler interface {
+	Enabled(level LogLevel) bool
+}
+
+// LogEnabled reports whether l writes messages of the level.
+// Loggers, which do not implement LevelEnabler, are considered to write all messages.
+func LogEnabled(l Logger, level LogLevel) bool {
+	if e, ok := l.(LevelEnabler); ok {
+		return e.Enabled(level)
+	}
+
+	return true
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// SortedKeys returns keys of the map in ascending order.
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	return SortedKeysFunc(m, cmp.Compare[K])
+}
+
+// SortedKeysFunc returns keys of the map sorted using compare function.
+func SortedKeysFunc[K comparable, V any](m map[K]V, compare func(a, b K) int) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, compare)
+
+	return keys
+}
+
+// SortedRange iterates over the map in ascending order of keys.
+func SortedRange[K cmp.Ordered, V any](m map[K]V) iter.Seq2[K, V] {
+	return SortedRangeFunc(m, cmp.Compare[K])
+}
+
+// SortedRangeFunc iterates over the map in order of keys defined by compare function.
+func SortedRangeFunc[K comparable, V any](m map[K]V, compare func(a, b K) int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, k := range SortedKeysFunc(m, compare) {
+			if !yield(k, m[k]) {
+				return
+			}
+		}
+	}
+}
+
+// CompareByLess converts "less" function into "compare" function.
+func CompareByLess[T any](less func(a, b T) bool) func(a, b T) int {
+	return func(a, b T) int {
+		if less(a, b) {
+			return -1
+		}
+		if less(b, a) {
+			return 1
+		}
+		return 0
+	}
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedKeys(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]int{"c": 3, "a": 1, "d": 4, "b": 2}
+
+	require.Equal(t, []string{"a", "b", "c", "d"}, utils.SortedKeys(m))
+	require.Equal(t, []string{"d", "c", "b", "a"}, utils.SortedKeysFunc(m, func(a, b string) int {
+		return strings.Compare(b, a)
+	}))
+	require.Empty(t, utils.SortedKeys(map[int]int(nil)))
+}
+
+func TestSortedRange(t *testing.T) {
+	t.Parallel()
+
+	m := map[int]string{3: "c", 1: "a", 2: "b"}
+
+	var keys []int
+	var values []string
+	for k, v := range utils.SortedRange(m) {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	require.Equal(t, []int{1, 2, 3}, keys)
+	require.Equal(t, []string{"a", "b", "c"}, values)
+
+	keys = nil
+	for k := range utils.SortedRangeFunc(m, utils.CompareByLess(func(a, b int) bool { return a > b })) {
+		keys = append(keys, k)
+		if k == 2 {
+			break
+		}
+	}
+	require.Equal(t, []int{3, 2}, keys)
+}
@@ -1,5 +1,7 @@
 package utils
 
+import "github.com/pkg/errors"
+
 func Must(err error) {
 	if err != nil {
 		panic(err)
@@ -12,3 +14,18 @@ func Must2[Ret any](r Ret, err error) Ret {
 	}
 	return r
 }
+
+// MustMsg is same as Must, but wraps error with context message before panicking.
+func MustMsg(err error, format string, args ...interface{}) {
+	if err != nil {
+		panic(errors.Wrapf(err, format, args...))
+	}
+}
+
+// Must2Msg is same as Must2, but wraps error with context message before panicking.
+func Must2Msg[Ret any](r Ret, err error, format string, args ...interface{}) Ret {
+	if err != nil {
+		panic(errors.Wrapf(err, format, args...))
+	}
+	return r
+}
@@ -0,0 +1,33 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMustMsg(t *testing.T) {
+	t.Parallel()
+
+	errBase := errors.New("base error")
+
+	require.NotPanics(t, func() { utils.MustMsg(nil, "loading %v", "config") })
+	require.Equal(t, 5, utils.Must2Msg(5, nil, "loading %v", "config"))
+
+	requirePanicErr := func(f func()) {
+		defer func() {
+			r := recover()
+			require.NotNil(t, r)
+			err, ok := r.(error)
+			require.True(t, ok)
+			require.ErrorIs(t, err, errBase)
+			require.Equal(t, "loading config: base error", err.Error())
+		}()
+		f()
+	}
+
+	requirePanicErr(func() { utils.MustMsg(errBase, "loading %v", "config") })
+	requirePanicErr(func() { utils.Must2Msg(5, errBase, "loading %v", "config") })
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// DoWithProfilerLabels calls f with pprof labels set for the current goroutine,
+// so that CPU profiles attribute time spent in f to those labels.
+// Labels are given as key-value pairs.
+//
+// If ctx is context.Context, labels are added to labels it already carries and f receives
+// the context with the labels. Otherwise labels are added to the labels of background context.
+func DoWithProfilerLabels[Ctx any](ctx Ctx, f func(ctx Ctx), labels ...string) {
+	parent, isContext := any(ctx).(context.Context)
+	if !isContext || parent == nil {
+		parent = context.Background()
+	}
+
+	pprof.Do(parent, pprof.Labels(labels...), func(labeled context.Context) {
+		if isContext {
+			if labeledCtx, ok := labeled.(Ctx); ok {
+				ctx = labeledCtx
+			}
+		}
+
+		f(ctx)
+	})
+}
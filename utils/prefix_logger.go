@@ -0,0 +1,70 @@
+package utils
+
+// WithPrefix returns Logger, which prepends prefix to all messages written into l.
+func WithPrefix(l Logger, prefix string) Logger {
+	if l == nil {
+		l = &NoopLogger{}
+	}
+
+	return &prefixLogger{l: l, prefix: prefix}
+}
+
+type prefixLogger struct {
+	l      Logger
+	prefix string
+}
+
+var _ Logger = &prefixLogger{}
+var _ KVLogger = &prefixLogger{}
+var _ LevelEnabler = &prefixLogger{}
+var _ callerKVLogger = &prefixLogger{}
+
+func (l *prefixLogger) Tracef(format string, args ...interface{}) {
+	l.l.Tracef("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Debugf(format string, args ...interface{}) {
+	l.l.Debugf("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Infof(format string, args ...interface{}) {
+	l.l.Infof("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Warnf(format string, args ...interface{}) {
+	l.l.Warnf("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Errorf(format string, args ...interface{}) {
+	l.l.Errorf("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Fatalf(format string, args ...interface{}) {
+	l.l.Fatalf("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) Panicf(format string, args ...interface{}) {
+	l.l.Panicf("%s"+format, l.withPrefix(args)...)
+}
+
+func (l *prefixLogger) LogKV(level LogLevel, msg string, kv ...interface{}) {
+	// Skipping runtime.Callers, logKV and this method.
+	l.logKV(3, level, msg, kv...)
+}
+
+func (l *prefixLogger) logKV(skip int, level LogLevel, msg string, kv ...interface{}) {
+	if cl, ok := l.l.(callerKVLogger); ok {
+		cl.logKV(skip+1, level, l.prefix+msg, kv...)
+		return
+	}
+
+	LogKV(l.l, level, l.prefix+msg, kv...)
+}
+
+func (l *prefixLogger) Enabled(level LogLevel) bool {
+	return LogEnabled(l.l, level)
+}
+
+func (l *prefixLogger) withPrefix(args []interface{}) []interface{} {
+	return append([]interface{}{l.prefix}, args...)
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Levels of slog, which are used for messages not having standard slog level.
+const (
+	SlogLevelTrace = slog.LevelDebug - 4
+	SlogLevelFatal = slog.LevelError + 4
+	SlogLevelPanic = slog.LevelError + 8
+)
+
+// SlogLevel converts LogLevel into corresponding slog level.
+func SlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelTrace:
+		return SlogLevelTrace
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return SlogLevelFatal
+	default:
+		return SlogLevelPanic
+	}
+}
+
+// NewSlogLogger returns Logger, which writes messages into the given slog.Logger.
+// Attributes and groups already attached to the slog.Logger are preserved.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+
+	return &SlogLogger{l: l}
+}
+
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+var _ Logger = &SlogLogger{}
+var _ KVLogger = &SlogLogger{}
+var _ LevelEnabler = &SlogLogger{}
+var _ callerKVLogger = &SlogLogger{}
+
+func (l *SlogLogger) Tracef(format string, args ...interface{}) {
+	l.log(SlogLevelTrace, format, args...)
+}
+
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.log(slog.LevelDebug, format, args...)
+}
+
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.log(slog.LevelInfo, format, args...)
+}
+
+func (l *SlogLogger) Warnf(format string, args ...interface{}) {
+	l.log(slog.LevelWarn, format, args...)
+}
+
+func (l *SlogLogger) Errorf(format string, args ...interface{}) {
+	l.log(slog.LevelError, format, args...)
+}
+
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.log(SlogLevelFatal, format, args...)
+	os.Exit(1)
+}
+
+func (l *SlogLogger) Panicf(format string, args ...interface{}) {
+	l.log(SlogLevelPanic, format, args...)
+	panic(fmt.Errorf(format, args...))
+}
+
+// LogKV writes message with key-value pairs as slog attributes.
+func (l *SlogLogger) LogKV(level LogLevel, msg string, kv ...interface{}) {
+	// Skipping runtime.Callers, logKV and this method.
+	l.logKV(3, level, msg, kv...)
+}
+
+func (l *SlogLogger) logKV(skip int, level LogLevel, msg string, kv ...interface{}) {
+	slogLevel := SlogLevel(level)
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(skip, pcs[:])
+
+	r := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	r.Add(kv...)
+	_ = l.l.Handler().Handle(ctx, r)
+}
+
+func (l *SlogLogger) Enabled(level LogLevel) bool {
+	return l.l.Enabled(context.Background(), SlogLevel(level))
+}
+
+// Slog returns underlying slog.Logger.
+func (l *SlogLogger) Slog() *slog.Logger {
+	return l.l
+}
+
+func (l *SlogLogger) log(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, level) {
+		return
+	}
+
+	// Skipping runtime.Callers, this function and the exported method to report the caller as source.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	r := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, args...), pcs[0])
+	_ = l.l.Handler().Handle(ctx, r)
+}
@@ -0,0 +1,105 @@
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger_LogKVSource(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	slogger := utils.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})))
+
+	_, file, line, _ := runtime.Caller(0)
+	slogger.LogKV(utils.LevelInfo, "method", "k", 1)
+	utils.LogKV(slogger, utils.LevelInfo, "func", "k", 2)
+	utils.LogKV(utils.WithPrefix(slogger, "p: "), utils.LevelInfo, "prefixed func", "k", 3)
+	utils.WithPrefix(slogger, "p: ").(utils.KVLogger).LogKV(utils.LevelInfo, "prefixed method", "k", 4)
+
+	type source struct {
+		File string `json:"file"`
+		Line int    `json:"line"`
+	}
+	type record struct {
+		Msg    string `json:"msg"`
+		K      int    `json:"k"`
+		Source source `json:"source"`
+	}
+
+	var records []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r record
+		require.NoError(t, dec.Decode(&r))
+		r.Source.File = filepath.Clean(r.Source.File)
+		records = append(records, r)
+	}
+
+	file = filepath.Clean(file)
+	require.Equal(t, []record{
+		{Msg: "method", K: 1, Source: source{File: file, Line: line + 1}},
+		{Msg: "func", K: 2, Source: source{File: file, Line: line + 2}},
+		{Msg: "p: prefixed func", K: 3, Source: source{File: file, Line: line + 3}},
+		{Msg: "p: prefixed method", K: 4, Source: source{File: file, Line: line + 4}},
+	}, records)
+}
+
+func TestSlogLogger_Levels(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: utils.SlogLevelTrace})
+	// Attributes attached to slog.Logger are preserved.
+	l := utils.NewSlogLogger(slog.New(h).With("svc", "store"))
+
+	l.Tracef("trace %v", 1)
+	l.Debugf("debug %v", 2)
+	l.Infof("info %v", 3)
+	l.Warnf("warn %v", 4)
+	l.Errorf("error %v", 5)
+	l.LogKV(utils.LevelInfo, "kv", "k", 6)
+	require.PanicsWithError(t, "panic 7", func() { l.Panicf("panic %v", 7) })
+
+	type record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		Svc   string `json:"svc"`
+		K     int    `json:"k,omitempty"`
+	}
+
+	var records []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r record
+		require.NoError(t, dec.Decode(&r))
+		records = append(records, r)
+	}
+
+	require.Equal(t, []record{
+		{Level: "DEBUG-4", Msg: "trace 1", Svc: "store"},
+		{Level: "DEBUG", Msg: "debug 2", Svc: "store"},
+		{Level: "INFO", Msg: "info 3", Svc: "store"},
+		{Level: "WARN", Msg: "warn 4", Svc: "store"},
+		{Level: "ERROR", Msg: "error 5", Svc: "store"},
+		{Level: "INFO", Msg: "kv", Svc: "store", K: 6},
+		{Level: "ERROR+8", Msg: "panic 7", Svc: "store"},
+	}, records)
+
+	// Level of the handler is respected.
+	buf.Reset()
+	l = utils.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	l.Infof("discarded")
+	l.LogKV(utils.LevelDebug, "discarded", "k", 1)
+	require.Empty(t, buf.String())
+	require.False(t, l.Enabled(utils.LevelInfo))
+	require.True(t, l.Enabled(utils.LevelWarn))
+	require.Equal(t, slog.LevelWarn, utils.SlogLevel(utils.LevelWarn))
+}
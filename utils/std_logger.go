@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// NewStdLogger returns Logger, which writes timestamped messages into stderr.
+// Messages with level lower than minLevel are discarded.
+// Fatalf and Panicf exit and panic even if their messages are discarded.
+func NewStdLogger(minLevel LogLevel) *StdLogger {
+	return NewStdLoggerTo(os.Stderr, minLevel)
+}
+
+// NewStdLoggerTo is same as NewStdLogger, but writes into w instead of stderr.
+func NewStdLoggerTo(w io.Writer, minLevel LogLevel) *StdLogger {
+	return &StdLogger{
+		l:        log.New(w, "", log.LstdFlags|log.Lmicroseconds),
+		minLevel: minLevel,
+	}
+}
+
+type StdLogger struct {
+	l        *log.Logger
+	minLevel LogLevel
+}
+
+var _ Logger = &StdLogger{}
+var _ KVLogger = &StdLogger{}
+var _ LevelEnabler = &StdLogger{}
+
+func (l *StdLogger) Tracef(format string, args ...interface{}) {
+	l.log(LevelTrace, format, args...)
+}
+
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	l.log(LevelDebug, format, args...)
+}
+
+func (l *StdLogger) Infof(format string, args ...interface{}) {
+	l.log(LevelInfo, format, args...)
+}
+
+func (l *StdLogger) Warnf(format string, args ...interface{}) {
+	l.log(LevelWarn, format, args...)
+}
+
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.log(LevelError, format, args...)
+}
+
+func (l *StdLogger) Fatalf(format string, args ...interface{}) {
+	l.log(LevelFatal, format, args...)
+	os.Exit(1)
+}
+
+func (l *StdLogger) Panicf(format string, args ...interface{}) {
+	l.log(LevelPanic, format, args...)
+	panic(fmt.Errorf(format, args...))
+}
+
+func (l *StdLogger) log(level LogLevel, format string, args ...interface{}) {
+	if level < l.minLevel {
+		return
+	}
+
+	l.l.Printf("%-5v %v", level, fmt.Sprintf(format, args...))
+}
+
+func (l *StdLogger) LogKV(level LogLevel, msg string, kv ...interface{}) {
+	if level < l.minLevel {
+		return
+	}
+
+	l.l.Printf("%-5v %v%v", level, msg, formatKV(kv))
+}
+
+func (l *StdLogger) Enabled(level LogLevel) bool {
+	return level >= l.minLevel
+}
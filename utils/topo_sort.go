@@ -3,10 +3,35 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"iter"
+	"maps"
+	"slices"
 )
 
+// GraphView is a read-only view of a directed graph.
+// It allows to sort graphs stored in any structure without copying them into Graph first.
+type GraphView[Key comparable] interface {
+	// Nodes returns all nodes of the graph.
+	// The order of nodes is used as a tie-breaker when sorting.
+	Nodes() iter.Seq[Key]
+
+	// Edges returns nodes, to which the node points.
+	Edges(node Key) iter.Seq[Key]
+}
+
 type Graph[Key comparable] map[Key][]Key
 
+var _ GraphView[int] = Graph[int]{}
+
+// Nodes returns all nodes of the graph in random order.
+func (g Graph[Key]) Nodes() iter.Seq[Key] {
+	return maps.Keys(g)
+}
+
+func (g Graph[Key]) Edges(node Key) iter.Seq[Key] {
+	return slices.Values(g[node])
+}
+
 var ErrCyclicDependecies = errors.New("cyclic dependencies")
 
 func StableTopologicalSortWithSortedKeys[Key comparable](graph Graph[Key], sortedKeys []Key) ([]Key, error) {
@@ -14,30 +39,37 @@ func StableTopologicalSortWithSortedKeys[Key comparable](graph Graph[Key], sorte
 		return nil, fmt.Errorf("wrong sorted keys info: len(sortedKeys) != len(graph): %v != %v", len(sortedKeys), len(graph))
 	}
 
+	return StableTopologicalSort[Key](&sortedGraph[Key]{graph: graph, sortedKeys: sortedKeys})
+}
+
+// StableTopologicalSort returns nodes in an order, in which each node is placed before all nodes it points to.
+// Nodes, which do not depend on each other, keep the order in which they are returned by graph.Nodes().
+func StableTopologicalSort[Key comparable](graph GraphView[Key]) ([]Key, error) {
 	inDegree := make(map[Key]int)
+	nodesCount := 0
 
-	for _, key := range sortedKeys {
-		dependencies := graph[key]
+	for node := range graph.Nodes() {
+		nodesCount++
 
-		for _, dependency := range dependencies {
+		for dependency := range graph.Edges(node) {
 			inDegree[dependency]++
 		}
 	}
 
 	queue := make([]Key, 0)
-	for _, node := range sortedKeys {
+	for node := range graph.Nodes() {
 		if inDegree[node] == 0 {
 			queue = append(queue, node)
 		}
 	}
 
-	result := make([]Key, 0)
+	result := make([]Key, 0, nodesCount)
 	for len(queue) > 0 {
 		node := queue[0]
 		queue = queue[1:]
 		result = append(result, node)
 
-		for _, neighbor := range graph[node] {
+		for neighbor := range graph.Edges(node) {
 			inDegree[neighbor]--
 			if inDegree[neighbor] == 0 {
 				queue = append(queue, neighbor)
@@ -45,9 +77,26 @@ func StableTopologicalSortWithSortedKeys[Key comparable](graph Graph[Key], sorte
 		}
 	}
 
-	if len(result) != len(graph) {
+	if len(result) != nodesCount {
 		return nil, fmt.Errorf("%w: %v, %v", ErrCyclicDependecies, result, graph)
 	}
 
 	return result, nil
 }
+
+type sortedGraph[Key comparable] struct {
+	graph      Graph[Key]
+	sortedKeys []Key
+}
+
+func (g *sortedGraph[Key]) Nodes() iter.Seq[Key] {
+	return slices.Values(g.sortedKeys)
+}
+
+func (g *sortedGraph[Key]) Edges(node Key) iter.Seq[Key] {
+	return g.graph.Edges(node)
+}
+
+func (g *sortedGraph[Key]) String() string {
+	return fmt.Sprintf("%v", g.graph)
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/nnikolash/go-shdep/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStableTopologicalSortWithSortedKeys(t *testing.T) {
+	t.Parallel()
+
+	graph := utils.Graph[string]{
+		"app":    {"db", "cache"},
+		"cache":  {"config"},
+		"db":     {"config"},
+		"config": nil,
+		"log":    nil,
+	}
+
+	sorted, err := utils.StableTopologicalSortWithSortedKeys(graph, []string{"log", "app", "db", "cache", "config"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"log", "app", "db", "cache", "config"}, sorted)
+
+	sorted, err = utils.StableTopologicalSortWithSortedKeys(graph, []string{"config", "cache", "db", "app", "log"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"app", "log", "db", "cache", "config"}, sorted)
+
+	_, err = utils.StableTopologicalSortWithSortedKeys(graph, []string{"app"})
+	require.Error(t, err)
+}
+
+func TestStableTopologicalSort_Cycle(t *testing.T) {
+	t.Parallel()
+
+	graph := utils.Graph[int]{
+		1: {2},
+		2: {3},
+		3: {1},
+		4: nil,
+	}
+
+	_, err := utils.StableTopologicalSort[int](graph)
+	require.ErrorIs(t, err, utils.ErrCyclicDependecies)
+}
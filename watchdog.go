@@ -0,0 +1,221 @@
+package shdep
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nnikolash/go-shdep/objstore"
+	"github.com/nnikolash/go-shdep/shdepexec"
+	"github.com/nnikolash/go-shdep/updtree"
+)
+
+// StallEvent is published by Watchdog, when watched object has not updated within the interval.
+type StallEvent struct {
+	// ID of the object as returned by ObjectID.
+	ObjectID string
+	Name     string
+	// Time of the last update of the object, or time of start of the watchdog, if it has not updated at all.
+	LastUpdate time.Time
+	Detected   time.Time
+}
+
+// Watchdog is a shared object, which detects stalled producers, e.g. dead data feeds. It subscribes to watched objects
+// and publishes StallEvent, when any of them has not updated within the interval. Each stall is reported once,
+// until the object updates again. Time is measured by clock of init params (see ClockProvider) or updtree.SetClock,
+// and events are posted into executor provided by init params, which must implement ExecutorProvider.
+type Watchdog[Ctx, InitParams any] struct {
+	SharedObjectBaseWithEvent[Ctx, InitParams, StallEvent]
+	interval time.Duration
+	watched  []SharedObject[Ctx, InitParams]
+
+	lock       sync.Mutex
+	exec       shdepexec.Executor[Ctx]
+	clock      updtree.Clock
+	stopCheck  func() bool
+	running    bool
+	lastUpdate []time.Time
+	stalled    []bool
+	onStall    []func(ctx Ctx, evt StallEvent)
+}
+
+// NewWatchdog creates watchdog of objects, which must update at least once per interval.
+// Watchdogs with same interval and same watched objects are shared.
+func NewWatchdog[Ctx, InitParams any](interval time.Duration, watched ...SharedObject[Ctx, InitParams]) *Watchdog[Ctx, InitParams] {
+	if interval <= 0 {
+		panic(fmt.Sprintf("interval of watchdog must be positive, but got %v", interval))
+	}
+
+	params := []interface{}{interval}
+	for _, obj := range watched {
+		params = append(params, ObjectID(obj))
+	}
+
+	w := &Watchdog[Ctx, InitParams]{
+		SharedObjectBaseWithEvent: NewSharedObjectBaseWithEvent[Ctx, InitParams, StallEvent]("Watchdog", params...),
+		interval:                  interval,
+		watched:                   slices.Clone(watched),
+		lastUpdate:                make([]time.Time, len(watched)),
+		stalled:                   make([]bool, len(watched)),
+	}
+
+	w.SetUpdateHandler(w.onWatchedUpdated)
+
+	return w
+}
+
+// OnStall adds callback, which is called with each published StallEvent. Callbacks must be added to registered replica
+// of the watchdog, e.g. after Register, because callbacks are not part of its hash.
+func (w *Watchdog[Ctx, InitParams]) OnStall(callback func(ctx Ctx, evt StallEvent)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.onStall = append(w.onStall, callback)
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (w *Watchdog[Ctx, InitParams]) RegisterDependencies(store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]) {
+	for i, obj := range w.watched {
+		replica, ok := objstore.RegisterObject(store, obj, nil)
+		if !ok {
+			continue
+		}
+
+		w.watched[i] = replica
+		replica.Subscribe(w.GetUpdateNode())
+	}
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Starts measuring time since last updates.
+func (w *Watchdog[Ctx, InitParams]) Start(params InitParams) error {
+	provider, ok := any(params).(ExecutorProvider[Ctx])
+	if !ok {
+		return fmt.Errorf("init params of type %T must implement ExecutorProvider to run %v", params, w.Name())
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.exec = provider.Executor()
+	w.clock = clockOf(params)
+	w.running = true
+
+	now := w.clock.Now()
+	for i := range w.watched {
+		w.lastUpdate[i], w.stalled[i] = now, false
+	}
+	w.schedule()
+
+	return nil
+}
+
+// One of lifecycle methods. See SharedObject interface for details. Stops detection. Events already posted into executor are dropped.
+func (w *Watchdog[Ctx, InitParams]) Stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.running = false
+	if w.stopCheck != nil {
+		w.stopCheck()
+		w.stopCheck = nil
+	}
+}
+
+// Stalled returns watched objects, which are stalled now.
+func (w *Watchdog[Ctx, InitParams]) Stalled() []SharedObject[Ctx, InitParams] {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var stalled []SharedObject[Ctx, InitParams]
+	for i, obj := range w.watched {
+		if w.stalled[i] {
+			stalled = append(stalled, obj)
+		}
+	}
+
+	return stalled
+}
+
+func (w *Watchdog[Ctx, InitParams]) onWatchedUpdated(ctx Ctx, evtTime time.Time) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.running {
+		return
+	}
+
+	now := w.clock.Now()
+	for i, obj := range w.watched {
+		if obj.GetUpdateNode().HasUpdated() {
+			w.lastUpdate[i], w.stalled[i] = now, false
+		}
+	}
+
+	// Recovered objects must be checked again, but check may not be scheduled, if all objects have been stalled.
+	if w.stopCheck == nil {
+		w.schedule()
+	}
+}
+
+// schedule schedules check at the earliest deadline of objects, which are not stalled. Must be called under lock.
+func (w *Watchdog[Ctx, InitParams]) schedule() {
+	var deadline time.Time
+	for i, last := range w.lastUpdate {
+		if !w.stalled[i] && (deadline.IsZero() || last.Before(deadline)) {
+			deadline = last
+		}
+	}
+	if deadline.IsZero() {
+		w.stopCheck = nil
+		return
+	}
+	deadline = deadline.Add(w.interval)
+
+	w.stopCheck = w.clock.AfterFunc(deadline.Sub(w.clock.Now()), func(now time.Time) {
+		w.lock.Lock()
+		if !w.running {
+			w.lock.Unlock()
+			return
+		}
+
+		var events []StallEvent
+		for i, obj := range w.watched {
+			if !w.stalled[i] && now.Sub(w.lastUpdate[i]) >= w.interval {
+				w.stalled[i] = true
+				events = append(events, StallEvent{ObjectID: ObjectID(obj), Name: obj.Name(), LastUpdate: w.lastUpdate[i], Detected: now})
+			}
+		}
+
+		w.schedule()
+		exec := w.exec
+		w.lock.Unlock()
+
+		if len(events) != 0 {
+			exec.Post(w.report(events))
+		}
+	})
+}
+
+func (w *Watchdog[Ctx, InitParams]) report(events []StallEvent) func(ctx Ctx) {
+	return func(ctx Ctx) {
+		w.lock.Lock()
+		running := w.running
+		callbacks := w.onStall
+		w.lock.Unlock()
+
+		if !running {
+			return
+		}
+
+		for _, evt := range events {
+			for _, callback := range callbacks {
+				callback(ctx, evt)
+			}
+			w.PublishEvent(ctx, evt.Detected, evt)
+		}
+	}
+}
+
+var _ SharedObject[context.Context, string] = &Watchdog[context.Context, string]{}
@@ -0,0 +1,69 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/nnikolash/go-shdep/simtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	params := &tickParams{sched: simtime.NewScheduler(start, context.Background)}
+
+	store := shdep.NewSharedStore[context.Context, *tickParams](nil)
+
+	var feeds []*shdep.FuncObject[context.Context, *tickParams]
+	for i := 1; i <= 2; i++ {
+		feed := shdep.NewFuncObject("feed", []interface{}{i}, shdep.FuncObjectSpec[context.Context, *tickParams]{})
+		store.Register(&feed)
+		feeds = append(feeds, feed)
+	}
+
+	watchdog := shdep.NewWatchdog[context.Context, *tickParams](time.Minute, shdep.NewFuncObject("feed", []interface{}{1}, shdep.FuncObjectSpec[context.Context, *tickParams]{}), feeds[1])
+	store.Register(&watchdog)
+	sameWatchdog := shdep.NewWatchdog[context.Context, *tickParams](time.Minute, feeds[0], feeds[1])
+	store.Register(&sameWatchdog)
+	require.Same(t, watchdog, sameWatchdog)
+
+	var stalled []string
+	watchdog.OnStall(func(ctx context.Context, evt shdep.StallEvent) {
+		stalled = append(stalled, evt.Name+" "+evt.LastUpdate.Format(time.TimeOnly)+" "+evt.Detected.Format(time.TimeOnly))
+	})
+	events := watchdog.NewEventPuller()
+
+	require.NoError(t, store.Init(params))
+	require.NoError(t, store.Start())
+
+	// First feed updates each 30 seconds, second stops after 40 seconds.
+	stop := params.sched.Every(30*time.Second, func(ctx context.Context, now time.Time) {
+		feeds[0].NotifyUpdated(ctx, now)
+	})
+	params.sched.NotifyAt(start.Add(40*time.Second), feeds[1])
+
+	params.sched.AdvanceTo(start.Add(5 * time.Minute))
+	require.Equal(t, []string{"feed 10:00:40 10:01:40"}, stalled)
+	require.Equal(t, []shdep.SharedObject[context.Context, *tickParams]{feeds[1]}, watchdog.Stalled())
+	require.Len(t, events.Pull(), 1)
+
+	// Stall is reported again only after recovery.
+	params.sched.NotifyAt(start.Add(5*time.Minute), feeds[1])
+	params.sched.AdvanceTo(start.Add(5*time.Minute + 30*time.Second))
+	require.Empty(t, watchdog.Stalled())
+	params.sched.AdvanceTo(start.Add(7 * time.Minute))
+	require.Equal(t, []string{"feed 10:00:40 10:01:40", "feed 10:05:00 10:06:00"}, stalled)
+
+	stop()
+	params.sched.AdvanceTo(start.Add(10 * time.Minute))
+	require.Len(t, watchdog.Stalled(), 2)
+	require.Len(t, stalled, 3)
+
+	require.NoError(t, store.StopE())
+	require.Equal(t, 0, params.sched.Pending())
+	require.NoError(t, store.CloseE())
+}
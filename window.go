@@ -0,0 +1,161 @@
+package shdep
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+
+	"github.com/nnikolash/go-shdep/objstore"
+)
+
+// WindowOpts defines which values are kept by Window. At least one of limits must be set.
+// If both are set, values must satisfy both of them.
+type WindowOpts struct {
+	// Maximum number of kept values.
+	Size int `json:"size,omitempty"`
+	// Maximum age of kept values relative to event time of the last update.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Window is a shared object, which keeps rolling window of recent values of the source, e.g. last prices for
+// a moving average. On each update of the source it reads value using function passed into NewWindow, evicts values,
+// which do not fit into the window anymore, and notifies subscribers. Append and eviction take constant time.
+type Window[Ctx, InitParams, T any, Src SharedObject[Ctx, InitParams]] struct {
+	SharedObjectBase[Ctx, InitParams]
+	source Src
+	read   func(src Src) T
+	opts   WindowOpts
+
+	// Ring buffer of entries. Entries in use start at index start and wrap around.
+	entries []windowEntry[T]
+	start   int
+	count   int
+}
+
+type windowEntry[T any] struct {
+	time  time.Time
+	value T
+}
+
+// NewWindow creates window of values of the source. Function read reads value from the source, when it updates.
+// Name must be different for windows with different functions, because functions are not part of the hash.
+// Windows with same name and options over the same source are shared.
+func NewWindow[Ctx, InitParams, T any, Src SharedObject[Ctx, InitParams]](name string, source Src, read func(src Src) T, opts WindowOpts) *Window[Ctx, InitParams, T, Src] {
+	if opts.Size <= 0 && opts.Duration <= 0 {
+		panic(fmt.Sprintf("neither size nor duration is set for window %v", name))
+	}
+
+	w := &Window[Ctx, InitParams, T, Src]{
+		SharedObjectBase: NewSharedObjectBase[Ctx, InitParams](name, reflect.TypeOf(source).String(), source.Hash(), opts),
+		source:           source,
+		read:             read,
+		opts:             opts,
+	}
+	if opts.Size > 0 {
+		w.entries = make([]windowEntry[T], opts.Size)
+	}
+
+	w.SetUpdateHandler(w.onSourceUpdated)
+
+	return w
+}
+
+// One of lifecycle methods. See SharedObject interface for details.
+func (w *Window[Ctx, InitParams, T, Src]) RegisterDependencies(store objstore.SharedStore[SharedObject[Ctx, InitParams], InitParams]) {
+	store.Register(&w.source)
+	w.source.Subscribe(w.GetUpdateNode())
+}
+
+func (w *Window[Ctx, InitParams, T, Src]) onSourceUpdated(ctx Ctx, evtTime time.Time) {
+	w.push(evtTime, w.read(w.source))
+
+	if w.opts.Duration > 0 {
+		oldest := evtTime.Add(-w.opts.Duration)
+		for w.count > 0 && !w.entries[w.start].time.After(oldest) {
+			w.evict()
+		}
+	}
+
+	w.NotifyUpdated(ctx, evtTime)
+}
+
+func (w *Window[Ctx, InitParams, T, Src]) push(evtTime time.Time, value T) {
+	if w.count == len(w.entries) {
+		if w.opts.Size > 0 {
+			w.evict()
+		} else {
+			// Window limited only by duration grows, so that append takes constant time on average.
+			grown := make([]windowEntry[T], max(2*len(w.entries), 8))
+			for i := 0; i < w.count; i++ {
+				grown[i] = w.entries[(w.start+i)%len(w.entries)]
+			}
+			w.entries, w.start = grown, 0
+		}
+	}
+
+	w.entries[(w.start+w.count)%len(w.entries)] = windowEntry[T]{time: evtTime, value: value}
+	w.count++
+}
+
+func (w *Window[Ctx, InitParams, T, Src]) evict() {
+	w.entries[w.start] = windowEntry[T]{}
+	w.start = (w.start + 1) % len(w.entries)
+	w.count--
+}
+
+// Source returns the source of values.
+func (w *Window[Ctx, InitParams, T, Src]) Source() Src {
+	return w.source
+}
+
+// Len returns number of values in the window.
+func (w *Window[Ctx, InitParams, T, Src]) Len() int {
+	return w.count
+}
+
+// At returns value with index i, where 0 is the oldest value, and event time of the update, when it was read.
+func (w *Window[Ctx, InitParams, T, Src]) At(i int) (T, time.Time) {
+	if i < 0 || i >= w.count {
+		panic(fmt.Sprintf("index %v is out of range of window %v with %v values", i, w.Name(), w.count))
+	}
+
+	e := w.entries[(w.start+i)%len(w.entries)]
+	return e.value, e.time
+}
+
+// Last returns the latest value, or false if the window is empty.
+func (w *Window[Ctx, InitParams, T, Src]) Last() (T, bool) {
+	if w.count == 0 {
+		var zero T
+		return zero, false
+	}
+
+	v, _ := w.At(w.count - 1)
+	return v, true
+}
+
+// Values returns copy of values from the oldest to the latest.
+func (w *Window[Ctx, InitParams, T, Src]) Values() []T {
+	values := make([]T, 0, w.count)
+	for _, v := range w.All() {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// All iterates over event times and values from the oldest to the latest without copying them.
+func (w *Window[Ctx, InitParams, T, Src]) All() iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		for i := 0; i < w.count; i++ {
+			e := w.entries[(w.start+i)%len(w.entries)]
+			if !yield(e.time, e.value) {
+				return
+			}
+		}
+	}
+}
+
+var _ SharedObject[context.Context, string] = &Window[context.Context, string, int, *SharedObjectBase[context.Context, string]]{}
@@ -0,0 +1,67 @@
+package shdep_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-shdep"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	store := NewSharedStore(nil)
+
+	c := newCounter()
+	store.Register(&c)
+
+	readValue := func(c *counter) int { return c.value }
+	last := shdep.NewWindow[context.Context, *InitParams]("lastValues", newCounter(), readValue, shdep.WindowOpts{Size: 3})
+	store.Register(&last)
+	sameLast := shdep.NewWindow[context.Context, *InitParams]("lastValues", newCounter(), readValue, shdep.WindowOpts{Size: 3})
+	store.Register(&sameLast)
+	recent := shdep.NewWindow[context.Context, *InitParams]("recentValues", newCounter(), readValue, shdep.WindowOpts{Duration: time.Minute})
+	store.Register(&recent)
+
+	require.Same(t, last, sameLast)
+
+	require.NoError(t, store.Init(&InitParams{}))
+	require.Same(t, c, last.Source())
+	require.Same(t, c, recent.Source())
+	require.NoError(t, store.Start())
+
+	_, ok := last.Last()
+	require.False(t, ok)
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 1; i <= 20; i++ {
+		c.value = i
+		c.NotifyUpdated(context.Background(), start.Add(time.Duration(i)*10*time.Second))
+	}
+
+	require.Equal(t, []int{18, 19, 20}, last.Values())
+	v, evtTime := last.At(0)
+	require.Equal(t, 18, v)
+	require.Equal(t, start.Add(180*time.Second), evtTime)
+	v, ok = last.Last()
+	require.True(t, ok)
+	require.Equal(t, 20, v)
+
+	require.Equal(t, 6, recent.Len())
+	require.Equal(t, []int{15, 16, 17, 18, 19, 20}, recent.Values())
+
+	var times []time.Time
+	for evtTime := range recent.All() {
+		times = append(times, evtTime)
+		if len(times) == 2 {
+			break
+		}
+	}
+	require.Equal(t, []time.Time{start.Add(150 * time.Second), start.Add(160 * time.Second)}, times)
+	require.Panics(t, func() { last.At(3) })
+
+	require.NoError(t, store.StopE())
+	require.NoError(t, store.CloseE())
+}